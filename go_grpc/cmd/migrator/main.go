@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"go_grpc/internal/lib/emailaddr"
+	"go_grpc/internal/storage/sqlite"
 
 	// Библиотека для миграций
 	"github.com/golang-migrate/migrate/v4"
@@ -40,10 +47,42 @@ func main() {
 
 	// Применение миграций
 	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			fmt.Println("no migrations to apply")
-			return
+		if !errors.Is(err, migrate.ErrNoChange) {
+			panic(err)
 		}
-		panic(err)
+		fmt.Println("no migrations to apply")
+	}
+
+	// Email хранится нормализованным. SQL-миграция меняет регистр только
+	// у ASCII, поэтому остальное доделываем той же функцией, что и сервис
+	if err := normalizeEmails(storagePath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// normalizeEmails нормализует email пользователей. Если после нормализации
+// адреса совпадают, они выводятся, и мигратор завершается с ошибкой:
+// такие аккаунты надо объединить вручную и запустить его снова
+func normalizeEmails(storagePath string) error {
+	storage, err := sqlite.New(storagePath)
+	if err != nil {
+		return err
 	}
+	defer storage.Close()
+
+	conflicts, err := storage.NormalizeEmails(context.Background(), emailaddr.Normalize)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	for _, normalized := range slices.Sorted(maps.Keys(conflicts)) {
+		fmt.Fprintf(os.Stderr, "%s: %q\n", normalized, conflicts[normalized])
+	}
+
+	return fmt.Errorf("normalized emails shared by several users: %d, merge these accounts manually and run the migrator again", len(conflicts))
 }
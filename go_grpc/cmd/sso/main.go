@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
 	"go_grpc/internal/app"
 	"go_grpc/internal/config"
+	"go_grpc/internal/lib/logger/handlers/slogredact"
 )
 
 // Константы окружений
@@ -22,10 +25,16 @@ func main() {
 	cfg := config.MustLoad()
 
 	// Настройка логгера
-	log := setupLogger(cfg.Env)
+	log := setupLogger(cfg.Env, cfg.Log)
 
 	// Создание приложения
-	application := app.New(log, cfg.GRPC.Port, cfg.StoragePath, cfg.TokenTTL)
+	application := app.New(log, cfg)
+
+	// Ротация ключей подписи в фоне
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go application.KeyRotator.Run(ctx)
 
 	// Запуск gRPC сервера в горутине
 	go func() {
@@ -34,6 +43,15 @@ func main() {
 		}
 	}()
 
+	// Запуск HTTP сервера с JWKS, если он включен
+	if application.HTTPServer != nil {
+		go func() {
+			if err := application.HTTPServer.Run(); err != nil {
+				log.Error("http server error", slog.Any("error", err))
+			}
+		}()
+	}
+
 	// Graceful shutdown
 
 	// Канал для сигналов ОС
@@ -47,29 +65,28 @@ func main() {
 	log.Info("Received signal", slog.String("signal", sig.String()))
 
 	// graceful shutdown
+	cancel()
 	application.GRPCServer.Stop()
 	application.Stop()
 	log.Info("Gracefully stopped")
 }
 
 // настройка логгера в зависимости от окружения
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+func setupLogger(env string, logCfg config.LogConfig) *slog.Logger {
+	var handler slog.Handler
 
 	switch env {
 	case envLocal:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
+		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
 	}
 
-	return log
+	// Пароли и токены (в том числе внутри gRPC-сообщений) не попадают в лог
+	return slog.New(slogredact.NewHandler(handler, slogredact.Options{
+		Keys:        append(slices.Clone(slogredact.DefaultKeys), logCfg.RedactKeys...),
+		QueryParams: append(slices.Clone(slogredact.DefaultQueryParams), logCfg.RedactQueryParams...),
+	}))
 }
@@ -1,50 +1,207 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	grpcapp "go_grpc/internal/app/grpc"
+	httpapp "go_grpc/internal/app/http"
+	"go_grpc/internal/config"
+	"go_grpc/internal/grpc/ratelimit"
+	"go_grpc/internal/lib/mail"
+	"go_grpc/internal/lib/password"
+	"go_grpc/internal/lib/secretbox"
 	"go_grpc/internal/services/auth"
+	"go_grpc/internal/services/keys"
 	"go_grpc/internal/storage/sqlite"
+
+	ssov1 "go_grpc/gen/go/sso"
 )
 
+// сколько при остановке ждать отправки писем из очереди
+const mailShutdownTimeout = 30 * time.Second
+
 // главное приложение, объединяющее все компоненты
 type App struct {
 	GRPCServer *grpcapp.App
+	HTTPServer *httpapp.App // nil, если HTTP сервер выключен
+	KeyRotator *keys.Rotator
+	Auth       *auth.Auth
 	Storage    *sqlite.Storage
 }
 
-// инициализация приложения
-func New(
-	log *slog.Logger,
-	grpcPort int,
-	storagePath string,
-	tokenTTL time.Duration,
-) *App {
+// инициализация приложения по конфигурации
+func New(log *slog.Logger, cfg *config.Config) *App {
 	// Инициализация хранилища
-	storage, err := sqlite.New(storagePath)
+	storage, err := sqlite.New(cfg.StoragePath)
 	if err != nil {
 		panic(err)
 	}
 
 	// Создание сервиса аутентификации
-	authService := auth.New(log, storage, storage, storage, tokenTTL)
+	lockout := auth.LockoutPolicy{
+		MaxFailures: cfg.Login.MaxFailures,
+		Lockout:     cfg.Login.Lockout,
+		MaxLockout:  cfg.Login.MaxLockout,
+		Window:      cfg.Login.FailureWindow,
+	}
+
+	mailer, err := newMailSender(log, cfg.Mail)
+	if err != nil {
+		panic(err)
+	}
+
+	accountPolicy := auth.AccountPolicy{
+		RequireVerifiedEmail: !cfg.Accounts.AllowUnverifiedLogin,
+		VerifyTokenTTL:       cfg.Accounts.VerifyTokenTTL,
+		ResetTokenTTL:        cfg.Accounts.ResetTokenTTL,
+		VerifyURL:            cfg.Accounts.VerifyURL,
+		ResetURL:             cfg.Accounts.ResetURL,
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	twoFactor := auth.TwoFactorPolicy{
+		Issuer:       cfg.TwoFactor.Issuer,
+		ChallengeTTL: cfg.TwoFactor.ChallengeTTL,
+		MaxAttempts:  cfg.TwoFactor.MaxAttempts,
+	}
+
+	// Без ключа шифрования секреты TOTP хранить нельзя, 2FA выключена
+	if cfg.TwoFactor.EncryptionKey != "" {
+		twoFactor.Cipher, err = secretbox.NewFromBase64(cfg.TwoFactor.EncryptionKey)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// Закрытые ключи подписи хранятся в БД зашифрованными
+	var signingCipher *secretbox.Box
+	if cfg.Signing.EncryptionKey != "" {
+		signingCipher, err = secretbox.NewFromBase64(cfg.Signing.EncryptionKey)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// Старый ключ принимается, пока не истекут подписанные им токены
+	keyRotator, err := keys.New(log, storage, cfg.Signing.Algorithm, cfg.Signing.RotationPeriod, cfg.TokenTTL, signingCipher)
+	if err != nil {
+		panic(err)
+	}
+
+	authService := auth.New(auth.Deps{
+		Log:            log,
+		Storage:        storage,
+		Mailer:         mailer,
+		AppKeys:        keyRotator,
+		SigningCipher:  signingCipher,
+		Lockout:        lockout,
+		AccountPolicy:  accountPolicy,
+		PasswordPolicy: passwordPolicy,
+		TwoFactor:      twoFactor,
+		TokenTTL:       cfg.TokenTTL,
+		RefreshTTL:     cfg.RefreshTTL,
+		ClockSkew:      cfg.ClockSkew,
+	})
+
+	// Лимит запросов с одного адреса для методов, где перебирают пароли и токены.
+	// Проверка токенов вызывается сервисами часто, ее не ограничиваем
+	var limiter *ratelimit.Limiter
+	if cfg.Login.RateLimit > 0 {
+		limiter = ratelimit.New(cfg.Login.RateLimit, cfg.Login.RateBurst,
+			ssov1.Auth_Login_FullMethodName,
+			ssov1.Auth_LoginVerify2FA_FullMethodName,
+			ssov1.Auth_Register_FullMethodName,
+			ssov1.Auth_Refresh_FullMethodName,
+			ssov1.Auth_VerifyEmail_FullMethodName,
+			ssov1.Auth_RequestPasswordReset_FullMethodName,
+			ssov1.Auth_ResetPassword_FullMethodName,
+		)
+	}
 
 	// Создание gRPC приложения
-	grpcApp := grpcapp.New(log, authService, grpcPort)
+	grpcApp := grpcapp.New(log, authService, cfg.GRPC.Port, limiter)
+
+	var httpApp *httpapp.App
+	if cfg.HTTP.Port != 0 {
+		httpApp = httpapp.New(log, authService, cfg.HTTP.Port)
+	}
 
 	return &App{
 		GRPCServer: grpcApp,
+		HTTPServer: httpApp,
+		KeyRotator: keyRotator,
+		Auth:       authService,
 		Storage:    storage,
 	}
 }
 
+// newMailSender выбирает способ отправки писем по конфигу
+func newMailSender(log *slog.Logger, cfg config.MailConfig) (mail.Sender, error) {
+	switch cfg.Sender {
+	case "log":
+		return mail.NewLog(log), nil
+	case "file":
+		return mail.NewFile(cfg.Dir, cfg.From)
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil, errors.New("mail: smtp.host is required")
+		}
+
+		return mail.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("mail: unknown sender %q", cfg.Sender)
+	}
+}
+
+// newPasswordPolicy собирает требования к паролям. Список утекших
+// паролей подключается, только если указан его каталог
+func newPasswordPolicy(cfg config.PasswordConfig) (password.Policy, error) {
+	policy := password.Policy{
+		MinLength:     cfg.MinLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+	}
+
+	if cfg.BreachedDir != "" {
+		list, err := password.NewBreachList(cfg.BreachedDir, cfg.BreachedMinCount)
+		if err != nil {
+			return password.Policy{}, err
+		}
+
+		policy.Breached = list
+	}
+
+	return policy, nil
+}
+
 // остановка приложения с graceful shutdown
 func (a *App) Stop() {
 	// Остановка gRPC сервера
 	a.GRPCServer.Stop()
 
+	// Остановка HTTP сервера
+	if a.HTTPServer != nil {
+		a.HTTPServer.Stop()
+	}
+
+	// Письма из очереди еще используют БД, дожидаемся их до ее закрытия
+	ctx, cancel := context.WithTimeout(context.Background(), mailShutdownTimeout)
+	defer cancel()
+
+	if err := a.Auth.Close(ctx); err != nil {
+		slog.Error("failed to send queued mail", slog.Any("error", err))
+	}
+
 	// Закрытие соединения с БД
 	if err := a.Storage.Close(); err != nil {
 		slog.Error("failed to close storage", slog.Any("error", interface{}(err)))
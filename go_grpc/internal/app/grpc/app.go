@@ -7,6 +7,7 @@ import (
 	"net"
 
 	authgrpc "go_grpc/internal/grpc/auth"
+	"go_grpc/internal/grpc/ratelimit"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
@@ -22,8 +23,9 @@ type App struct {
 	port       int // Порт, на котором будет работать grpc-сервер
 }
 
-// создание нового gRPC приложения
-func New(log *slog.Logger, authService authgrpc.Auth, port int) *App {
+// создание нового gRPC приложения. limiter ограничивает частоту
+// запросов с одного адреса, nil - без ограничения
+func New(log *slog.Logger, authService authgrpc.Auth, port int, limiter *ratelimit.Limiter) *App {
 	// Настройки логирования
 	loggingOpts := []logging.Option{
 		logging.WithLogOnEvents(
@@ -40,11 +42,18 @@ func New(log *slog.Logger, authService authgrpc.Auth, port int) *App {
 		}),
 	}
 
-	// Создаем gRPC сервер с единственным интерсептором
-	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+	interceptors := []grpc.UnaryServerInterceptor{
 		recovery.UnaryServerInterceptor(recoveryOpts...),
 		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
-	))
+	}
+
+	// Лимит проверяется после логирования, чтобы отказы попадали в лог
+	if limiter != nil {
+		interceptors = append(interceptors, limiter.UnaryServerInterceptor())
+	}
+
+	// Создаем gRPC сервер с цепочкой интерсепторов
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
 
 	// Регистрация сервиса аутентификации
 	authgrpc.Register(gRPCServer, authService)
@@ -58,17 +67,10 @@ func New(log *slog.Logger, authService authgrpc.Auth, port int) *App {
 	}
 }
 
-// адаптер slog.Logger для gRPC интерсептора с маскировкой паролей
+// адаптер slog.Logger для gRPC интерсептора. Пароли и токены в содержимом
+// запросов и ответов скрывает обработчик slogredact, настроенный в main
 func InterceptorLogger(l *slog.Logger) logging.Logger {
 	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
-		// Маскировка полей с паролями
-		for i := 0; i < len(fields); i += 2 {
-			if fields[i] == "password" || fields[i] == "pass" {
-				if i+1 < len(fields) {
-					fields[i+1] = "***"
-				}
-			}
-		}
 		l.Log(ctx, slog.Level(lvl), msg, fields...)
 	})
 }
@@ -0,0 +1,107 @@
+package grpcapp_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	grpcapp "go_grpc/internal/app/grpc"
+	"go_grpc/internal/lib/logger/handlers/slogredact"
+
+	ssov1 "go_grpc/gen/go/sso"
+)
+
+// секрет, который не должен попасть в лог
+const sensitive = "s3cr3t-value"
+
+// Содержимое запросов и ответов логируется, секреты в нем должны скрываться
+func TestInterceptorLogger_Redacts(t *testing.T) {
+	tests := []struct {
+		name string
+		req  any
+		resp any
+	}{
+		{
+			name: "Register",
+			req:  &ssov1.RegisterRequest{Email: "user@example.com", Password: sensitive},
+			resp: &ssov1.RegisterResponse{UserId: 1},
+		},
+		{
+			name: "Login",
+			req:  &ssov1.LoginRequest{Email: "user@example.com", Password: sensitive, AppId: 1},
+			resp: &ssov1.LoginResponse{Token: sensitive, RefreshToken: sensitive},
+		},
+		{
+			name: "Refresh",
+			req:  &ssov1.RefreshRequest{RefreshToken: sensitive},
+			resp: &ssov1.RefreshResponse{Token: sensitive, RefreshToken: sensitive},
+		},
+		{
+			name: "VerifyEmail",
+			req:  &ssov1.VerifyEmailRequest{Token: sensitive},
+			resp: &ssov1.VerifyEmailResponse{},
+		},
+		{
+			name: "ResetPassword",
+			req:  &ssov1.ResetPasswordRequest{Token: sensitive, NewPassword: sensitive},
+			resp: &ssov1.ResetPasswordResponse{},
+		},
+		{
+			name: "Login2FA",
+			req:  &ssov1.LoginRequest{Email: "user@example.com", Password: sensitive, AppId: 1},
+			resp: &ssov1.LoginResponse{TwoFactorRequired: true, Challenge: sensitive, ChallengeExpiresAt: 1700000000},
+		},
+		{
+			name: "LoginVerify2FA",
+			req:  &ssov1.LoginVerify2FARequest{Challenge: sensitive, Code: sensitive},
+			resp: &ssov1.LoginVerify2FAResponse{Token: sensitive, RefreshToken: sensitive},
+		},
+		{
+			name: "EnrollTOTP",
+			req:  &ssov1.EnrollTOTPRequest{},
+			resp: &ssov1.EnrollTOTPResponse{
+				OtpauthUri: "otpauth://totp/SSO:user%40example.com?secret=" + sensitive + "&issuer=SSO",
+				Secret:     sensitive,
+			},
+		},
+		{
+			name: "ConfirmTOTP",
+			req:  &ssov1.ConfirmTOTPRequest{Code: sensitive},
+			resp: &ssov1.ConfirmTOTPResponse{RecoveryCodes: []string{sensitive, sensitive}},
+		},
+		{
+			name: "CreateApp",
+			req:  &ssov1.CreateAppRequest{Name: "billing"},
+			resp: &ssov1.CreateAppResponse{App: &ssov1.App{Id: 2, Name: "billing"}, Secret: sensitive},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			log := slog.New(slogredact.NewHandler(slog.NewJSONHandler(&buf, nil), slogredact.Options{
+				Keys:        slogredact.DefaultKeys,
+				QueryParams: slogredact.DefaultQueryParams,
+			}))
+
+			interceptor := logging.UnaryServerInterceptor(grpcapp.InterceptorLogger(log),
+				logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent),
+			)
+
+			_, err := interceptor(context.Background(), tt.req,
+				&grpc.UnaryServerInfo{FullMethod: "/auth.Auth/" + tt.name},
+				func(context.Context, any) (any, error) { return tt.resp, nil },
+			)
+			require.NoError(t, err)
+
+			require.Contains(t, buf.String(), slogredact.Redacted)
+			require.NotContains(t, buf.String(), sensitive)
+		})
+	}
+}
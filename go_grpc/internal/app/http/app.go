@@ -0,0 +1,72 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"go_grpc/internal/http/jwks"
+)
+
+// время на завершение активных запросов при остановке
+const shutdownTimeout = 5 * time.Second
+
+// HTTP сервер с открытыми ключами проверки токенов
+type App struct {
+	log    *slog.Logger
+	server *http.Server
+	port   int // Порт, на котором будет работать http-сервер
+}
+
+// создание HTTP приложения
+func New(log *slog.Logger, provider jwks.Provider, port int) *App {
+	mux := http.NewServeMux()
+	mux.Handle("GET /.well-known/jwks.json", jwks.New(log, provider))
+
+	return &App{
+		log: log,
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		port: port,
+	}
+}
+
+// запуск HTTP сервера
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+
+	l, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.log.Info("http server started", slog.String("addr", l.Addr().String()))
+
+	if err := a.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// остановка сервера
+func (a *App) Stop() {
+	const op = "httpapp.Stop"
+
+	a.log.With(slog.String("op", op)).
+		Info("stopping HTTP server", slog.Int("port", a.port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.Error("failed to stop http server", slog.Any("error", err))
+	}
+}
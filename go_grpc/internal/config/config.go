@@ -1,20 +1,113 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"log"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/secretbox"
 )
 
-// основная структура конфигурации приложения
+// основная структура конфигурации приложения.
+// cleanenv подставляет env-default вместо нулевых значений из YAML,
+// поэтому выключать что-либо нулем или false нельзя: для этого
+// используются отрицательные значения и флаги, по умолчанию равные false
 type Config struct {
-	Env            string        `yaml:"env" env-default:"local"`                    // Окружение
-	StoragePath    string        `yaml:"storage_path" env-required:"true"`           // Путь к БД
-	GRPC           GRPCConfig    `yaml:"grpc"`                                       // Конфиг gRPC
-	MigrationsPath string        `yaml:"migrations_path" env-default:"./migrations"` // Путь к миграциям
-	TokenTTL       time.Duration `yaml:"token_ttl" env-default:"1h"`                 // Время жизни токена
+	Env            string          `yaml:"env" env-default:"local"`                    // Окружение
+	StoragePath    string          `yaml:"storage_path" env-required:"true"`           // Путь к БД
+	GRPC           GRPCConfig      `yaml:"grpc"`                                       // Конфиг gRPC
+	MigrationsPath string          `yaml:"migrations_path" env-default:"./migrations"` // Путь к миграциям
+	TokenTTL       time.Duration   `yaml:"token_ttl" env-default:"1h"`                 // Время жизни токена
+	RefreshTTL     time.Duration   `yaml:"refresh_token_ttl" env-default:"720h"`       // Время жизни refresh-токена
+	ClockSkew      time.Duration   `yaml:"clock_skew" env-default:"30s"`               // Допустимое расхождение часов при проверке токенов
+	Signing        SigningConfig   `yaml:"signing"`                                    // Подпись токенов
+	HTTP           HTTPConfig      `yaml:"http"`                                       // Конфиг HTTP (JWKS)
+	Login          LoginConfig     `yaml:"login"`                                      // Защита от перебора паролей
+	Password       PasswordConfig  `yaml:"password"`                                   // Требования к паролям
+	TwoFactor      TwoFactorConfig `yaml:"two_factor"`                                 // Двухфакторная аутентификация
+	Accounts       AccountConfig   `yaml:"accounts"`                                   // Подтверждение email и сброс пароля
+	Mail           MailConfig      `yaml:"mail"`                                       // Отправка писем
+	Log            LogConfig       `yaml:"log"`                                        // Логирование
+}
+
+// настройки логирования
+type LogConfig struct {
+	// Дополнительные ключи атрибутов и параметры URL, значения которых
+	// скрываются в логах (к стандартным password, token, secret и т.д.)
+	RedactKeys        []string `yaml:"redact_keys" env:"LOG_REDACT_KEYS" env-separator:","`
+	RedactQueryParams []string `yaml:"redact_query_params" env:"LOG_REDACT_QUERY_PARAMS" env-separator:","`
+}
+
+// требования к паролям при регистрации и сбросе
+type PasswordConfig struct {
+	MinLength        int    `yaml:"min_length" env-default:"8"`         // Минимальная длина в символах
+	RequireUpper     bool   `yaml:"require_upper"`                      // Нужна заглавная буква
+	RequireLower     bool   `yaml:"require_lower"`                      // Нужна строчная буква
+	RequireDigit     bool   `yaml:"require_digit"`                      // Нужна цифра
+	RequireSymbol    bool   `yaml:"require_symbol"`                     // Нужен символ, не буква и не цифра
+	BreachedDir      string `yaml:"breached_dir"`                       // Каталог Pwned Passwords по префиксам, пусто - без проверки
+	BreachedMinCount int    `yaml:"breached_min_count" env-default:"1"` // С какого числа появлений в утечках пароль отклоняется
+}
+
+// двухфакторная аутентификация (TOTP)
+type TwoFactorConfig struct {
+	Issuer        string        `yaml:"issuer" env-default:"SSO"`                 // Название сервиса в приложении-аутентификаторе
+	EncryptionKey string        `yaml:"encryption_key" env:"TOTP_ENCRYPTION_KEY"` // Ключ шифрования секретов, 32 байта в base64. Пусто - 2FA выключена
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env-default:"5m"`           // Сколько ждать код после проверки пароля
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`             // Неверных кодов до отмены входа
+}
+
+// подтверждение email и сброс пароля
+type AccountConfig struct {
+	AllowUnverifiedLogin bool          `yaml:"allow_unverified_login"`             // Разрешить вход без подтверждения email
+	VerifyTokenTTL       time.Duration `yaml:"verify_token_ttl" env-default:"24h"` // Время жизни ссылки подтверждения email
+	ResetTokenTTL        time.Duration `yaml:"reset_token_ttl" env-default:"1h"`   // Время жизни ссылки сброса пароля
+	VerifyURL            string        `yaml:"verify_url"`                         // Шаблон ссылки подтверждения, {token} заменяется токеном
+	ResetURL             string        `yaml:"reset_url"`                          // Шаблон ссылки сброса пароля
+}
+
+// отправка писем
+type MailConfig struct {
+	Sender string     `yaml:"sender" env-default:"log"`              // log, file или smtp
+	From   string     `yaml:"from" env-default:"no-reply@localhost"` // Отправитель
+	Dir    string     `yaml:"dir" env-default:"./storage/mail"`      // Каталог для писем (sender: file)
+	SMTP   SMTPConfig `yaml:"smtp"`                                  // Почтовый сервер (sender: smtp)
+}
+
+// настройки SMTP сервера
+type SMTPConfig struct {
+	Host     string `yaml:"host"`                         // Адрес сервера
+	Port     int    `yaml:"port" env-default:"587"`       // Порт, STARTTLS включается, если сервер его поддерживает
+	Username string `yaml:"username"`                     // Логин, пустой - без авторизации
+	Password string `yaml:"password" env:"SMTP_PASSWORD"` // Пароль, лучше передавать через env
+}
+
+// защита входа от перебора паролей
+type LoginConfig struct {
+	MaxFailures   int           `yaml:"max_failures" env-default:"5"`     // Неудач подряд до блокировки email, -1 - без блокировки
+	Lockout       time.Duration `yaml:"lockout" env-default:"1m"`         // Первая блокировка, дальше удваивается
+	MaxLockout    time.Duration `yaml:"max_lockout" env-default:"1h"`     // Максимальная блокировка
+	FailureWindow time.Duration `yaml:"failure_window" env-default:"24h"` // Через сколько без неудач счетчик сбрасывается
+	RateLimit     float64       `yaml:"rate_limit" env-default:"5"`       // Запросов входа в секунду с одного IP, -1 - без ограничения
+	RateBurst     int           `yaml:"rate_burst" env-default:"10"`      // Допустимый всплеск запросов с одного IP
+}
+
+// настройки подписи access-токенов
+type SigningConfig struct {
+	Algorithm      string        `yaml:"algorithm" env-default:"HS256"`               // HS256 (секрет приложения), RS256 или EdDSA
+	RotationPeriod time.Duration `yaml:"rotation_period" env-default:"720h"`          // Как часто менять ключи
+	EncryptionKey  string        `yaml:"encryption_key" env:"SIGNING_ENCRYPTION_KEY"` // Ключ шифрования закрытых ключей, 32 байта в base64. Нужен для RS256 и EdDSA
+}
+
+// конфигурация HTTP сервера с JWKS
+type HTTPConfig struct {
+	Port int `yaml:"port"` // Порт сервера, 0 - сервер не запускается
 }
 
 // конфигурация gRPC сервера
@@ -33,7 +126,8 @@ func MustLoad() *Config {
 	return MustLoadPath(configPath)
 }
 
-// загружает конфигурацию из указанного пути
+// загружает конфигурацию из указанного пути. Если конфигурация
+// некорректна, выводит все найденные проблемы и завершает программу
 func MustLoadPath(configPath string) *Config {
 	// Проверка существования файла
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -47,9 +141,49 @@ func MustLoadPath(configPath string) *Config {
 		panic("config path is empty: " + err.Error())
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config:\n%s", err)
+	}
+
 	return &cfg
 }
 
+// Validate проверяет конфигурацию и возвращает сразу все найденные проблемы
+func (c *Config) Validate() error {
+	var errs []error
+
+	problem := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Signing.Algorithm {
+	case jwk.AlgHS256:
+	case jwk.AlgRS256, jwk.AlgEdDSA:
+		// Закрытые ключи подписи без ключа шифрования хранить нельзя
+		if c.Signing.EncryptionKey == "" {
+			problem("signing.encryption_key (SIGNING_ENCRYPTION_KEY) is required for %s, generate it with: openssl rand -base64 32",
+				c.Signing.Algorithm)
+		}
+	default:
+		problem("signing.algorithm %q is unknown, use %s, %s or %s",
+			c.Signing.Algorithm, jwk.AlgHS256, jwk.AlgRS256, jwk.AlgEdDSA)
+	}
+
+	if c.Signing.EncryptionKey != "" {
+		if _, err := secretbox.NewFromBase64(c.Signing.EncryptionKey); err != nil {
+			problem("signing.encryption_key is invalid: %w", err)
+		}
+	}
+
+	if c.TwoFactor.EncryptionKey != "" {
+		if _, err := secretbox.NewFromBase64(c.TwoFactor.EncryptionKey); err != nil {
+			problem("two_factor.encryption_key is invalid: %w", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // fetchConfigPath - получение пути к конфигурации (флаг > env > default)
 func fetchConfigPath() string {
 	var res string
@@ -0,0 +1,73 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/config"
+)
+
+// ключ шифрования для тестов: 32 нулевых байта
+const testKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+func TestValidate_Signing(t *testing.T) {
+	cases := []struct {
+		name    string
+		signing config.SigningConfig
+		wantErr string
+	}{
+		{
+			name:    "HS256 without key",
+			signing: config.SigningConfig{Algorithm: "HS256"},
+		},
+		{
+			name:    "EdDSA with key",
+			signing: config.SigningConfig{Algorithm: "EdDSA", EncryptionKey: testKey},
+		},
+		{
+			name:    "EdDSA without key",
+			signing: config.SigningConfig{Algorithm: "EdDSA"},
+			wantErr: "signing.encryption_key (SIGNING_ENCRYPTION_KEY) is required for EdDSA",
+		},
+		{
+			name:    "RS256 without key",
+			signing: config.SigningConfig{Algorithm: "RS256"},
+			wantErr: "signing.encryption_key (SIGNING_ENCRYPTION_KEY) is required for RS256",
+		},
+		{
+			name:    "Short key",
+			signing: config.SigningConfig{Algorithm: "EdDSA", EncryptionKey: "c2hvcnQ="},
+			wantErr: "signing.encryption_key is invalid",
+		},
+		{
+			name:    "Unknown algorithm",
+			signing: config.SigningConfig{Algorithm: "HS512"},
+			wantErr: `signing.algorithm "HS512" is unknown`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{Signing: tc.signing}
+
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// Конфиг из репозитория запускается без дополнительных переменных окружения
+func TestMustLoadPath_DefaultConfig(t *testing.T) {
+	t.Setenv("SIGNING_ENCRYPTION_KEY", "")
+	t.Setenv("TOTP_ENCRYPTION_KEY", "")
+
+	cfg := config.MustLoadPath("../../config/config.yaml")
+	require.NoError(t, cfg.Validate())
+}
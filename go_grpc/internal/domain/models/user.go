@@ -1,15 +1,135 @@
 package models
 
+import "time"
+
 // модель пользователя системы
 type User struct {
 	ID       int64  // Уникальный идентификатор
 	Email    string // Email пользователя (уникальный)
 	PassHash []byte // Хэш пароля (bcrypt)
+	IsAdmin  bool   // Есть ли у пользователя права администратора
+	// Email подтвержден переходом по ссылке из письма
+	EmailVerified bool
+	// Access-токены, выданные раньше этого момента, отозваны. nil - отзыва не было
+	TokensRevokedAt *time.Time
+}
+
+// роли пользователя в токене
+const (
+	RoleUser  = "user"  // Есть у всех пользователей
+	RoleAdmin = "admin" // Администратор
+)
+
+// роли пользователя для claim roles
+func (u User) Roles() []string {
+	if u.IsAdmin {
+		return []string{RoleUser, RoleAdmin}
+	}
+
+	return []string{RoleUser}
 }
 
 // модель приложения
 type App struct {
-	ID     int    // Уникальный идентификатор приложения
-	Name   string // Название приложения
-	Secret string // Секретный ключ для подписи JWT
+	ID       int    // Уникальный идентификатор приложения
+	Name     string // Название приложения
+	Secret   string // Секретный ключ для подписи JWT
+	Issuer   string // Издатель токенов приложения (iss)
+	Audience string // Получатель токенов приложения (aud)
+}
+
+// модель refresh-токена. Сам токен не хранится, только его хэш
+type RefreshToken struct {
+	ID        int64      // Уникальный идентификатор
+	UserID    int64      // Владелец токена
+	AppID     int        // Приложение, для которого выдан токен
+	Family    string     // Цепочка токенов одного входа
+	TokenHash []byte     // SHA-256 токена
+	CreatedAt time.Time  // Время выдачи
+	ExpiresAt time.Time  // Время истечения
+	UsedAt    *time.Time // Время обмена на новую пару, nil - не использован
+	RevokedAt *time.Time // Время отзыва, nil - не отозван
+}
+
+// назначение одноразового токена из письма
+const (
+	PurposeVerifyEmail   = "verify_email"   // Подтверждение email
+	PurposeResetPassword = "reset_password" // Сброс пароля
+)
+
+// модель одноразового токена из письма. Сам токен не хранится, только его хэш
+type UserToken struct {
+	ID        int64      // Уникальный идентификатор
+	UserID    int64      // Владелец токена
+	Purpose   string     // Назначение: PurposeVerifyEmail или PurposeResetPassword
+	TokenHash []byte     // SHA-256 токена
+	CreatedAt time.Time  // Время выдачи
+	ExpiresAt time.Time  // Время истечения
+	UsedAt    *time.Time // Время использования, nil - не использован
+}
+
+// TOTP пользователя. Секрет хранится зашифрованным
+type TOTP struct {
+	UserID      int64      // Владелец
+	Secret      []byte     // Зашифрованный секрет
+	CreatedAt   time.Time  // Начало подключения
+	ConfirmedAt *time.Time // Подтверждение кодом, nil - еще не подключен
+	LastStep    int64      // Шаг последнего принятого кода
+}
+
+// незавершенный вход: пароль проверен, ждем второй фактор.
+// Сам токен не хранится, только его хэш
+type LoginChallenge struct {
+	ID        int64      // Уникальный идентификатор
+	UserID    int64      // Пользователь
+	AppID     int        // Приложение, в которое выполняется вход
+	TokenHash []byte     // SHA-256 токена
+	CreatedAt time.Time  // Время проверки пароля
+	ExpiresAt time.Time  // До какого момента можно ввести код
+	Attempts  int        // Неверных кодов
+	UsedAt    *time.Time // Вход завершен или отменен, nil - ждет кода
+}
+
+// результат входа: пара токенов или, если включена
+// двухфакторная аутентификация, challenge для ввода кода
+type LoginResult struct {
+	Tokens    TokenPair // Пара токенов, пустая при Challenge != ""
+	Challenge string    // Токен для LoginVerify2FA
+	// До какого момента можно завершить вход
+	ChallengeExpiresAt time.Time
+}
+
+// результат проверки access-токена (интроспекция).
+// Для неактивного токена заполнен только Active
+type TokenInfo struct {
+	Active    bool      // Токен действителен: подпись верна, срок не истек, не отозван
+	UserID    int64     // ID пользователя
+	Email     string    // Email пользователя
+	AppID     int       // ID приложения, для которого выдан токен
+	Roles     []string  // Роли пользователя на момент выдачи
+	IsAdmin   bool      // Права администратора на момент выдачи
+	Issuer    string    // Издатель токена (iss)
+	Audience  []string  // Получатели токена (aud)
+	TokenID   string    // Уникальный ID токена (jti)
+	IssuedAt  time.Time // Время выдачи
+	ExpiresAt time.Time // Время истечения токена
+}
+
+// пара токенов, выдаваемая при входе и обновлении
+type TokenPair struct {
+	AccessToken  string // Короткоживущий JWT
+	RefreshToken string // Долгоживущий токен для получения новой пары
+}
+
+// ключ подписи токенов приложения. Текущий ключ один (VerifyUntil == nil),
+// предыдущие после ротации хранятся до VerifyUntil только для проверки
+type SigningKey struct {
+	ID          int64      // Уникальный идентификатор
+	AppID       int        // Приложение, токены которого подписывает ключ
+	KID         string     // ID ключа, передается в заголовке kid токена
+	Algorithm   string     // Алгоритм подписи: RS256 или EdDSA
+	PrivateKey  []byte     // Закрытый ключ, PKCS #8
+	PublicKey   []byte     // Открытый ключ, PKIX
+	CreatedAt   time.Time  // Время создания
+	VerifyUntil *time.Time // До какого момента ключ принимается после ротации
 }
@@ -3,13 +3,20 @@ package authgrpc
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/jwk"
 	"go_grpc/internal/services/auth"
 	"go_grpc/internal/storage"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	ssov1 "go_grpc/gen/go/sso"
 )
@@ -21,13 +28,29 @@ type Auth interface {
 		email string,
 		password string,
 		appID int,
-	) (token string, err error)
+	) (result models.LoginResult, err error)
+	LoginVerify2FA(ctx context.Context, challenge string, code string) (models.TokenPair, error)
+	EnrollTOTP(ctx context.Context, token string) (uri string, secret string, err error)
+	ConfirmTOTP(ctx context.Context, token string, code string) (recoveryCodes []string, err error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string, allSessions bool) error
 	RegisterNewUser(
 		ctx context.Context,
 		email string,
 		password string,
 	) (userID int64, err error)
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	ValidateToken(ctx context.Context, token string) (models.TokenInfo, error)
+	JWKS(ctx context.Context, appID int) (jwk.Set, error)
 	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	GrantAdmin(ctx context.Context, token string, userID int64) error
+	RevokeAdmin(ctx context.Context, token string, userID int64) error
+	CreateApp(ctx context.Context, token string, app models.App) (models.App, error)
+	ListApps(ctx context.Context, token string) ([]models.App, error)
+	RotateAppSecret(ctx context.Context, token string, appID int) (secret string, err error)
+	DeleteApp(ctx context.Context, token string, appID int) error
 }
 
 // реализация gRPC сервера
@@ -56,14 +79,158 @@ func (s *serverAPI) Login(
 	if in.GetAppId() == 0 {
 		return nil, status.Error(codes.InvalidArgument, "app_id is required")
 	}
-	token, err := s.auth.Login(ctx, in.GetEmail(), in.GetPassword(), int(in.GetAppId()))
+	result, err := s.auth.Login(ctx, in.GetEmail(), in.GetPassword(), int(in.GetAppId()))
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
+
+		var locked *auth.LockedError
+		if errors.As(err, &locked) {
+			return nil, resourceExhausted("too many failed login attempts, try again later", time.Until(locked.Until))
+		}
+
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+
+		if errors.Is(err, auth.ErrTwoFactorDisabled) {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is not configured")
+		}
+
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
-	return &ssov1.LoginResponse{Token: token}, nil
+	if result.Challenge != "" {
+		return &ssov1.LoginResponse{
+			TwoFactorRequired:  true,
+			Challenge:          result.Challenge,
+			ChallengeExpiresAt: result.ChallengeExpiresAt.Unix(),
+		}, nil
+	}
+
+	return &ssov1.LoginResponse{Token: result.Tokens.AccessToken, RefreshToken: result.Tokens.RefreshToken}, nil
+}
+
+// обработчик gRPC метода LoginVerify2FA
+func (s *serverAPI) LoginVerify2FA(
+	ctx context.Context,
+	in *ssov1.LoginVerify2FARequest,
+) (*ssov1.LoginVerify2FAResponse, error) {
+	if in.GetChallenge() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge is required")
+	}
+
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	tokens, err := s.auth.LoginVerify2FA(ctx, in.GetChallenge(), in.GetCode())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired challenge")
+		}
+
+		var locked *auth.LockedError
+		if errors.As(err, &locked) {
+			return nil, resourceExhausted("too many failed login attempts, try again later", time.Until(locked.Until))
+		}
+
+		// Приложение удалили, пока пользователь вводил код
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+
+		return nil, twoFactorError(err, "failed to login")
+	}
+
+	return &ssov1.LoginVerify2FAResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// обработчик gRPC метода EnrollTOTP
+func (s *serverAPI) EnrollTOTP(
+	ctx context.Context,
+	in *ssov1.EnrollTOTPRequest,
+) (*ssov1.EnrollTOTPResponse, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri, secret, err := s.auth.EnrollTOTP(ctx, token)
+	if err != nil {
+		return nil, twoFactorError(err, "failed to enroll totp")
+	}
+
+	return &ssov1.EnrollTOTPResponse{OtpauthUri: uri, Secret: secret}, nil
+}
+
+// обработчик gRPC метода ConfirmTOTP
+func (s *serverAPI) ConfirmTOTP(
+	ctx context.Context,
+	in *ssov1.ConfirmTOTPRequest,
+) (*ssov1.ConfirmTOTPResponse, error) {
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.auth.ConfirmTOTP(ctx, token, in.GetCode())
+	if err != nil {
+		return nil, twoFactorError(err, "failed to confirm totp")
+	}
+
+	return &ssov1.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// обработчик gRPC метода Refresh
+func (s *serverAPI) Refresh(
+	ctx context.Context,
+	in *ssov1.RefreshRequest,
+) (*ssov1.RefreshResponse, error) {
+	// Валидация входных данных
+	if in.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	tokens, err := s.auth.Refresh(ctx, in.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to refresh tokens")
+	}
+
+	return &ssov1.RefreshResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// обработчик gRPC метода Logout
+func (s *serverAPI) Logout(
+	ctx context.Context,
+	in *ssov1.LogoutRequest,
+) (*ssov1.LogoutResponse, error) {
+	// Валидация входных данных
+	if in.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	if err := s.auth.Logout(ctx, in.GetRefreshToken(), in.GetAllSessions()); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to logout")
+	}
+
+	return &ssov1.LogoutResponse{}, nil
 }
 
 // обработчик gRPC метода Register
@@ -71,13 +238,14 @@ func (s *serverAPI) Register(
 	ctx context.Context,
 	in *ssov1.RegisterRequest,
 ) (*ssov1.RegisterResponse, error) {
-	// Валидация входных данных
+	// Валидация входных данных. Формат email и требования к паролю
+	// проверяет сервис
 	if in.Email == "" {
-		return nil, status.Error(codes.InvalidArgument, "email is required")
+		return nil, invalidArgument("email is required", auth.FieldViolation{Field: "email", Description: "is required"})
 	}
 
 	if in.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "password is required")
+		return nil, invalidArgument("password is required", auth.FieldViolation{Field: "password", Description: "is required"})
 	}
 
 	// Регистрация пользователя
@@ -88,12 +256,148 @@ func (s *serverAPI) Register(
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
 
+		var invalid *auth.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, invalidArgument(invalid.Error(), invalid.Violations...)
+		}
+
 		return nil, status.Error(codes.Internal, "failed to register user")
 	}
 
 	return &ssov1.RegisterResponse{UserId: uid}, nil
 }
 
+// обработчик gRPC метода VerifyEmail
+func (s *serverAPI) VerifyEmail(
+	ctx context.Context,
+	in *ssov1.VerifyEmailRequest,
+) (*ssov1.VerifyEmailResponse, error) {
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := s.auth.VerifyEmail(ctx, in.GetToken()); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to verify email")
+	}
+
+	return &ssov1.VerifyEmailResponse{}, nil
+}
+
+// обработчик gRPC метода RequestPasswordReset
+func (s *serverAPI) RequestPasswordReset(
+	ctx context.Context,
+	in *ssov1.RequestPasswordResetRequest,
+) (*ssov1.RequestPasswordResetResponse, error) {
+	if in.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.RequestPasswordReset(ctx, in.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to request password reset")
+	}
+
+	return &ssov1.RequestPasswordResetResponse{}, nil
+}
+
+// обработчик gRPC метода ResetPassword
+func (s *serverAPI) ResetPassword(
+	ctx context.Context,
+	in *ssov1.ResetPasswordRequest,
+) (*ssov1.ResetPasswordResponse, error) {
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if in.GetNewPassword() == "" {
+		return nil, invalidArgument("new_password is required", auth.FieldViolation{Field: "new_password", Description: "is required"})
+	}
+
+	if err := s.auth.ResetPassword(ctx, in.GetToken(), in.GetNewPassword()); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+
+		var invalid *auth.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, invalidArgument(invalid.Error(), invalid.Violations...)
+		}
+
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+
+	return &ssov1.ResetPasswordResponse{}, nil
+}
+
+// обработчик gRPC метода ValidateToken
+func (s *serverAPI) ValidateToken(
+	ctx context.Context,
+	in *ssov1.ValidateTokenRequest,
+) (*ssov1.ValidateTokenResponse, error) {
+	// Валидация входных данных
+	if in.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	info, err := s.auth.ValidateToken(ctx, in.GetToken())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+
+	if !info.Active {
+		return &ssov1.ValidateTokenResponse{Active: false}, nil
+	}
+
+	return &ssov1.ValidateTokenResponse{
+		Active:  true,
+		UserId:  info.UserID,
+		Email:   info.Email,
+		AppId:   int32(info.AppID),
+		Exp:     info.ExpiresAt.Unix(),
+		Roles:   info.Roles,
+		IsAdmin: info.IsAdmin,
+		Iss:     info.Issuer,
+		Aud:     info.Audience,
+		Jti:     info.TokenID,
+		Iat:     info.IssuedAt.Unix(),
+	}, nil
+}
+
+// обработчик gRPC метода GetJWKS
+func (s *serverAPI) GetJWKS(
+	ctx context.Context,
+	in *ssov1.GetJWKSRequest,
+) (*ssov1.GetJWKSResponse, error) {
+	// Валидация входных данных
+	if in.AppId < 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id must not be negative")
+	}
+
+	set, err := s.auth.JWKS(ctx, int(in.GetAppId()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get keys")
+	}
+
+	keys := make([]*ssov1.JWK, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		keys = append(keys, &ssov1.JWK{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Use: k.Use,
+			Alg: k.Alg,
+			N:   k.N,
+			E:   k.E,
+			Crv: k.Crv,
+			X:   k.X,
+		})
+	}
+
+	return &ssov1.GetJWKSResponse{Keys: keys}, nil
+}
+
 // обработчик gRPC метода IsAdmin
 func (s *serverAPI) IsAdmin(
 	ctx context.Context,
@@ -117,3 +421,239 @@ func (s *serverAPI) IsAdmin(
 
 	return &ssov1.IsAdminResponse{IsAdmin: isAdmin}, nil
 }
+
+// обработчик gRPC метода GrantAdmin
+func (s *serverAPI) GrantAdmin(
+	ctx context.Context,
+	in *ssov1.GrantAdminRequest,
+) (*ssov1.GrantAdminResponse, error) {
+	// Валидация входных данных
+	if in.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.GrantAdmin(ctx, token, in.GetUserId()); err != nil {
+		return nil, adminError(err, "failed to grant admin role")
+	}
+
+	return &ssov1.GrantAdminResponse{}, nil
+}
+
+// обработчик gRPC метода RevokeAdmin
+func (s *serverAPI) RevokeAdmin(
+	ctx context.Context,
+	in *ssov1.RevokeAdminRequest,
+) (*ssov1.RevokeAdminResponse, error) {
+	// Валидация входных данных
+	if in.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeAdmin(ctx, token, in.GetUserId()); err != nil {
+		return nil, adminError(err, "failed to revoke admin role")
+	}
+
+	return &ssov1.RevokeAdminResponse{}, nil
+}
+
+// обработчик gRPC метода CreateApp
+func (s *serverAPI) CreateApp(
+	ctx context.Context,
+	in *ssov1.CreateAppRequest,
+) (*ssov1.CreateAppResponse, error) {
+	// Валидация входных данных
+	name := strings.TrimSpace(in.GetName())
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.auth.CreateApp(ctx, token, models.App{
+		Name:     name,
+		Issuer:   strings.TrimSpace(in.GetIssuer()),
+		Audience: strings.TrimSpace(in.GetAudience()),
+	})
+	if err != nil {
+		return nil, adminError(err, "failed to create app")
+	}
+
+	return &ssov1.CreateAppResponse{App: appToProto(app), Secret: app.Secret}, nil
+}
+
+// обработчик gRPC метода ListApps
+func (s *serverAPI) ListApps(
+	ctx context.Context,
+	in *ssov1.ListAppsRequest,
+) (*ssov1.ListAppsResponse, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := s.auth.ListApps(ctx, token)
+	if err != nil {
+		return nil, adminError(err, "failed to list apps")
+	}
+
+	resp := &ssov1.ListAppsResponse{Apps: make([]*ssov1.App, 0, len(apps))}
+	for _, app := range apps {
+		resp.Apps = append(resp.Apps, appToProto(app))
+	}
+
+	return resp, nil
+}
+
+// обработчик gRPC метода RotateAppSecret
+func (s *serverAPI) RotateAppSecret(
+	ctx context.Context,
+	in *ssov1.RotateAppSecretRequest,
+) (*ssov1.RotateAppSecretResponse, error) {
+	// Валидация входных данных
+	if in.AppId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.auth.RotateAppSecret(ctx, token, int(in.GetAppId()))
+	if err != nil {
+		return nil, adminError(err, "failed to rotate app secret")
+	}
+
+	return &ssov1.RotateAppSecretResponse{Secret: secret}, nil
+}
+
+// обработчик gRPC метода DeleteApp
+func (s *serverAPI) DeleteApp(
+	ctx context.Context,
+	in *ssov1.DeleteAppRequest,
+) (*ssov1.DeleteAppResponse, error) {
+	// Валидация входных данных
+	if in.AppId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.DeleteApp(ctx, token, int(in.GetAppId())); err != nil {
+		return nil, adminError(err, "failed to delete app")
+	}
+
+	return &ssov1.DeleteAppResponse{}, nil
+}
+
+// appToProto - приложение для ответа, без секрета
+func appToProto(app models.App) *ssov1.App {
+	return &ssov1.App{
+		Id:       int32(app.ID),
+		Name:     app.Name,
+		Issuer:   app.Issuer,
+		Audience: app.Audience,
+	}
+}
+
+// adminError переводит ошибку административного метода в gRPC статус
+func adminError(err error, msg string) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "admin role required")
+	case errors.Is(err, auth.ErrSelfRevoke):
+		return status.Error(codes.FailedPrecondition, "cannot revoke own admin role")
+	case errors.Is(err, storage.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, storage.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, storage.ErrAppExists):
+		return status.Error(codes.AlreadyExists, "app already exists")
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
+
+// twoFactorError - статус для ошибок двухфакторной аутентификации
+func twoFactorError(err error, msg string) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, auth.ErrInvalidCode):
+		return status.Error(codes.InvalidArgument, "invalid code")
+	case errors.Is(err, auth.ErrTOTPEnabled):
+		return status.Error(codes.AlreadyExists, "totp already enabled")
+	case errors.Is(err, auth.ErrTOTPNotEnrolled):
+		return status.Error(codes.FailedPrecondition, "totp enrollment not started")
+	case errors.Is(err, auth.ErrTwoFactorDisabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is not configured")
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
+
+// invalidArgument - статус InvalidArgument с нарушениями по полям
+// (BadRequest), чтобы клиент мог показать их у полей формы
+func invalidArgument(msg string, violations ...auth.FieldViolation) error {
+	st := status.New(codes.InvalidArgument, msg)
+
+	details := &errdetails.BadRequest{}
+	for _, v := range violations {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	detailed, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// resourceExhausted - статус ResourceExhausted с временем, через которое
+// можно повторить запрос (RetryInfo)
+func resourceExhausted(msg string, retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, msg)
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter.Round(time.Second))})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// bearerToken достает токен из метаданных запроса (authorization: Bearer <token>)
+func bearerToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	for _, v := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(v, " ")
+		if ok && strings.EqualFold(scheme, "bearer") && token != "" {
+			return token, nil
+		}
+	}
+
+	return "", status.Error(codes.Unauthenticated, "authorization token is required")
+}
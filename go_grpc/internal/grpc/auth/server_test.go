@@ -0,0 +1,112 @@
+package authgrpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/services/auth"
+	"go_grpc/internal/storage"
+
+	ssov1 "go_grpc/gen/go/sso"
+)
+
+// fakeAuth возвращает заданную ошибку из всех вызываемых методов.
+// Остальные методы интерфейса не реализованы и паникуют
+type fakeAuth struct {
+	Auth
+	err error
+}
+
+func (f fakeAuth) Login(context.Context, string, string, int) (models.LoginResult, error) {
+	return models.LoginResult{}, f.err
+}
+
+func (f fakeAuth) LoginVerify2FA(context.Context, string, string) (models.TokenPair, error) {
+	return models.TokenPair{}, f.err
+}
+
+func (f fakeAuth) CreateApp(context.Context, string, models.App) (models.App, error) {
+	return models.App{}, f.err
+}
+
+func (f fakeAuth) ListApps(context.Context, string) ([]models.App, error) {
+	return nil, f.err
+}
+
+func (f fakeAuth) RotateAppSecret(context.Context, string, int) (string, error) {
+	return "", f.err
+}
+
+func (f fakeAuth) DeleteApp(context.Context, string, int) error {
+	return f.err
+}
+
+func TestLogin_AppNotFound(t *testing.T) {
+	// Сервис оборачивает ошибки хранилища
+	s := &serverAPI{auth: fakeAuth{err: fmt.Errorf("Auth.Login: %w", storage.ErrAppNotFound)}}
+	ctx := context.Background()
+
+	_, err := s.Login(ctx, &ssov1.LoginRequest{Email: "user@example.com", Password: "password", AppId: 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.LoginVerify2FA(ctx, &ssov1.LoginVerify2FARequest{Challenge: "challenge", Code: "123456"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestApps_PermissionDenied(t *testing.T) {
+	calls := []struct {
+		name string
+		call func(s *serverAPI, ctx context.Context) error
+	}{
+		{
+			name: "CreateApp",
+			call: func(s *serverAPI, ctx context.Context) error {
+				_, err := s.CreateApp(ctx, &ssov1.CreateAppRequest{Name: "billing"})
+				return err
+			},
+		},
+		{
+			name: "ListApps",
+			call: func(s *serverAPI, ctx context.Context) error {
+				_, err := s.ListApps(ctx, &ssov1.ListAppsRequest{})
+				return err
+			},
+		},
+		{
+			name: "RotateAppSecret",
+			call: func(s *serverAPI, ctx context.Context) error {
+				_, err := s.RotateAppSecret(ctx, &ssov1.RotateAppSecretRequest{AppId: 1})
+				return err
+			},
+		},
+		{
+			name: "DeleteApp",
+			call: func(s *serverAPI, ctx context.Context) error {
+				_, err := s.DeleteApp(ctx, &ssov1.DeleteAppRequest{AppId: 1})
+				return err
+			},
+		},
+	}
+
+	bearer := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			denied := &serverAPI{auth: fakeAuth{err: fmt.Errorf("Auth.%s: %w", tt.name, auth.ErrPermissionDenied)}}
+			require.Equal(t, codes.PermissionDenied, status.Code(tt.call(denied, bearer)))
+
+			invalid := &serverAPI{auth: fakeAuth{err: auth.ErrInvalidToken}}
+			require.Equal(t, codes.Unauthenticated, status.Code(tt.call(invalid, bearer)))
+
+			// Без токена сервис не вызывается
+			require.Equal(t, codes.Unauthenticated, status.Code(tt.call(&serverAPI{auth: fakeAuth{}}, context.Background())))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// через сколько без запросов адрес забывается
+const idleTTL = 10 * time.Minute
+
+// ограничение частоты запросов с одного адреса (token bucket)
+type Limiter struct {
+	rps     rate.Limit
+	burst   int
+	methods map[string]bool // Ограничиваемые методы, пустой - все
+
+	mu          sync.Mutex
+	clients     map[string]*client
+	lastCleanup time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New ограничивает каждый адрес rps запросами в секунду с запасом burst.
+// methods - полные имена методов (/auth.Auth/Login), пустой список - все методы
+func New(rps float64, burst int, methods ...string) *Limiter {
+	l := &Limiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		methods: make(map[string]bool, len(methods)),
+		clients: make(map[string]*client),
+	}
+
+	for _, m := range methods {
+		l.methods[m] = true
+	}
+
+	return l
+}
+
+// UnaryServerInterceptor отклоняет запросы сверх лимита с кодом ResourceExhausted
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if len(l.methods) > 0 && !l.methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		if !l.allow(peerAddr(ctx), time.Now()) {
+			return nil, tooManyRequests(time.Duration(float64(time.Second) / float64(l.rps)))
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (l *Limiter) allow(addr string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > idleTTL {
+		for a, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleTTL {
+				delete(l.clients, a)
+			}
+		}
+
+		l.lastCleanup = now
+	}
+
+	c, ok := l.clients[addr]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.clients[addr] = c
+	}
+
+	c.lastSeen = now
+
+	return c.limiter.AllowN(now, 1)
+}
+
+// peerAddr - IP клиента без порта: у каждого соединения свой порт
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func tooManyRequests(retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "too many requests")
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
@@ -0,0 +1,59 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/logger/sl"
+)
+
+// как долго клиенты могут кэшировать набор ключей
+const cacheControl = "public, max-age=300"
+
+// источник открытых ключей
+type Provider interface {
+	JWKS(ctx context.Context, appID int) (jwk.Set, error)
+}
+
+// New отдает открытые ключи проверки токенов (JWKS).
+// Параметр ?app_id=N ограничивает набор ключами одного приложения
+func New(log *slog.Logger, provider Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.jwks.New"
+
+		log := log.With(slog.String("op", op))
+
+		var appID int
+
+		if v := r.URL.Query().Get("app_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				http.Error(w, "invalid app_id", http.StatusBadRequest)
+
+				return
+			}
+
+			appID = id
+		}
+
+		set, err := provider.JWKS(r.Context(), appID)
+		if err != nil {
+			log.Error("failed to get jwks", sl.Err(err))
+
+			http.Error(w, "internal error", http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", cacheControl)
+
+		if err := json.NewEncoder(w).Encode(set); err != nil {
+			log.Error("failed to write jwks", sl.Err(err))
+		}
+	}
+}
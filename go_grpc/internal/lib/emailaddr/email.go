@@ -0,0 +1,45 @@
+package emailaddr
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// максимальная длина адреса по RFC 5321
+const maxLength = 254
+
+var ErrInvalid = errors.New("invalid email address")
+
+// Normalize приводит email к каноническому виду: без пробелов по краям,
+// в NFC и в нижнем регистре. Адреса, которые отличаются только регистром
+// или способом записи символов Unicode, дают одну и ту же строку.
+// Полный case folding (ß -> ss) не используется: он меняет сам адрес
+func Normalize(s string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimSpace(s)))
+}
+
+// Validate проверяет, что s - голый адрес вида local@domain
+// без имени, комментариев и угловых скобок
+func Validate(s string) error {
+	if len(s) > maxLength {
+		return ErrInvalid
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return ErrInvalid
+	}
+
+	at := strings.LastIndexByte(s, '@')
+	domain := s[at+1:]
+
+	// Домен без точки (user@localhost) для регистрации не подходит
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return ErrInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package emailaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"user@example.com":       "user@example.com",
+		"  User@Example.COM \n":  "user@example.com",
+		"Straße@Example.com":     "straße@example.com",
+		"Иван@Пример.рф":         "иван@пример.рф",
+		"e\u0301@example.com":    "\u00e9@example.com", // NFC: e + combining acute
+		"\u00c9@EXAMPLE.com":     "\u00e9@example.com",
+		"Mixed.Case+Tag@Mail.io": "mixed.case+tag@mail.io",
+	}
+
+	for in, want := range cases {
+		require.Equal(t, want, Normalize(in), "input: %q", in)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"user@example.com",
+		"first.last+tag@sub.example.co.uk",
+		"иван@пример.рф",
+	}
+	for _, s := range valid {
+		require.NoError(t, Validate(s), s)
+	}
+
+	invalid := []string{
+		"",
+		"user",
+		"user@",
+		"@example.com",
+		"user@localhost",
+		"user@example.",
+		"User <user@example.com>",
+		"<user@example.com>",
+		"user@example.com (comment)",
+		"a@b@example.com",
+		"user@example.com\r\nBcc: x@example.com",
+	}
+	for _, s := range invalid {
+		require.ErrorIs(t, Validate(s), ErrInvalid, s)
+	}
+}
@@ -0,0 +1,176 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// алгоритмы подписи токенов
+const (
+	AlgHS256 = "HS256" // HMAC секретом приложения
+	AlgRS256 = "RS256" // RSA 2048
+	AlgEdDSA = "EdDSA" // Ed25519
+)
+
+// длина RSA-ключа в битах
+const rsaBits = 2048
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+// открытый ключ в формате JWK (RFC 7517)
+type Key struct {
+	Kty string `json:"kty"`           // Тип ключа: RSA или OKP
+	Kid string `json:"kid"`           // ID ключа, совпадает с заголовком kid токена
+	Use string `json:"use"`           // Назначение: всегда sig
+	Alg string `json:"alg"`           // Алгоритм подписи
+	N   string `json:"n,omitempty"`   // RSA: модуль
+	E   string `json:"e,omitempty"`   // RSA: экспонента
+	Crv string `json:"crv,omitempty"` // OKP: кривая
+	X   string `json:"x,omitempty"`   // OKP: открытый ключ
+}
+
+// набор ключей (JWKS)
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// пара ключей в DER: закрытый в PKCS #8, открытый в PKIX
+type KeyPair struct {
+	KID        string // Отпечаток открытого ключа (RFC 7638)
+	Algorithm  string // Алгоритм подписи
+	PrivateKey []byte // Закрытый ключ, PKCS #8
+	PublicKey  []byte // Открытый ключ, PKIX
+}
+
+// Supported сообщает, поддерживается ли асимметричный алгоритм
+func Supported(alg string) bool {
+	return alg == AlgRS256 || alg == AlgEdDSA
+}
+
+// генерация новой пары ключей для алгоритма alg
+func Generate(alg string) (KeyPair, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaBits)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return KeyPair{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("generate %s key: %w", alg, err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	public, err := FromPublicKey(alg, "", private.Public())
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{
+		KID:        public.Thumbprint(),
+		Algorithm:  alg,
+		PrivateKey: privateDER,
+		PublicKey:  publicDER,
+	}, nil
+}
+
+// разбор закрытого ключа из PKCS #8
+func ParsePrivateKey(der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, key)
+	}
+
+	return signer, nil
+}
+
+// разбор открытого ключа из PKIX
+func ParsePublicKey(der []byte) (crypto.PublicKey, error) {
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// FromPublicKey переводит открытый ключ в JWK
+func FromPublicKey(alg, kid string, public crypto.PublicKey) (Key, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			break
+		}
+
+		return Key{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   encode(k.N.Bytes()),
+			E:   encode(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		if alg != AlgEdDSA {
+			break
+		}
+
+		return Key{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   encode(k),
+		}, nil
+	}
+
+	return Key{}, fmt.Errorf("%w: %s with %T", ErrUnsupportedAlgorithm, alg, public)
+}
+
+// Thumbprint - отпечаток ключа по RFC 7638: SHA-256 от обязательных
+// полей JWK в каноническом виде. Используется как kid
+func (k Key) Thumbprint() string {
+	var canonical any
+
+	// json.Marshal сортирует ключи map, как того требует RFC 7638
+	switch k.Kty {
+	case "RSA":
+		canonical = map[string]string{"e": k.E, "kty": k.Kty, "n": k.N}
+	default:
+		canonical = map[string]string{"crv": k.Crv, "kty": k.Kty, "x": k.X}
+	}
+
+	b, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(b)
+
+	return encode(sum[:])
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,112 @@
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/lib/jwk"
+)
+
+// publicFromJWK восстанавливает открытый ключ из JWK, как это делает
+// сервис, проверяющий токены по JWKS
+func publicFromJWK(t *testing.T, k jwk.Key) crypto.PublicKey {
+	t.Helper()
+
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+
+		return b
+	}
+
+	switch k.Kty {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(k.N)),
+			E: int(new(big.Int).SetBytes(decode(k.E)).Int64()),
+		}
+	case "OKP":
+		require.Equal(t, "Ed25519", k.Crv)
+
+		return ed25519.PublicKey(decode(k.X))
+	}
+
+	t.Fatalf("unexpected kty %q", k.Kty)
+
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, alg := range []string{jwk.AlgRS256, jwk.AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			pair, err := jwk.Generate(alg)
+			require.NoError(t, err)
+			require.Equal(t, alg, pair.Algorithm)
+
+			private, err := jwk.ParsePrivateKey(pair.PrivateKey)
+			require.NoError(t, err)
+
+			public, err := jwk.ParsePublicKey(pair.PublicKey)
+			require.NoError(t, err)
+			require.True(t, public.(interface{ Equal(crypto.PublicKey) bool }).Equal(private.Public()))
+
+			key, err := jwk.FromPublicKey(alg, pair.KID, public)
+			require.NoError(t, err)
+			require.Equal(t, pair.KID, key.Thumbprint())
+
+			// Через JSON ключ проходит без потерь
+			b, err := json.Marshal(jwk.Set{Keys: []jwk.Key{key}})
+			require.NoError(t, err)
+
+			var set jwk.Set
+			require.NoError(t, json.Unmarshal(b, &set))
+			require.Equal(t, []jwk.Key{key}, set.Keys)
+			require.Equal(t, "sig", set.Keys[0].Use)
+			require.Equal(t, pair.KID, set.Keys[0].Kid)
+
+			// Восстановленным из JWK ключом проверяется подпись закрытым
+			restored := publicFromJWK(t, set.Keys[0])
+			require.True(t, restored.(interface{ Equal(crypto.PublicKey) bool }).Equal(public))
+
+			msg := []byte("header.payload")
+
+			switch k := restored.(type) {
+			case *rsa.PublicKey:
+				digest := sha256.Sum256(msg)
+
+				sig, err := private.Sign(rand.Reader, digest[:], crypto.SHA256)
+				require.NoError(t, err)
+				require.NoError(t, rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig))
+			case ed25519.PublicKey:
+				sig, err := private.Sign(rand.Reader, msg, crypto.Hash(0))
+				require.NoError(t, err)
+				require.True(t, ed25519.Verify(k, msg, sig))
+			}
+		})
+	}
+}
+
+func TestFromPublicKey_AlgorithmMismatch(t *testing.T) {
+	pair, err := jwk.Generate(jwk.AlgEdDSA)
+	require.NoError(t, err)
+
+	public, err := jwk.ParsePublicKey(pair.PublicKey)
+	require.NoError(t, err)
+
+	_, err = jwk.FromPublicKey(jwk.AlgRS256, pair.KID, public)
+	require.ErrorIs(t, err, jwk.ErrUnsupportedAlgorithm)
+}
+
+func TestGenerate_Unsupported(t *testing.T) {
+	_, err := jwk.Generate(jwk.AlgHS256)
+	require.ErrorIs(t, err, jwk.ErrUnsupportedAlgorithm)
+}
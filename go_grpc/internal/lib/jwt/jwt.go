@@ -1,29 +1,263 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"go_grpc/internal/domain/models"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrUnknownKey   = errors.New("unknown verification key")
+)
+
+// claims токена: зарегистрированные (iss, sub, aud, exp, nbf, iat, jti)
+// и данные пользователя
+type Claims struct {
+	jwt.RegisteredClaims
+
+	UID     int64    `json:"uid"`             // ID пользователя, дублирует sub числом
+	Email   string   `json:"email"`           // Email пользователя
+	AppID   int      `json:"app_id"`          // ID приложения, для которого выдан токен
+	Roles   []string `json:"roles,omitempty"` // Роли пользователя
+	IsAdmin bool     `json:"is_admin"`        // Права администратора на момент выдачи
+}
+
+// ключ подписи. Пустой ключ - HS256 с секретом приложения
+type SigningKey struct {
+	KID       string        // ID ключа для заголовка kid
+	Algorithm string        // RS256 или EdDSA
+	Key       crypto.Signer // Закрытый ключ
+}
+
+// ключ проверки подписи и ожидаемые издатель и получатель токена
+type VerificationKey struct {
+	Algorithm string // Алгоритм, которым должен быть подписан токен
+	Key       any    // []byte для HS256, *rsa.PublicKey для RS256, ed25519.PublicKey для EdDSA
+	Issuer    string // Ожидаемый iss, пустой - не проверяется
+	Audience  string // Ожидаемый aud, пустой - не проверяется
+}
+
+// возвращает ключ проверки подписи по app_id и заголовку kid
+// (пустой kid - токен подписан секретом приложения). Если ключа нет
+// или им больше нельзя проверять токены, возвращает ErrUnknownKey:
+// токен недействителен. Остальные ошибки (например, БД) означают, что
+// проверить токен не удалось, и возвращаются из ParseToken как есть
+type KeyFunc func(appID int, kid string) (VerificationKey, error)
+
+// сообщает, отозван ли токен с проверенной подписью
+type RevokedFunc func(claims Claims) (bool, error)
+
 // создание JWT токена для пользователя и приложения
-func NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
-	// Создание нового токена с алгоритмом HS256
-	token := jwt.New(jwt.SigningMethodHS256)
-
-	// Добавление claims (данных) в токен
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(duration).Unix()
-	claims["app_id"] = app.ID
-
-	// Подпись токена секретным ключом приложения
-	tokenString, err := token.SignedString([]byte(app.Secret))
+func NewToken(user models.User, app models.App, key SigningKey, duration time.Duration) (string, error) {
+	// Без ключа подписываем HS256 секретом приложения
+	method := jwt.SigningMethod(jwt.SigningMethodHS256)
+	var signKey any = []byte(app.Secret)
+
+	if key.Key != nil {
+		method = jwt.GetSigningMethod(key.Algorithm)
+		if method == nil {
+			return "", fmt.Errorf("unsupported signing algorithm %q", key.Algorithm)
+		}
+
+		signKey = key.Key
+	}
+
+	jti, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    app.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+		UID:     user.ID,
+		Email:   user.Email,
+		AppID:   app.ID,
+		Roles:   user.Roles(),
+		IsAdmin: user.IsAdmin,
+	}
+
+	if app.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{app.Audience}
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if key.KID != "" {
+		token.Header["kid"] = key.KID
+	}
+
+	// Подпись токена
+	tokenString, err := token.SignedString(signKey)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// параметры проверки токена
+type parseOptions struct {
+	leeway time.Duration
+	now    func() time.Time
+}
+
+type ParseOption func(*parseOptions)
+
+// WithLeeway - допустимое расхождение часов при проверке exp, nbf и iat
+func WithLeeway(leeway time.Duration) ParseOption {
+	return func(o *parseOptions) {
+		o.leeway = leeway
+	}
+}
+
+// WithTimeFunc подменяет текущее время, например в тестах
+func WithTimeFunc(now func() time.Time) ParseOption {
+	return func(o *parseOptions) {
+		o.now = now
+	}
+}
+
+// проверка подписи и срока действия токена. Ключ выбирается
+// по app_id и kid из токена, поэтому app_id проверяется вместе с подписью.
+// Если для приложения заданы издатель и получатель, они тоже проверяются
+func ParseToken(tokenString string, keys KeyFunc, opts ...ParseOption) (Claims, error) {
+	o := parseOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		claims    Claims
+		expected  VerificationKey
+		lookupErr error // Ключ не удалось получить не по вине токена
+	)
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		if claims.AppID == 0 {
+			return nil, errors.New("app_id claim is missing")
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := keys(claims.AppID, kid)
+		if err != nil {
+			if !errors.Is(err, ErrUnknownKey) {
+				lookupErr = err
+			}
+
+			return nil, err
+		}
+
+		// Алгоритм задает ключ, а не заголовок токена
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing algorithm %q", t.Method.Alg())
+		}
+
+		expected = key
+
+		return key.Key, nil
+	},
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}),
+		jwt.WithLeeway(o.leeway),
+		jwt.WithTimeFunc(o.now),
+		jwt.WithIssuedAt(),
+	)
+	// Сбой хранилища - не повод считать токен недействительным
+	if lookupErr != nil {
+		return Claims{}, lookupErr
+	}
+
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	// Парсер проверяет exp, только если он есть, а бессрочные токены мы не выдаем
+	if claims.ExpiresAt == nil {
+		return Claims{}, fmt.Errorf("%w: exp claim is missing", ErrInvalidToken)
+	}
+
+	if claims.UID == 0 {
+		return Claims{}, fmt.Errorf("%w: uid claim is missing", ErrInvalidToken)
+	}
+
+	if claims.Subject != "" && claims.Subject != strconv.FormatInt(claims.UID, 10) {
+		return Claims{}, fmt.Errorf("%w: sub does not match uid", ErrInvalidToken)
+	}
+
+	if expected.Issuer != "" && claims.Issuer != expected.Issuer {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+
+	if expected.Audience != "" && !slices.Contains(claims.Audience, expected.Audience) {
+		return Claims{}, fmt.Errorf("%w: token is not intended for %q", ErrInvalidToken, expected.Audience)
+	}
+
+	return claims, nil
+}
+
+// полная проверка токена: подпись, срок действия и отзыв
+func Validate(tokenString string, keys KeyFunc, revoked RevokedFunc, opts ...ParseOption) (Claims, error) {
+	claims, err := ParseToken(tokenString, keys, opts...)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	isRevoked, err := revoked(claims)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if isRevoked {
+		return Claims{}, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// RevokedBefore сообщает, выдан ли токен раньше момента отзыва at.
+// iat хранится с точностью до секунды, поэтому токены, выданные
+// в ту же секунду, что и отзыв, остаются действительными
+func RevokedBefore(claims Claims, at *time.Time) bool {
+	if at == nil {
+		return false
+	}
+
+	// Время выдачи неизвестно - считаем токен выданным до отзыва
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return claims.IssuedAt.Before(at.Truncate(time.Second))
+}
+
+// newID генерирует уникальный ID токена (jti)
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
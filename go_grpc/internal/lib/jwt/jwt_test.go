@@ -0,0 +1,253 @@
+package jwt_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/jwt"
+)
+
+var (
+	testUser = models.User{ID: 42, Email: "user@example.com", IsAdmin: true}
+	testApp  = models.App{ID: 1, Name: "web", Secret: "test-secret", Issuer: "sso", Audience: "web"}
+)
+
+// secretKeys - ключи проверки для токенов, подписанных секретом testApp
+func secretKeys(appID int, kid string) (jwt.VerificationKey, error) {
+	if appID != testApp.ID || kid != "" {
+		return jwt.VerificationKey{}, jwt.ErrUnknownKey
+	}
+
+	return jwt.VerificationKey{
+		Algorithm: jwk.AlgHS256,
+		Key:       []byte(testApp.Secret),
+		Issuer:    testApp.Issuer,
+		Audience:  testApp.Audience,
+	}, nil
+}
+
+func TestNewToken_Claims(t *testing.T) {
+	token, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseToken(token, secretKeys)
+	require.NoError(t, err)
+
+	require.Equal(t, testUser.ID, claims.UID)
+	require.Equal(t, testUser.Email, claims.Email)
+	require.Equal(t, testApp.ID, claims.AppID)
+	require.Equal(t, []string{models.RoleUser, models.RoleAdmin}, claims.Roles)
+	require.True(t, claims.IsAdmin)
+
+	require.Equal(t, "42", claims.Subject)
+	require.Equal(t, "sso", claims.Issuer)
+	require.Equal(t, gojwt.ClaimStrings{"web"}, claims.Audience)
+	require.NotEmpty(t, claims.ID)
+	require.NotNil(t, claims.IssuedAt)
+	require.NotNil(t, claims.NotBefore)
+	require.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 2*time.Second)
+
+	// У каждого токена свой jti
+	other, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Hour)
+	require.NoError(t, err)
+
+	otherClaims, err := jwt.ParseToken(other, secretKeys)
+	require.NoError(t, err)
+	require.NotEqual(t, claims.ID, otherClaims.ID)
+}
+
+func TestNewToken_AsymmetricKey(t *testing.T) {
+	for _, alg := range []string{jwk.AlgRS256, jwk.AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			pair, err := jwk.Generate(alg)
+			require.NoError(t, err)
+
+			private, err := jwk.ParsePrivateKey(pair.PrivateKey)
+			require.NoError(t, err)
+
+			public, err := jwk.ParsePublicKey(pair.PublicKey)
+			require.NoError(t, err)
+
+			token, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{
+				KID:       pair.KID,
+				Algorithm: alg,
+				Key:       private,
+			}, time.Hour)
+			require.NoError(t, err)
+
+			keys := func(appID int, kid string) (jwt.VerificationKey, error) {
+				if kid != pair.KID {
+					return jwt.VerificationKey{}, jwt.ErrUnknownKey
+				}
+
+				return jwt.VerificationKey{Algorithm: alg, Key: public}, nil
+			}
+
+			claims, err := jwt.ParseToken(token, keys)
+			require.NoError(t, err)
+			require.Equal(t, testUser.ID, claims.UID)
+
+			// Токен, подписанный секретом, не проходит, если ключ приложения асимметричный
+			hsToken, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Hour)
+			require.NoError(t, err)
+
+			_, err = jwt.ParseToken(hsToken, func(int, string) (jwt.VerificationKey, error) {
+				return jwt.VerificationKey{Algorithm: alg, Key: public}, nil
+			})
+			require.ErrorIs(t, err, jwt.ErrInvalidToken)
+		})
+	}
+}
+
+func TestParseToken_ClockSkew(t *testing.T) {
+	token, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	cases := []struct {
+		name    string
+		now     time.Time     // Время проверяющей стороны
+		leeway  time.Duration // Допустимое расхождение часов
+		wantErr bool
+	}{
+		{name: "Valid", now: now},
+		{name: "Expired", now: now.Add(time.Minute + 10*time.Second), wantErr: true},
+		{name: "Expired within leeway", now: now.Add(time.Minute + 10*time.Second), leeway: 30 * time.Second},
+		{name: "Expired beyond leeway", now: now.Add(2 * time.Minute), leeway: 30 * time.Second, wantErr: true},
+		// Часы проверяющей стороны отстают: nbf и iat еще в будущем
+		{name: "Issued in the future", now: now.Add(-10 * time.Second), wantErr: true},
+		{name: "Issued in the future within leeway", now: now.Add(-10 * time.Second), leeway: 30 * time.Second},
+		{name: "Issued in the future beyond leeway", now: now.Add(-time.Minute), leeway: 30 * time.Second, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := jwt.ParseToken(token, secretKeys,
+				jwt.WithLeeway(tc.leeway),
+				jwt.WithTimeFunc(func() time.Time { return tc.now }),
+			)
+
+			if tc.wantErr {
+				require.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParseToken_Invalid(t *testing.T) {
+	valid := func(c *gojwt.MapClaims) {}
+
+	cases := []struct {
+		name   string
+		modify func(c *gojwt.MapClaims) // Изменение claims корректного токена
+		alg    gojwt.SigningMethod
+		secret string
+	}{
+		{name: "Wrong secret", modify: valid, secret: "other-secret"},
+		{name: "Wrong issuer", modify: func(c *gojwt.MapClaims) { (*c)["iss"] = "evil" }},
+		{name: "Wrong audience", modify: func(c *gojwt.MapClaims) { (*c)["aud"] = "mobile" }},
+		{name: "Missing audience", modify: func(c *gojwt.MapClaims) { delete(*c, "aud") }},
+		{name: "Missing exp", modify: func(c *gojwt.MapClaims) { delete(*c, "exp") }},
+		{name: "Missing uid", modify: func(c *gojwt.MapClaims) { delete(*c, "uid") }},
+		{name: "Missing app_id", modify: func(c *gojwt.MapClaims) { delete(*c, "app_id") }},
+		{name: "Subject mismatch", modify: func(c *gojwt.MapClaims) { (*c)["sub"] = "7" }},
+		{name: "Unexpected algorithm", modify: valid, alg: gojwt.SigningMethodHS512},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+
+			claims := gojwt.MapClaims{
+				"uid":    testUser.ID,
+				"email":  testUser.Email,
+				"app_id": testApp.ID,
+				"sub":    "42",
+				"iss":    "sso",
+				"aud":    "web",
+				"iat":    now.Unix(),
+				"nbf":    now.Unix(),
+				"exp":    now.Add(time.Hour).Unix(),
+			}
+			tc.modify(&claims)
+
+			alg := tc.alg
+			if alg == nil {
+				alg = gojwt.SigningMethodHS256
+			}
+
+			secret := tc.secret
+			if secret == "" {
+				secret = testApp.Secret
+			}
+
+			token, err := gojwt.NewWithClaims(alg, claims).SignedString([]byte(secret))
+			require.NoError(t, err)
+
+			_, err = jwt.ParseToken(token, secretKeys)
+			require.ErrorIs(t, err, jwt.ErrInvalidToken)
+		})
+	}
+}
+
+func TestParseToken_KeyLookupFailed(t *testing.T) {
+	token, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Hour)
+	require.NoError(t, err)
+
+	// Неизвестный ключ - токен недействителен
+	_, err = jwt.ParseToken(token, func(int, string) (jwt.VerificationKey, error) {
+		return jwt.VerificationKey{}, fmt.Errorf("%w: app not found", jwt.ErrUnknownKey)
+	})
+	require.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	// Сбой хранилища - токен проверить не удалось, но он не недействителен
+	errDB := errors.New("database is locked")
+
+	_, err = jwt.ParseToken(token, func(int, string) (jwt.VerificationKey, error) {
+		return jwt.VerificationKey{}, errDB
+	})
+	require.ErrorIs(t, err, errDB)
+	require.NotErrorIs(t, err, jwt.ErrInvalidToken)
+}
+
+func TestValidate_Revoked(t *testing.T) {
+	token, err := jwt.NewToken(testUser, testApp, jwt.SigningKey{}, time.Hour)
+	require.NoError(t, err)
+
+	notRevoked := func(jwt.Claims) (bool, error) { return false, nil }
+	revoked := func(jwt.Claims) (bool, error) { return true, nil }
+
+	claims, err := jwt.Validate(token, secretKeys, notRevoked)
+	require.NoError(t, err)
+	require.Equal(t, testUser.ID, claims.UID)
+
+	_, err = jwt.Validate(token, secretKeys, revoked)
+	require.ErrorIs(t, err, jwt.ErrTokenRevoked)
+}
+
+func TestRevokedBefore(t *testing.T) {
+	issued := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{IssuedAt: gojwt.NewNumericDate(issued)}}
+
+	at := func(t time.Time) *time.Time { return &t }
+
+	require.False(t, jwt.RevokedBefore(claims, nil))
+	require.True(t, jwt.RevokedBefore(claims, at(issued.Add(time.Second))))
+	require.False(t, jwt.RevokedBefore(claims, at(issued.Add(-time.Second))))
+	// Выдан в ту же секунду, что и отзыв
+	require.False(t, jwt.RevokedBefore(claims, at(issued.Add(500*time.Millisecond))))
+	// Без iat время выдачи неизвестно
+	require.True(t, jwt.RevokedBefore(jwt.Claims{}, at(issued)))
+}
@@ -0,0 +1,66 @@
+package slogredact_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"log/slog" // для логирования
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/lib/logger/handlers/slogredact"
+)
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type request struct {
+	URL      string
+	Password string
+}
+
+func (r request) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("url", r.URL), slog.String("password", r.Password))
+}
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := slog.New(slogredact.NewHandler(slog.NewJSONHandler(&buf, nil), slogredact.Options{
+		Keys:        slogredact.DefaultKeys,
+		QueryParams: slogredact.DefaultQueryParams,
+	}))
+
+	log.With(slog.String("token", "t0ken")).Info("test",
+		slog.String("Password", "secret"),
+		slog.String("uri", "/url?alias=a&token=abc&Key=k#top"),
+		slog.Group("auth", slog.String("authorization", "Basic xyz"), slog.String("user", "bob")),
+		slog.Any("req", request{URL: "https://example.com/?password=p", Password: "p"}),
+		slog.Any("content", &credentials{Email: "bob@example.com", Password: "p"}),
+	)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	require.Equal(t, slogredact.Redacted, entry["token"])
+	require.Equal(t, slogredact.Redacted, entry["Password"])
+	require.Equal(t, "/url?alias=a&token=[REDACTED]&Key=[REDACTED]#top", entry["uri"])
+
+	auth := entry["auth"].(map[string]any)
+	require.Equal(t, slogredact.Redacted, auth["authorization"])
+	require.Equal(t, "bob", auth["user"])
+
+	req := entry["req"].(map[string]any)
+	require.Equal(t, "https://example.com/?password=[REDACTED]", req["url"])
+	require.Equal(t, slogredact.Redacted, req["password"])
+
+	content := entry["content"].(map[string]any)
+	require.Equal(t, "bob@example.com", content["email"])
+	require.Equal(t, slogredact.Redacted, content["password"])
+
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "t0ken")
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender сохраняет каждое письмо в отдельный .eml файл.
+// Для разработки и тестов без почтового сервера
+type FileSender struct {
+	dir  string // Каталог для писем
+	from string // Отправитель
+}
+
+func NewFile(dir, from string) (*FileSender, error) {
+	const op = "mail.NewFile"
+
+	// Письма содержат одноразовые токены, посторонним их читать нельзя
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, msg Message) error {
+	const op = "mail.FileSender.Send"
+
+	now := time.Now()
+
+	data, err := build(s.from, msg, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Имя сортируется по времени, случайный суффикс исключает совпадения
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogSender пишет письма в лог вместо отправки. Только для локальной
+// разработки: в лог попадают одноразовые токены из писем
+type LogSender struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.log.InfoContext(ctx, "mail is not sent, logging it instead",
+		slog.String("op", "mail.LogSender.Send"),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+
+	return nil
+}
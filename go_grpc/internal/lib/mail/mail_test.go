@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	sender, err := NewFile(dir, "SSO <no-reply@example.com>")
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Подтверждение email",
+		Body:    "line 1\nline 2",
+	})
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+
+	msg := string(data)
+	require.Contains(t, msg, "From: SSO <no-reply@example.com>\r\n")
+	require.Contains(t, msg, "To: user@example.com\r\n")
+	// Не-ASCII тема кодируется по RFC 2047
+	require.Contains(t, msg, "Subject: =?utf-8?q?")
+	require.True(t, strings.HasSuffix(msg, "\r\n\r\nline 1\r\nline 2"))
+}
+
+func TestBuild_RejectsHeaderInjection(t *testing.T) {
+	_, err := build("no-reply@example.com", Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "subject",
+	}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = build("no-reply@example.com", Message{
+		To:      "user@example.com",
+		Subject: "subject\nBcc: victim@example.com",
+	}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidHeader)
+}
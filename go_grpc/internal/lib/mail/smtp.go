@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSender отправляет письма через SMTP сервер.
+// Если сервер поддерживает STARTTLS, соединение шифруется
+type SMTPSender struct {
+	addr string    // Адрес сервера host:port
+	host string    // Имя сервера для проверки сертификата
+	from string    // Отправитель, например "SSO <no-reply@example.com>"
+	auth smtp.Auth // nil - без авторизации
+}
+
+func NewSMTP(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		// PlainAuth сам откажется передавать пароль без TLS
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		host: host,
+		from: from,
+		auth: auth,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	const op = "mail.SMTPSender.Send"
+
+	data, err := build(s.from, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	from, err := netmail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("%s: parse from: %w", op, err)
+	}
+
+	to, err := netmail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%s: parse to: %w", op, err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// net/smtp не принимает контекст, поэтому его срок переносим на соединение
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("%s: starttls: %w", op, err)
+		}
+	}
+
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("%s: auth: %w", op, err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,32 @@
+package opaque
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// длина случайной части токена в байтах
+const tokenBytes = 32
+
+// генерация случайного токена. Клиенту отдается token,
+// в БД хранится только hash
+func New() (token string, hash []byte, err error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+
+	return token, Hash(token), nil
+}
+
+// хэш токена для поиска в БД. Токены случайные и длинные,
+// поэтому соль и медленный хэш не нужны
+func Hash(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+
+	return sum[:]
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// длина префикса SHA-1, по которому хэши разбиты на файлы
+const prefixLength = 5
+
+// BreachList - офлайн список утекших паролей в формате Pwned Passwords
+// с разбиением по префиксу (k-anonymity): в каталоге лежат файлы
+// XXXXX.txt, где XXXXX - первые 5 символов SHA-1 в hex, а строки файла -
+// SUFFIX:COUNT, остаток хэша и сколько раз пароль встречался в утечках.
+// Такой каталог создает haveibeenpwned-downloader с флагом -s false.
+// Для проверки читается только файл с нужным префиксом
+type BreachList struct {
+	dir      string // Каталог с файлами диапазонов
+	minCount int    // С какого числа появлений пароль считается утекшим
+}
+
+func NewBreachList(dir string, minCount int) (*BreachList, error) {
+	const op = "password.NewBreachList"
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: %s is not a directory", op, dir)
+	}
+
+	if minCount < 1 {
+		minCount = 1
+	}
+
+	return &BreachList{dir: dir, minCount: minCount}, nil
+}
+
+func (l *BreachList) Breached(ctx context.Context, password string) (bool, error) {
+	const op = "password.BreachList.Breached"
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		// Нет файла - нет утекших паролей с таким префиксом
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		lineSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+
+		// Строки с нулевым счетчиком - дополнение, скрывающее размер диапазона
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid line in %s.txt: %w", op, prefix, err)
+		}
+
+		return n >= l.minCount, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	ctx := context.Background()
+
+	policy := Policy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	violations, err := policy.Check(ctx, "Str0ng!pass", "user@example.com")
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	violations, err = policy.Check(ctx, "short", "user@example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"must be at least 8 characters long",
+		"must contain an uppercase letter",
+		"must contain a digit",
+		"must contain a symbol",
+	}, violations)
+
+	// Длина считается в символах, а не в байтах
+	violations, err = Policy{MinLength: 8}.Check(ctx, "пароль12", "")
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	violations, err = Policy{}.Check(ctx, strings.Repeat("я", 40), "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"must be at most 72 bytes long"}, violations)
+}
+
+func TestPolicy_Check_SameAsEmail(t *testing.T) {
+	ctx := context.Background()
+
+	for _, pass := range []string{"user@example.com", "USER@example.com", "user", "User"} {
+		violations, err := Policy{}.Check(ctx, pass, "user@example.com")
+		require.NoError(t, err)
+		require.Equal(t, []string{"must not be the same as the email"}, violations, pass)
+	}
+}
+
+func TestBreachList(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	writeRange(t, dir, map[string]int{
+		"password123": 250000,
+		"rarely-seen": 1,
+	}, "0000000000000000000000000000000000A:0")
+
+	list, err := NewBreachList(dir, 2)
+	require.NoError(t, err)
+
+	breached, err := list.Breached(ctx, "password123")
+	require.NoError(t, err)
+	require.True(t, breached)
+
+	// Ниже порога minCount
+	breached, err = list.Breached(ctx, "rarely-seen")
+	require.NoError(t, err)
+	require.False(t, breached)
+
+	// Файла с таким префиксом нет
+	breached, err = list.Breached(ctx, "correct horse battery staple")
+	require.NoError(t, err)
+	require.False(t, breached)
+
+	// Проверка по списку идет последней и только для подходящего пароля
+	violations, err := Policy{MinLength: 8, Breached: list}.Check(ctx, "password123", "user@example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"has appeared in a data breach, choose another one"}, violations)
+
+	_, err = NewBreachList(filepath.Join(dir, "missing"), 1)
+	require.Error(t, err)
+}
+
+// writeRange записывает хэши паролей в файлы диапазонов, как haveibeenpwned-downloader
+func writeRange(t *testing.T, dir string, passwords map[string]int, padding string) {
+	t.Helper()
+
+	for pass, count := range passwords {
+		sum := sha1.Sum([]byte(pass))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+		f, err := os.OpenFile(filepath.Join(dir, hash[:5]+".txt"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+
+		_, err = f.WriteString(padding + "\r\n" + hash[5:] + ":" + strconv.Itoa(count) + "\r\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+}
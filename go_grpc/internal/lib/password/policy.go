@@ -0,0 +1,104 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt учитывает только первые 72 байта пароля, более длинные отклоняем,
+// чтобы пароли с общим началом не считались одинаковыми
+const MaxBytes = 72
+
+// Checker - проверка пароля по списку утекших паролей
+type Checker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// Policy - требования к паролю
+type Policy struct {
+	MinLength     int     // Минимальная длина в символах
+	RequireUpper  bool    // Нужна заглавная буква
+	RequireLower  bool    // Нужна строчная буква
+	RequireDigit  bool    // Нужна цифра
+	RequireSymbol bool    // Нужен символ, не буква и не цифра
+	Breached      Checker // Список утекших паролей, nil - не проверяется
+}
+
+// Check возвращает описания всех нарушенных требований.
+// Пустой результат - пароль подходит. Ошибка - только если не удалось
+// проверить пароль по списку утекших
+func (p Policy) Check(ctx context.Context, password string, email string) ([]string, error) {
+	var violations []string
+
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+
+	if len(password) > MaxBytes {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes long", MaxBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if sameAsEmail(password, email) {
+		violations = append(violations, "must not be the same as the email")
+	}
+
+	// Нарушения выше видны сразу, список утекших проверяем последним
+	if len(violations) > 0 || p.Breached == nil {
+		return violations, nil
+	}
+
+	breached, err := p.Breached.Breached(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if breached {
+		violations = append(violations, "has appeared in a data breach, choose another one")
+	}
+
+	return violations, nil
+}
+
+// sameAsEmail - пароль совпадает с email или его частью до @ без учета регистра
+func sameAsEmail(password string, email string) bool {
+	if email == "" {
+		return false
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+
+	return strings.EqualFold(password, email) || strings.EqualFold(password, local)
+}
@@ -0,0 +1,79 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// длина ключа: AES-256
+const KeySize = 32
+
+var (
+	ErrInvalidKey = errors.New("encryption key must be 32 bytes")
+	ErrDecrypt    = errors.New("decryption failed")
+)
+
+// Box шифрует небольшие секреты для хранения в БД (AES-256-GCM).
+// Результат Seal: nonce || ciphertext || tag
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// NewFromBase64 - ключ в base64, например из openssl rand -base64 32
+func NewFromBase64(key string) (*Box, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+
+	return New(raw)
+}
+
+// Seal шифрует plaintext. aad не шифруется, но проверяется при Open:
+// через него шифротекст привязывается к записи (например, к ID
+// пользователя), чтобы его нельзя было переставить в чужую строку
+func (b *Box) Seal(plaintext []byte, aad []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Open расшифровывает результат Seal с тем же aad
+func (b *Box) Open(sealed []byte, aad []byte) ([]byte, error) {
+	n := b.aead.NonceSize()
+	if len(sealed) < n+b.aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := b.aead.Open(nil, sealed[:n], sealed[n:], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
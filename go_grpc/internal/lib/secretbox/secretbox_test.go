@@ -0,0 +1,50 @@
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{7}, KeySize))
+	require.NoError(t, err)
+
+	secret := []byte("totp secret")
+
+	sealed, err := box.Seal(secret, []byte("user:1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), string(secret))
+
+	opened, err := box.Open(sealed, []byte("user:1"))
+	require.NoError(t, err)
+	require.Equal(t, secret, opened)
+
+	// Шифротекст привязан к aad
+	_, err = box.Open(sealed, []byte("user:2"))
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	// Одинаковые данные шифруются по-разному
+	again, err := box.Seal(secret, []byte("user:1"))
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, again)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = box.Open(sealed, []byte("user:1"))
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = box.Open([]byte("short"), nil)
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	_, err := New([]byte("short"))
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = NewFromBase64("not base64!")
+	require.Error(t, err)
+
+	_, err = NewFromBase64("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	require.NoError(t, err)
+}
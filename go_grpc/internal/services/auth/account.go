@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/emailaddr"
+	"go_grpc/internal/lib/logger/sl"
+	"go_grpc/internal/lib/mail"
+	"go_grpc/internal/lib/opaque"
+	"go_grpc/internal/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// сколько ждать отправки одного письма о сбросе пароля, которое уходит в фоне
+const resetMailTimeout = 30 * time.Second
+
+var ErrEmailNotVerified = errors.New("email is not verified")
+
+// AccountPolicy - подтверждение email и сброс пароля
+type AccountPolicy struct {
+	RequireVerifiedEmail bool          // Вход только с подтвержденным email
+	VerifyTokenTTL       time.Duration // Время жизни ссылки подтверждения email
+	ResetTokenTTL        time.Duration // Время жизни ссылки сброса пароля
+	// Шаблоны ссылок в письмах, {token} заменяется токеном.
+	// Пустой шаблон - в письме только сам токен
+	VerifyURL string
+	ResetURL  string
+}
+
+type AccountStorage interface {
+	SaveUserToken(ctx context.Context, token models.UserToken) error
+	UserToken(ctx context.Context, purpose string, tokenHash []byte) (models.UserToken, error)
+	VerifyEmail(ctx context.Context, tokenID int64, userID int64, at time.Time) error
+	ResetPassword(ctx context.Context, tokenID int64, userID int64, passHash []byte, at time.Time) error
+}
+
+// подтверждение email по токену из письма
+func (a *Auth) VerifyEmail(ctx context.Context, token string) error {
+	const op = "Auth.VerifyEmail"
+
+	log := a.log.With(slog.String("op", op))
+
+	t, err := a.userToken(ctx, models.PurposeVerifyEmail, token)
+	if err != nil {
+		log.Warn("invalid verification token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", t.UserID))
+
+	if err := a.accounts.VerifyEmail(ctx, t.ID, t.UserID, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTokenUsed) {
+			// Токен использовали параллельным запросом
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to verify email", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email verified")
+
+	return nil
+}
+
+// запрос на сброс пароля. Ответ не зависит от того, зарегистрирован ли
+// email: поиск пользователя и отправка письма идут в фоне через очередь
+func (a *Auth) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "Auth.RequestPasswordReset"
+
+	email = emailaddr.Normalize(email)
+
+	log := a.log.With(slog.String("op", op), slog.String("email", email))
+
+	log.Info("password reset requested")
+
+	// Переполненная очередь - не ошибка для клиента, иначе по ответу
+	// было бы видно нагрузку; письмо можно запросить повторно
+	if err := a.resetMail.Enqueue(email); err != nil {
+		log.Error("failed to queue password reset mail", sl.Err(err))
+	}
+
+	return nil
+}
+
+func (a *Auth) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := a.usrProvider.User(ctx, email)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := a.newUserToken(ctx, user.ID, models.PurposeResetPassword, a.accountPolicy.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf(
+			"Чтобы задать новый пароль, перейдите по ссылке:\n\n%s\n\n"+
+				"Ссылка действует %s. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			link(a.accountPolicy.ResetURL, token), humanDuration(a.accountPolicy.ResetTokenTTL),
+		),
+	})
+}
+
+// смена пароля по токену из письма. Все сессии пользователя завершаются
+func (a *Auth) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	const op = "Auth.ResetPassword"
+
+	log := a.log.With(slog.String("op", op))
+
+	t, err := a.userToken(ctx, models.PurposeResetPassword, token)
+	if err != nil {
+		log.Warn("invalid password reset token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", t.UserID))
+
+	user, err := a.usrProvider.UserByID(ctx, t.UserID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Токен не тратится, пока пароль не подойдет под политику
+	if err := a.validatePassword(ctx, newPassword, user.Email, "new_password"); err != nil {
+		log.Info("new password rejected", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.accounts.ResetPassword(ctx, t.ID, t.UserID, passHash, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTokenUsed) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to reset password", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset, all sessions revoked")
+
+	return nil
+}
+
+// sendVerification отправляет письмо со ссылкой подтверждения email
+func (a *Auth) sendVerification(ctx context.Context, user models.User) error {
+	token, err := a.newUserToken(ctx, user.ID, models.PurposeVerifyEmail, a.accountPolicy.VerifyTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf(
+			"Чтобы подтвердить email, перейдите по ссылке:\n\n%s\n\nСсылка действует %s.\n",
+			link(a.accountPolicy.VerifyURL, token), humanDuration(a.accountPolicy.VerifyTokenTTL),
+		),
+	})
+}
+
+// newUserToken создает одноразовый токен и сохраняет его хэш
+func (a *Auth) newUserToken(ctx context.Context, userID int64, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	err = a.accounts.SaveUserToken(ctx, models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// userToken находит действующий токен из письма. Неизвестный,
+// использованный и истекший токены - ErrInvalidToken
+func (a *Auth) userToken(ctx context.Context, purpose string, token string) (models.UserToken, error) {
+	t, err := a.accounts.UserToken(ctx, purpose, opaque.Hash(token))
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return models.UserToken{}, ErrInvalidToken
+	}
+	if err != nil {
+		return models.UserToken{}, err
+	}
+
+	if t.UsedAt != nil || !time.Now().Before(t.ExpiresAt) {
+		return models.UserToken{}, ErrInvalidToken
+	}
+
+	return t, nil
+}
+
+// link подставляет токен в шаблон ссылки
+func link(template string, token string) string {
+	if template == "" {
+		return token
+	}
+
+	return strings.ReplaceAll(template, "{token}", url.QueryEscape(token))
+}
+
+// humanDuration - срок действия ссылки для текста письма
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d ч", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%d мин", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLink(t *testing.T) {
+	require.Equal(t, "abc_-1", link("", "abc_-1"))
+	require.Equal(t,
+		"https://example.com/verify?token=abc_-1",
+		link("https://example.com/verify?token={token}", "abc_-1"),
+	)
+}
+
+func TestHumanDuration(t *testing.T) {
+	require.Equal(t, "24 ч", humanDuration(24*time.Hour))
+	require.Equal(t, "90 мин", humanDuration(90*time.Minute))
+	require.Equal(t, "30s", humanDuration(30*time.Second))
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/logger/sl"
+)
+
+// издатель токенов по умолчанию, как в схеме таблицы apps
+const defaultAppIssuer = "sso"
+
+// длина секрета приложения в байтах до кодирования
+const appSecretSize = 32
+
+type AppStorage interface {
+	Apps(ctx context.Context) ([]models.App, error)
+	SaveApp(ctx context.Context, app models.App) (int, error)
+	SetAppSecret(ctx context.Context, appID int, secret string) error
+	DeleteApp(ctx context.Context, appID int) error
+}
+
+// AppKeyRotator выпускает ключи подписи приложениям, у которых их нет
+type AppKeyRotator interface {
+	Rotate(ctx context.Context) error
+}
+
+// регистрация нового приложения администратором. Секрет возвращается
+// только здесь и при RotateAppSecret, в списке приложений его нет
+func (a *Auth) CreateApp(ctx context.Context, token string, app models.App) (models.App, error) {
+	const op = "Auth.CreateApp"
+
+	log := a.log.With(slog.String("op", op), slog.String("name", app.Name))
+
+	callerID, err := a.authorizeAdmin(ctx, token)
+	if err != nil {
+		log.Warn("app creation denied", sl.Err(err))
+
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("caller_id", callerID))
+
+	if app.Issuer == "" {
+		app.Issuer = defaultAppIssuer
+	}
+
+	app.Secret, err = newAppSecret()
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app.ID, err = a.appStorage.SaveApp(ctx, app)
+	if err != nil {
+		log.Warn("failed to save app", sl.Err(err))
+
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Пустой audience в токенах заменяется названием приложения
+	if app.Audience == "" {
+		app.Audience = app.Name
+	}
+
+	log = log.With(slog.Int("app_id", app.ID))
+
+	// При асимметричной подписи ключ нужен сразу: иначе до следующей
+	// ротации токены приложения подписывались бы секретом
+	if err := a.appKeys.Rotate(ctx); err != nil {
+		log.Error("failed to issue signing key", sl.Err(err))
+	}
+
+	log.Info("app created")
+
+	return app, nil
+}
+
+// список приложений без секретов
+func (a *Auth) ListApps(ctx context.Context, token string) ([]models.App, error) {
+	const op = "Auth.ListApps"
+
+	log := a.log.With(slog.String("op", op))
+
+	if _, err := a.authorizeAdmin(ctx, token); err != nil {
+		log.Warn("listing apps denied", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apps, err := a.appStorage.Apps(ctx)
+	if err != nil {
+		log.Error("failed to get apps", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range apps {
+		apps[i].Secret = ""
+	}
+
+	return apps, nil
+}
+
+// замена секрета приложения. Токены, подписанные старым секретом (HS256),
+// сразу перестают проходить проверку
+func (a *Auth) RotateAppSecret(ctx context.Context, token string, appID int) (string, error) {
+	const op = "Auth.RotateAppSecret"
+
+	log := a.log.With(slog.String("op", op), slog.Int("app_id", appID))
+
+	callerID, err := a.authorizeAdmin(ctx, token)
+	if err != nil {
+		log.Warn("app secret rotation denied", sl.Err(err))
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("caller_id", callerID))
+
+	secret, err := newAppSecret()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.appStorage.SetAppSecret(ctx, appID, secret); err != nil {
+		log.Warn("failed to set app secret", sl.Err(err))
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app secret rotated")
+
+	return secret, nil
+}
+
+// удаление приложения. Его ключи и refresh-токены удаляются,
+// выданные access-токены перестают проходить проверку
+func (a *Auth) DeleteApp(ctx context.Context, token string, appID int) error {
+	const op = "Auth.DeleteApp"
+
+	log := a.log.With(slog.String("op", op), slog.Int("app_id", appID))
+
+	callerID, err := a.authorizeAdmin(ctx, token)
+	if err != nil {
+		log.Warn("app deletion denied", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("caller_id", callerID))
+
+	if err := a.appStorage.DeleteApp(ctx, appID); err != nil {
+		log.Warn("failed to delete app", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app deleted")
+
+	return nil
+}
+
+// newAppSecret генерирует случайный секрет приложения
+func newAppSecret() (string, error) {
+	b := make([]byte, appSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate app secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
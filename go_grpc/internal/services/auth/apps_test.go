@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/storage"
+)
+
+func TestNewAppSecret(t *testing.T) {
+	secret, err := newAppSecret()
+	require.NoError(t, err)
+
+	raw, err := base64.RawURLEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	require.Len(t, raw, appSecretSize)
+
+	other, err := newAppSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+}
+
+// Управлять приложениями может только администратор
+func TestApps_RequireAdmin(t *testing.T) {
+	a, s := newTestAuth(t)
+	ctx := context.Background()
+
+	adminID, appID := newTestUser(t, a, s)
+	require.NoError(t, s.SetAdmin(ctx, adminID, true))
+	admin := login(t, a, appID).AccessToken
+
+	const userEmail = "other@example.com"
+
+	_, err := a.RegisterNewUser(ctx, userEmail, testPassword)
+	require.NoError(t, err)
+
+	result, err := a.Login(ctx, userEmail, testPassword, appID)
+	require.NoError(t, err)
+	user := result.Tokens.AccessToken
+
+	// Токены подписаны секретом appID, поэтому меняем секрет другого приложения
+	target, err := s.SaveApp(ctx, models.App{Name: "target", Secret: "target-secret", Issuer: defaultAppIssuer})
+	require.NoError(t, err)
+
+	calls := []struct {
+		name string
+		call func(token string) error
+	}{
+		{
+			name: "CreateApp",
+			call: func(token string) error {
+				_, err := a.CreateApp(ctx, token, models.App{Name: "billing"})
+				return err
+			},
+		},
+		{
+			name: "ListApps",
+			call: func(token string) error {
+				_, err := a.ListApps(ctx, token)
+				return err
+			},
+		},
+		{
+			name: "RotateAppSecret",
+			call: func(token string) error {
+				_, err := a.RotateAppSecret(ctx, token, target)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.call(user), ErrPermissionDenied)
+			require.ErrorIs(t, tt.call("malformed"), ErrInvalidToken)
+			require.NoError(t, tt.call(admin))
+		})
+	}
+
+	// Удаление проверяем последним: приложение после него не найти
+	t.Run("DeleteApp", func(t *testing.T) {
+		require.ErrorIs(t, a.DeleteApp(ctx, user, target), ErrPermissionDenied)
+		require.ErrorIs(t, a.DeleteApp(ctx, "malformed", target), ErrInvalidToken)
+		require.NoError(t, a.DeleteApp(ctx, admin, target))
+
+		_, err := s.App(ctx, target)
+		require.ErrorIs(t, err, storage.ErrAppNotFound)
+	})
+
+	// Права проверяются по БД, а не по выданному токену
+	t.Run("Revoked", func(t *testing.T) {
+		require.NoError(t, s.SetAdmin(ctx, adminID, false))
+
+		_, err := a.ListApps(ctx, admin)
+		require.ErrorIs(t, err, ErrPermissionDenied)
+	})
+}
+
+func TestLogin_UnknownApp(t *testing.T) {
+	a, s := newTestAuth(t)
+	_, appID := newTestUser(t, a, s)
+
+	_, err := a.Login(context.Background(), testEmail, testPassword, appID+1)
+	require.ErrorIs(t, err, storage.ErrAppNotFound)
+}
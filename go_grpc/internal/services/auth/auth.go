@@ -2,14 +2,23 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/emailaddr"
+	"go_grpc/internal/lib/jwk"
 	"go_grpc/internal/lib/jwt"
 	"go_grpc/internal/lib/logger/sl"
+	"go_grpc/internal/lib/mail"
+	"go_grpc/internal/lib/opaque"
+	"go_grpc/internal/lib/password"
+	"go_grpc/internal/lib/secretbox"
+	"go_grpc/internal/services/keys"
 	"go_grpc/internal/storage"
 
 	"golang.org/x/crypto/bcrypt"
@@ -17,15 +26,34 @@ import (
 
 // Auth - сервис аутентификации
 type Auth struct {
-	log         *slog.Logger  // Логгер
-	usrSaver    UserSaver     // Сохранение пользователей
-	usrProvider UserProvider  // Получение пользователей
-	appProvider AppProvider   // Получение приложений
-	tokenTTL    time.Duration // Время жизни токенов
+	log              *slog.Logger        // Логгер
+	usrSaver         UserSaver           // Сохранение пользователей
+	usrProvider      UserProvider        // Получение пользователей
+	appProvider      AppProvider         // Получение приложений
+	tokenStorage     RefreshTokenStorage // Хранение refresh-токенов
+	keyProvider      KeyProvider         // Ключи подписи access-токенов
+	signingCipher    *secretbox.Box      // Расшифровка закрытых ключей подписи
+	attempts         LoginAttempts       // Учет неудачных попыток входа
+	lockout          LockoutPolicy       // Блокировка после неудачных попыток
+	accounts         AccountStorage      // Токены подтверждения email и сброса пароля
+	mailer           mail.Sender         // Отправка писем
+	accountPolicy    AccountPolicy       // Подтверждение email и сброс пароля
+	passwordPolicy   password.Policy     // Требования к паролям
+	twoFactorStorage TwoFactorStorage    // TOTP, коды восстановления и незавершенные входы
+	twoFactor        TwoFactorPolicy     // Двухфакторная аутентификация
+	appStorage       AppStorage          // Управление приложениями
+	appKeys          AppKeyRotator       // Выпуск ключей подписи новым приложениям
+	tokenTTL         time.Duration       // Время жизни access-токенов
+	refreshTTL       time.Duration       // Время жизни refresh-токенов
+	clockSkew        time.Duration       // Допустимое расхождение часов при проверке токенов
+	resetMail        *mailQueue          // Фоновая отправка писем о сбросе пароля
 }
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrSelfRevoke         = errors.New("cannot revoke own admin role")
 )
 
 type UserSaver interface {
@@ -34,10 +62,12 @@ type UserSaver interface {
 		email string,
 		passHash []byte,
 	) (uid int64, err error)
+	SetAdmin(ctx context.Context, userID int64, isAdmin bool) error
 }
 
 type UserProvider interface {
 	User(ctx context.Context, email string) (models.User, error)
+	UserByID(ctx context.Context, id int64) (models.User, error)
 	IsAdmin(ctx context.Context, userID int64) (bool, error)
 }
 
@@ -45,80 +75,221 @@ type AppProvider interface {
 	App(ctx context.Context, appID int) (models.App, error)
 }
 
-func New(
-	log *slog.Logger,
-	userSaver UserSaver,
-	userProvider UserProvider,
-	appProvider AppProvider,
-	tokenTTL time.Duration,
-) *Auth {
-	return &Auth{
-		usrSaver:    userSaver,
-		usrProvider: userProvider,
-		log:         log,
-		appProvider: appProvider,
-		tokenTTL:    tokenTTL, // Время жизни возвращаемых токенов
+type RefreshTokenStorage interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RefreshToken(ctx context.Context, tokenHash []byte) (models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID int64, next models.RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, family string, at time.Time) error
+	RevokeUserTokens(ctx context.Context, userID int64, at time.Time) error
+}
+
+type KeyProvider interface {
+	ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error)
+	SigningKey(ctx context.Context, kid string) (models.SigningKey, error)
+	VerificationKeys(ctx context.Context, appID int, now time.Time) ([]models.SigningKey, error)
+}
+
+// Storage - все хранилища сервиса. В приложении их реализует одна БД
+type Storage interface {
+	UserSaver
+	UserProvider
+	AppProvider
+	RefreshTokenStorage
+	KeyProvider
+	LoginAttempts
+	AccountStorage
+	TwoFactorStorage
+	AppStorage
+}
+
+// Deps - зависимости и настройки сервиса аутентификации
+type Deps struct {
+	Log            *slog.Logger    // Логгер
+	Storage        Storage         // Пользователи, приложения, токены, ключи и 2FA
+	Mailer         mail.Sender     // Отправка писем
+	AppKeys        AppKeyRotator   // Выпуск ключей подписи новым приложениям
+	SigningCipher  *secretbox.Box  // Расшифровка закрытых ключей подписи
+	Lockout        LockoutPolicy   // Блокировка после неудачных попыток
+	AccountPolicy  AccountPolicy   // Подтверждение email и сброс пароля
+	PasswordPolicy password.Policy // Требования к паролям
+	TwoFactor      TwoFactorPolicy // Двухфакторная аутентификация
+	TokenTTL       time.Duration   // Время жизни access-токенов
+	RefreshTTL     time.Duration   // Время жизни refresh-токенов
+	ClockSkew      time.Duration   // Допустимое расхождение часов при проверке токенов
+}
+
+func New(deps Deps) *Auth {
+	a := &Auth{
+		log:              deps.Log,
+		usrSaver:         deps.Storage,
+		usrProvider:      deps.Storage,
+		appProvider:      deps.Storage,
+		tokenStorage:     deps.Storage,
+		keyProvider:      deps.Storage,
+		signingCipher:    deps.SigningCipher,
+		attempts:         deps.Storage,
+		lockout:          deps.Lockout,
+		accounts:         deps.Storage,
+		mailer:           deps.Mailer,
+		accountPolicy:    deps.AccountPolicy,
+		passwordPolicy:   deps.PasswordPolicy,
+		twoFactorStorage: deps.Storage,
+		twoFactor:        deps.TwoFactor,
+		appStorage:       deps.Storage,
+		appKeys:          deps.AppKeys,
+		tokenTTL:         deps.TokenTTL,
+		refreshTTL:       deps.RefreshTTL,
+		clockSkew:        deps.ClockSkew,
 	}
+
+	a.resetMail = newMailQueue(deps.Log, a.sendPasswordReset)
+
+	return a
+}
+
+// Close дожидается отправки писем, поставленных в очередь.
+// Вызывается после остановки серверов, когда новых запросов нет
+func (a *Auth) Close(ctx context.Context) error {
+	const op = "Auth.Close"
+
+	if err := a.resetMail.Close(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
-// аутентификация пользователя и выдача токена
+// аутентификация пользователя и выдача пары токенов. Если у пользователя
+// включена 2FA, вместо токенов возвращается challenge для LoginVerify2FA
 func (a *Auth) Login(
 	ctx context.Context,
 	email string,
 	password string, // пароль в чистом виде
 	appID int, // ID приложения, в котором логинится пользователь
-) (string, error) {
+) (models.LoginResult, error) {
 	const op = "Auth.Login"
 
+	// Email хранится нормализованным, блокировка тоже считается по нему
+	email = emailaddr.Normalize(email)
+
 	// Логгер с контекстом операции
 	log := a.log.With(slog.String("op", op), slog.String("username", email)) // password либо не логируем, либо логируем в замаскированном виде
 
 	log.Info("attempting to login user")
 
+	// После серии неудач вход временно заблокирован, пароль даже не проверяем
+	if err := a.checkLocked(ctx, email); err != nil {
+		log.Warn("login is locked", sl.Err(err))
+
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	// Достаем пользователя из БД
 	user, err := a.usrProvider.User(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("user not found", sl.Err(err))
+			log.Warn("user not found", sl.Err(err))
 
-			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			// Проверка пароля займет столько же, сколько для существующего пользователя
+			compareDummyHash(password)
+			a.loginFailed(ctx, log, email)
+
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
 
-		a.log.Error("failed to get user", sl.Err(err))
+		log.Error("failed to get user", sl.Err(err))
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// Проверяем корректность полученного пароля
 	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		a.log.Info("invalid credentials", sl.Err(err))
+		log.Info("invalid credentials", sl.Err(err))
+
+		a.loginFailed(ctx, log, email)
 
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	// О неподтвержденном email сообщаем только после верного пароля,
+	// иначе по ответу можно было бы узнать, зарегистрирован ли email
+	if a.accountPolicy.RequireVerifiedEmail && !user.EmailVerified {
+		log.Info("email is not verified")
+
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
 	}
 
 	// Получаем информацию о приложении
 	app, err := a.appProvider.App(ctx, appID)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", slog.Int("app_id", appID))
+		} else {
+			log.Error("failed to get app", sl.Err(err))
+		}
+
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// С включенной 2FA пароля недостаточно
+	enabled, err := a.twoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to check two-factor status", sl.Err(err))
+
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if enabled {
+		// Без ключа шифрования секрет TOTP не расшифровать, и challenge
+		// нельзя было бы завершить: сообщаем об этом сразу
+		if a.twoFactor.Cipher == nil {
+			log.Error("two-factor is enabled for user, but encryption key is not configured")
+
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrTwoFactorDisabled)
+		}
+
+		result, err := a.newLoginChallenge(ctx, user.ID, app.ID)
+		if err != nil {
+			log.Error("failed to create login challenge", sl.Err(err))
+
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("password accepted, waiting for second factor")
+
+		return result, nil
+	}
+
+	// С 2FA счетчик неудач сбрасывается только после второго фактора
+	if err := a.attempts.ResetLoginFailures(ctx, email); err != nil {
+		log.Error("failed to reset login failures", sl.Err(err))
 	}
 
 	log.Info("user logged in successfully")
 
-	// Создаем JWT
-	token, err := jwt.NewToken(user, app, a.tokenTTL)
+	// Каждый вход начинает новую цепочку refresh-токенов
+	family, err := newFamilyID()
+	if err != nil {
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pair, err := a.issueTokens(ctx, user, app, family, 0)
 	if err != nil {
-		a.log.Error("failed to generate token", sl.Err(err))
+		a.log.Error("failed to generate tokens", sl.Err(err))
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return token, nil
+	return models.LoginResult{Tokens: pair}, nil
 }
 
 // регистрация нового пользователя
 func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (int64, error) {
 	const op = "Auth.RegisterNewUser"
 
+	// Нормализация не дает завести второй аккаунт на тот же email в другом регистре
+	email = emailaddr.Normalize(email)
+
 	// Логгер с контекстом
 	log := a.log.With(
 		slog.String("op", op),
@@ -127,6 +298,12 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (
 
 	log.Info("registering user")
 
+	if err := a.validateCredentials(ctx, email, pass); err != nil {
+		log.Info("invalid registration data", sl.Err(err))
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	// Генерируем хэш и соль для пароля
 	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
 	if err != nil {
@@ -143,6 +320,12 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// Пользователь уже создан, поэтому ошибку отправки не возвращаем:
+	// новое письмо можно получить через сброс пароля
+	if err := a.sendVerification(ctx, models.User{ID: id, Email: email}); err != nil {
+		log.Error("failed to send verification mail", sl.Err(err))
+	}
+
 	return id, nil
 }
 
@@ -166,3 +349,426 @@ func (a *Auth) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 
 	return isAdmin, nil
 }
+
+// выдача прав администратора. token - токен вызывающего, он должен быть администратором
+func (a *Auth) GrantAdmin(ctx context.Context, token string, userID int64) error {
+	const op = "Auth.GrantAdmin"
+
+	return a.setAdmin(ctx, op, token, userID, true)
+}
+
+// отзыв прав администратора. Свои права отозвать нельзя,
+// чтобы в системе не остаться без администраторов
+func (a *Auth) RevokeAdmin(ctx context.Context, token string, userID int64) error {
+	const op = "Auth.RevokeAdmin"
+
+	return a.setAdmin(ctx, op, token, userID, false)
+}
+
+func (a *Auth) setAdmin(ctx context.Context, op string, token string, userID int64, isAdmin bool) error {
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+	)
+
+	callerID, err := a.authorizeAdmin(ctx, token)
+	if err != nil {
+		log.Warn("admin role change denied", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("caller_id", callerID))
+
+	if !isAdmin && callerID == userID {
+		return fmt.Errorf("%s: %w", op, ErrSelfRevoke)
+	}
+
+	if err := a.usrSaver.SetAdmin(ctx, userID, isAdmin); err != nil {
+		log.Error("failed to change admin role", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("admin role changed", slog.Bool("is_admin", isAdmin))
+
+	return nil
+}
+
+// authenticate проверяет access-токен и возвращает ID его владельца
+func (a *Auth) authenticate(ctx context.Context, token string) (int64, error) {
+	claims, err := a.validateToken(ctx, token)
+	if errors.Is(err, jwt.ErrInvalidToken) || errors.Is(err, jwt.ErrTokenRevoked) {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.UID, nil
+}
+
+// authorizeAdmin проверяет токен и возвращает ID вызывающего,
+// если у него есть права администратора
+func (a *Auth) authorizeAdmin(ctx context.Context, token string) (int64, error) {
+	callerID, err := a.authenticate(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+
+	isAdmin, err := a.usrProvider.IsAdmin(ctx, callerID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		// Пользователь удален после выдачи токена
+		return 0, ErrPermissionDenied
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !isAdmin {
+		return 0, ErrPermissionDenied
+	}
+
+	return callerID, nil
+}
+
+// проверка access-токена для сервисов, которые его принимают (интроспекция).
+// Недействительный токен - не ошибка: возвращается TokenInfo с Active: false
+func (a *Auth) ValidateToken(ctx context.Context, token string) (models.TokenInfo, error) {
+	const op = "Auth.ValidateToken"
+
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.validateToken(ctx, token)
+	if errors.Is(err, jwt.ErrInvalidToken) || errors.Is(err, jwt.ErrTokenRevoked) {
+		log.Info("inactive token", sl.Err(err))
+
+		return models.TokenInfo{}, nil
+	}
+	if err != nil {
+		log.Error("failed to validate token", sl.Err(err))
+
+		return models.TokenInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info := models.TokenInfo{
+		Active:    true,
+		UserID:    claims.UID,
+		Email:     claims.Email,
+		AppID:     claims.AppID,
+		Roles:     claims.Roles,
+		IsAdmin:   claims.IsAdmin,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	if claims.IssuedAt != nil {
+		info.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return info, nil
+}
+
+// validateToken проверяет подпись, срок действия, издателя и получателя
+// токена по данным приложения из токена, а также что токен не отозван
+// выходом со всех устройств и пользователь не удален
+func (a *Auth) validateToken(ctx context.Context, token string) (jwt.Claims, error) {
+	keys := func(appID int, kid string) (jwt.VerificationKey, error) {
+		app, err := a.appProvider.App(ctx, appID)
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return jwt.VerificationKey{}, fmt.Errorf("%w: %w", jwt.ErrUnknownKey, err)
+		}
+		if err != nil {
+			return jwt.VerificationKey{}, err
+		}
+
+		var key jwt.VerificationKey
+		if kid == "" {
+			key, err = a.secretKey(ctx, app)
+		} else {
+			key, err = a.publicKey(ctx, app.ID, kid)
+		}
+		if err != nil {
+			return jwt.VerificationKey{}, err
+		}
+
+		key.Issuer = app.Issuer
+		key.Audience = app.Audience
+
+		return key, nil
+	}
+
+	revoked := func(claims jwt.Claims) (bool, error) {
+		user, err := a.usrProvider.UserByID(ctx, claims.UID)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return jwt.RevokedBefore(claims, user.TokensRevokedAt), nil
+	}
+
+	return jwt.Validate(token, keys, revoked, jwt.WithLeeway(a.clockSkew))
+}
+
+// secretKey - ключ проверки токена, подписанного HS256 секретом приложения.
+// Если у приложения есть асимметричный ключ, такие токены не принимаются:
+// секрет мог остаться у сервисов, проверявших токены до перехода на ключи
+func (a *Auth) secretKey(ctx context.Context, app models.App) (jwt.VerificationKey, error) {
+	_, err := a.keyProvider.ActiveSigningKey(ctx, app.ID)
+	if err == nil {
+		return jwt.VerificationKey{}, fmt.Errorf("%w: app requires asymmetric signature", jwt.ErrUnknownKey)
+	}
+	if !errors.Is(err, storage.ErrKeyNotFound) {
+		return jwt.VerificationKey{}, err
+	}
+
+	return jwt.VerificationKey{Algorithm: jwk.AlgHS256, Key: []byte(app.Secret)}, nil
+}
+
+// publicKey - ключ проверки токена, подписанного ключом kid приложения appID
+func (a *Auth) publicKey(ctx context.Context, appID int, kid string) (jwt.VerificationKey, error) {
+	key, err := a.keyProvider.SigningKey(ctx, kid)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return jwt.VerificationKey{}, fmt.Errorf("%w: %w", jwt.ErrUnknownKey, err)
+	}
+	if err != nil {
+		return jwt.VerificationKey{}, err
+	}
+
+	if key.AppID != appID {
+		return jwt.VerificationKey{}, fmt.Errorf("%w: signing key belongs to another app", jwt.ErrUnknownKey)
+	}
+
+	if key.VerifyUntil != nil && !time.Now().Before(*key.VerifyUntil) {
+		return jwt.VerificationKey{}, fmt.Errorf("%w: signing key expired", jwt.ErrUnknownKey)
+	}
+
+	public, err := jwk.ParsePublicKey(key.PublicKey)
+	if err != nil {
+		return jwt.VerificationKey{}, err
+	}
+
+	return jwt.VerificationKey{Algorithm: key.Algorithm, Key: public}, nil
+}
+
+// открытые ключи для проверки токенов (JWKS). appID == 0 - всех приложений
+func (a *Auth) JWKS(ctx context.Context, appID int) (jwk.Set, error) {
+	const op = "Auth.JWKS"
+
+	keys, err := a.keyProvider.VerificationKeys(ctx, appID, time.Now())
+	if err != nil {
+		return jwk.Set{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	set := jwk.Set{Keys: make([]jwk.Key, 0, len(keys))}
+
+	for _, key := range keys {
+		public, err := jwk.ParsePublicKey(key.PublicKey)
+		if err != nil {
+			return jwk.Set{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		k, err := jwk.FromPublicKey(key.Algorithm, key.KID, public)
+		if err != nil {
+			return jwk.Set{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		set.Keys = append(set.Keys, k)
+	}
+
+	return set, nil
+}
+
+// обмен refresh-токена на новую пару токенов. Использованный токен
+// становится недействительным. Повторное предъявление уже использованного
+// токена означает, что он украден: вся цепочка токенов этого входа отзывается
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	const op = "Auth.Refresh"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.tokenStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", stored.UserID), slog.String("family", stored.Family))
+
+	if stored.RevokedAt != nil {
+		log.Info("revoked refresh token used")
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if stored.UsedAt != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, a.reuseDetected(ctx, log, stored.Family))
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := a.appProvider.App(ctx, stored.AppID)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pair, err := a.issueTokens(ctx, user, app, stored.Family, stored.ID)
+	if errors.Is(err, storage.ErrTokenUsed) {
+		// Токен использовали параллельно - тоже считаем повторным использованием
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, a.reuseDetected(ctx, log, stored.Family))
+	}
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tokens refreshed")
+
+	return pair, nil
+}
+
+// reuseDetected отзывает цепочку токенов, в которой повторно
+// использован refresh-токен
+func (a *Auth) reuseDetected(ctx context.Context, log *slog.Logger, family string) error {
+	log.Warn("refresh token reuse detected, revoking token family")
+
+	if err := a.tokenStorage.RevokeTokenFamily(ctx, family, time.Now()); err != nil {
+		log.Error("failed to revoke token family", sl.Err(err))
+
+		return err
+	}
+
+	return ErrInvalidToken
+}
+
+// выход: отзыв цепочки токенов, к которой относится refreshToken,
+// или, если allSessions, всех токенов пользователя. В первом случае
+// выданные access-токены остаются действительными до истечения срока,
+// во втором - перестают проходить ValidateToken
+func (a *Auth) Logout(ctx context.Context, refreshToken string, allSessions bool) error {
+	const op = "Auth.Logout"
+
+	log := a.log.With(slog.String("op", op))
+
+	// Использованный или отозванный токен тоже подходит: он все еще
+	// указывает на пользователя и цепочку
+	stored, err := a.tokenStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", stored.UserID), slog.Bool("all_sessions", allSessions))
+
+	if allSessions {
+		err = a.tokenStorage.RevokeUserTokens(ctx, stored.UserID, time.Now())
+	} else {
+		err = a.tokenStorage.RevokeTokenFamily(ctx, stored.Family, time.Now())
+	}
+	if err != nil {
+		log.Error("failed to revoke tokens", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged out")
+
+	return nil
+}
+
+// issueTokens выдает access-токен и новый refresh-токен цепочки family.
+// Если previousID не 0, refresh-токен с этим ID помечается использованным
+func (a *Auth) issueTokens(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+	family string,
+	previousID int64,
+) (models.TokenPair, error) {
+	key, err := a.signingKey(ctx, app.ID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	// Создаем JWT
+	accessToken, err := jwt.NewToken(user, app, key, a.tokenTTL)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refreshToken, hash, err := opaque.New()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	now := time.Now()
+
+	next := models.RefreshToken{
+		UserID:    user.ID,
+		AppID:     app.ID,
+		Family:    family,
+		TokenHash: hash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.refreshTTL),
+	}
+
+	if previousID == 0 {
+		err = a.tokenStorage.SaveRefreshToken(ctx, next)
+	} else {
+		err = a.tokenStorage.RotateRefreshToken(ctx, previousID, next)
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// signingKey возвращает текущий ключ подписи приложения.
+// Без ключа токены подписываются HS256 секретом приложения
+func (a *Auth) signingKey(ctx context.Context, appID int) (jwt.SigningKey, error) {
+	key, err := a.keyProvider.ActiveSigningKey(ctx, appID)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return jwt.SigningKey{}, nil
+	}
+	if err != nil {
+		return jwt.SigningKey{}, err
+	}
+
+	private, err := keys.PrivateKey(a.signingCipher, key)
+	if err != nil {
+		return jwt.SigningKey{}, err
+	}
+
+	return jwt.SigningKey{KID: key.KID, Algorithm: key.Algorithm, Key: private}, nil
+}
+
+// newFamilyID генерирует ID цепочки refresh-токенов
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token family: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/jwt"
+	"go_grpc/internal/lib/mail"
+	"go_grpc/internal/lib/password"
+	"go_grpc/internal/lib/secretbox"
+	"go_grpc/internal/services/keys"
+	"go_grpc/internal/storage/sqlite"
+	"go_grpc/internal/storage/sqlite/sqlitetest"
+)
+
+const (
+	testEmail    = "user@example.com"
+	testPassword = "correct horse battery"
+)
+
+// newTestAuth создает сервис поверх пустой БД. Токены подписываются
+// HS256 секретом приложения, вход без подтверждения email разрешен
+func newTestAuth(t *testing.T) (*Auth, *sqlite.Storage) {
+	t.Helper()
+
+	log := slog.New(slog.DiscardHandler)
+	storage := sqlitetest.New(t)
+
+	rotator, err := keys.New(log, storage, jwk.AlgHS256, time.Hour, time.Hour, nil)
+	require.NoError(t, err)
+
+	lockout := LockoutPolicy{MaxFailures: 5, Lockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+	accountPolicy := AccountPolicy{VerifyTokenTTL: time.Hour, ResetTokenTTL: time.Hour}
+	twoFactor := TwoFactorPolicy{Issuer: "SSO", ChallengeTTL: time.Minute, MaxAttempts: 5, Cipher: testCipher(t)}
+
+	a := New(Deps{
+		Log:            log,
+		Storage:        storage,
+		Mailer:         mail.NewLog(log),
+		AppKeys:        rotator,
+		SigningCipher:  testCipher(t),
+		Lockout:        lockout,
+		AccountPolicy:  accountPolicy,
+		PasswordPolicy: password.Policy{MinLength: 8},
+		TwoFactor:      twoFactor,
+		TokenTTL:       time.Hour,
+		RefreshTTL:     time.Hour,
+		ClockSkew:      time.Second,
+	})
+	t.Cleanup(func() { _ = a.Close(context.Background()) })
+
+	return a, storage
+}
+
+// testCipher - шифрование секретов TOTP и закрытых ключей подписи.
+// Ключ постоянный, поэтому сервис и ротации ключей в одном тесте
+// расшифровывают данные друг друга
+func testCipher(t *testing.T) *secretbox.Box {
+	t.Helper()
+
+	box, err := secretbox.New(make([]byte, secretbox.KeySize))
+	require.NoError(t, err)
+
+	return box
+}
+
+// newTestUser регистрирует пользователя и приложение, в которое он входит
+func newTestUser(t *testing.T, a *Auth, storage *sqlite.Storage) (int64, int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	userID, err := a.RegisterNewUser(ctx, testEmail, testPassword)
+	require.NoError(t, err)
+
+	appID, err := storage.SaveApp(ctx, models.App{Name: "test", Secret: "test-secret", Issuer: defaultAppIssuer})
+	require.NoError(t, err)
+
+	return userID, appID
+}
+
+// login входит без 2FA и возвращает пару токенов
+func login(t *testing.T, a *Auth, appID int) models.TokenPair {
+	t.Helper()
+
+	result, err := a.Login(context.Background(), testEmail, testPassword, appID)
+	require.NoError(t, err)
+	require.Empty(t, result.Challenge)
+
+	return result.Tokens
+}
+
+func TestRefresh_Rotates(t *testing.T) {
+	a, storage := newTestAuth(t)
+	userID, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	first := login(t, a, appID)
+
+	second, err := a.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	require.NotEqual(t, first.AccessToken, second.AccessToken)
+
+	info, err := a.ValidateToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	require.True(t, info.Active)
+	require.Equal(t, userID, info.UserID)
+	require.Equal(t, appID, info.AppID)
+
+	// Новый refresh-токен снова обменивается
+	_, err = a.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	stolen := login(t, a, appID)
+	other := login(t, a, appID)
+
+	rotated, err := a.Refresh(ctx, stolen.RefreshToken)
+	require.NoError(t, err)
+
+	// Повторное предъявление использованного токена отзывает всю цепочку
+	_, err = a.Refresh(ctx, stolen.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = a.Refresh(ctx, rotated.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// Другой вход того же пользователя не затронут
+	_, err = a.Refresh(ctx, other.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestRefresh_Expired(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+
+	a.refreshTTL = -time.Minute
+	pair := login(t, a, appID)
+
+	_, err := a.Refresh(context.Background(), pair.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRefresh_UnknownToken(t *testing.T) {
+	a, _ := newTestAuth(t)
+
+	_, err := a.Refresh(context.Background(), "unknown")
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLogout(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	current := login(t, a, appID)
+	other := login(t, a, appID)
+
+	require.NoError(t, a.Logout(ctx, current.RefreshToken, false))
+
+	_, err := a.Refresh(ctx, current.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = a.Refresh(ctx, other.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestLogout_AllSessions(t *testing.T) {
+	a, storage := newTestAuth(t)
+	userID, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	current := login(t, a, appID)
+	other := login(t, a, appID)
+
+	require.NoError(t, a.Logout(ctx, current.RefreshToken, true))
+
+	_, err := a.Refresh(ctx, current.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = a.Refresh(ctx, other.RefreshToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// Access-токены, выданные до выхода, отзываются через tokens_revoked_at
+	user, err := storage.UserByID(ctx, userID)
+	require.NoError(t, err)
+	require.NotNil(t, user.TokensRevokedAt)
+
+	// Новый вход после выхода работает
+	pair := login(t, a, appID)
+
+	_, err = a.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestValidateToken(t *testing.T) {
+	a, storage := newTestAuth(t)
+	userID, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	pair := login(t, a, appID)
+	user := models.User{ID: userID, Email: testEmail}
+	app := models.App{ID: appID, Name: "test", Secret: "test-secret", Issuer: defaultAppIssuer}
+
+	info, err := a.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	require.True(t, info.Active)
+	require.Equal(t, userID, info.UserID)
+	require.Equal(t, testEmail, info.Email)
+	require.Equal(t, []string{models.RoleUser}, info.Roles)
+	require.Equal(t, defaultAppIssuer, info.Issuer)
+	require.Equal(t, []string{"test"}, info.Audience)
+
+	otherSecret := app
+	otherSecret.Secret = "other-secret"
+
+	unknownApp := app
+	unknownApp.ID = appID + 100
+
+	tests := []struct {
+		name     string
+		app      models.App
+		duration time.Duration
+	}{
+		{name: "bad signature", app: otherSecret, duration: time.Hour},
+		{name: "expired", app: app, duration: -time.Minute},
+		{name: "unknown app", app: unknownApp, duration: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewToken(user, tt.app, jwt.SigningKey{}, tt.duration)
+			require.NoError(t, err)
+
+			info, err := a.ValidateToken(ctx, token)
+			require.NoError(t, err)
+			require.False(t, info.Active)
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		info, err := a.ValidateToken(ctx, "not-a-jwt")
+		require.NoError(t, err)
+		require.False(t, info.Active)
+	})
+}
+
+// Сбой БД - ошибка, а не неактивный токен: иначе клиенты сочтут
+// действующий токен отозванным
+func TestValidateToken_StorageFailure(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+
+	pair := login(t, a, appID)
+
+	require.NoError(t, storage.Close())
+
+	_, err := a.ValidateToken(context.Background(), pair.AccessToken)
+	require.Error(t, err)
+}
+
+func TestValidateToken_IssuedBeforeRevocation(t *testing.T) {
+	a, storage := newTestAuth(t)
+	userID, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	pair := login(t, a, appID)
+
+	info, err := a.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	require.True(t, info.Active)
+
+	// iat хранится с точностью до секунды, поэтому отзыв заведомо позже выдачи
+	require.NoError(t, storage.RevokeUserTokens(ctx, userID, time.Now().Add(2*time.Second)))
+
+	info, err = a.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	require.False(t, info.Active)
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	// rotate меняет ключ приложения, если он старше period.
+	// Старый ключ принимается еще grace
+	rotate := func(period, grace time.Duration) {
+		t.Helper()
+
+		r, err := keys.New(slog.New(slog.DiscardHandler), storage, jwk.AlgEdDSA, period, grace, testCipher(t))
+		require.NoError(t, err)
+		require.NoError(t, r.Rotate(ctx))
+	}
+
+	active := func(token string) bool {
+		t.Helper()
+
+		info, err := a.ValidateToken(ctx, token)
+		require.NoError(t, err)
+
+		return info.Active
+	}
+
+	kids := func() []string {
+		t.Helper()
+
+		set, err := a.JWKS(ctx, appID)
+		require.NoError(t, err)
+
+		var kids []string
+		for _, key := range set.Keys {
+			kids = append(kids, key.Kid)
+		}
+
+		return kids
+	}
+
+	activeKID := func() string {
+		t.Helper()
+
+		key, err := storage.ActiveSigningKey(ctx, appID)
+		require.NoError(t, err)
+
+		return key.KID
+	}
+
+	rotate(time.Hour, time.Hour)
+	first := activeKID()
+	firstToken := login(t, a, appID).AccessToken
+	require.True(t, active(firstToken))
+	require.Equal(t, []string{first}, kids())
+
+	// Пока не наступил verify_until, токены старого ключа принимаются
+	rotate(time.Nanosecond, time.Hour)
+	second := activeKID()
+	secondToken := login(t, a, appID).AccessToken
+	require.True(t, active(firstToken))
+	require.True(t, active(secondToken))
+	require.Equal(t, []string{second, first}, kids())
+
+	// После verify_until ключ пропадает из JWKS, его токены не принимаются
+	rotate(time.Nanosecond, -time.Second)
+	third := activeKID()
+	require.False(t, active(secondToken))
+	require.True(t, active(firstToken))
+	require.Equal(t, []string{third, first}, kids())
+}
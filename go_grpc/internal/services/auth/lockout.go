@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go_grpc/internal/lib/logger/sl"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// политика блокировки входа после неудачных попыток
+type LockoutPolicy struct {
+	MaxFailures int           // Сколько неудач подряд допускается без блокировки, 0 и меньше - без блокировки
+	Lockout     time.Duration // Первая блокировка, каждая следующая неудача удваивает ее
+	MaxLockout  time.Duration // Максимальная блокировка
+	Window      time.Duration // Через сколько после последней неудачи счетчик сбрасывается
+}
+
+// вход временно заблокирован после серии неудачных попыток
+type LockedError struct {
+	Until time.Time // Когда можно повторить попытку
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+type LoginAttempts interface {
+	LoginLockedUntil(ctx context.Context, email string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, email string, at, resetBefore time.Time) (int, error)
+	LockLogin(ctx context.Context, email string, until time.Time) error
+	ResetLoginFailures(ctx context.Context, email string) error
+}
+
+// lockoutFor - длительность блокировки после failures неудач подряд
+func (p LockoutPolicy) lockoutFor(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+
+	d := p.Lockout
+	for i := p.MaxFailures; i < failures && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+
+	return min(d, p.MaxLockout)
+}
+
+// checkLocked возвращает *LockedError, если вход по email заблокирован
+func (a *Auth) checkLocked(ctx context.Context, email string) error {
+	lockedUntil, err := a.attempts.LoginLockedUntil(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Before(lockedUntil) {
+		return &LockedError{Until: lockedUntil}
+	}
+
+	return nil
+}
+
+// loginFailed учитывает неудачную попытку и при необходимости блокирует вход.
+// Ошибки учета только логируются: пользователь в любом случае получит отказ
+func (a *Auth) loginFailed(ctx context.Context, log *slog.Logger, email string) {
+	now := time.Now()
+
+	failures, err := a.attempts.RecordLoginFailure(ctx, email, now, now.Add(-a.lockout.Window))
+	if err != nil {
+		log.Error("failed to record login failure", sl.Err(err))
+
+		return
+	}
+
+	lockout := a.lockout.lockoutFor(failures)
+	if lockout == 0 {
+		return
+	}
+
+	if err := a.attempts.LockLogin(ctx, email, now.Add(lockout)); err != nil {
+		log.Error("failed to lock login", sl.Err(err))
+
+		return
+	}
+
+	log.Warn("login locked", slog.Int("failures", failures), slog.Duration("lockout", lockout))
+}
+
+// dummyHash вычисляется при запуске, а не при первом обращении:
+// иначе первый запрос с несуществующим email был бы заметно дольше
+var dummyHash = mustDummyHash()
+
+func mustDummyHash() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(fmt.Sprintf("generate dummy hash: %v", err))
+	}
+
+	return hash
+}
+
+// compareDummyHash тратит на проверку пароля столько же времени, сколько
+// для существующего пользователя, чтобы время ответа не выдавало,
+// зарегистрирован ли email
+func compareDummyHash(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutPolicy_LockoutFor(t *testing.T) {
+	policy := LockoutPolicy{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Minute},
+		{failures: 4, want: 2 * time.Minute},
+		{failures: 5, want: 4 * time.Minute},
+		{failures: 6, want: 8 * time.Minute},
+		{failures: 7, want: 10 * time.Minute},
+		{failures: 100, want: 10 * time.Minute},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, policy.lockoutFor(tc.failures), "failures: %d", tc.failures)
+	}
+
+	// Без MaxFailures блокировки нет
+	require.Zero(t, LockoutPolicy{Lockout: time.Minute}.lockoutFor(100))
+}
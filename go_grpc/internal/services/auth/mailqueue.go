@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"go_grpc/internal/lib/logger/sl"
+)
+
+const (
+	mailQueueSize    = 100 // Писем, ожидающих отправки
+	mailQueueWorkers = 4   // Писем, отправляемых одновременно
+)
+
+var (
+	errMailQueueFull   = errors.New("mail queue is full")
+	errMailQueueClosed = errors.New("mail queue is closed")
+)
+
+// mailQueue отправляет письма в фоне ограниченным числом горутин,
+// чтобы время ответа не зависело от отправки. Close дожидается
+// отправки уже принятых писем
+type mailQueue struct {
+	log    *slog.Logger
+	send   func(ctx context.Context, email string) error
+	queue  chan string
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+	// Отменяется, если Close не дождался отправки
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newMailQueue(log *slog.Logger, send func(ctx context.Context, email string) error) *mailQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := &mailQueue{
+		log:    log,
+		send:   send,
+		queue:  make(chan string, mailQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for range mailQueueWorkers {
+		q.wg.Go(q.work)
+	}
+
+	return q
+}
+
+// Enqueue ставит письмо на адрес email в очередь, не дожидаясь отправки
+func (q *mailQueue) Enqueue(email string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return errMailQueueClosed
+	}
+
+	select {
+	case q.queue <- email:
+		return nil
+	default:
+		return errMailQueueFull
+	}
+}
+
+// Close перестает принимать письма и ждет отправки очереди. Если ctx
+// отменен раньше, текущие отправки прерываются, остальные письма теряются
+func (q *mailQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+
+		return nil
+	}
+
+	q.closed = true
+	close(q.queue)
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+
+		return ctx.Err()
+	}
+}
+
+func (q *mailQueue) work() {
+	for email := range q.queue {
+		if q.ctx.Err() != nil {
+			q.log.Warn("mail dropped on shutdown", slog.String("email", email))
+
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(q.ctx, resetMailTimeout)
+		err := q.send(ctx, email)
+		cancel()
+
+		if err != nil {
+			q.log.Error("failed to send mail", slog.String("email", email), sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/lib/mail"
+)
+
+// recordingSender запоминает отправленные письма
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, msg mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, msg)
+
+	return nil
+}
+
+func (s *recordingSender) messages(subject string) []mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []mail.Message
+	for _, msg := range s.sent {
+		if msg.Subject == subject {
+			res = append(res, msg)
+		}
+	}
+
+	return res
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	a, storage := newTestAuth(t)
+	newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	sender := &recordingSender{}
+	a.mailer = sender
+
+	require.NoError(t, a.RequestPasswordReset(ctx, "User@Example.com"))
+	require.NoError(t, a.RequestPasswordReset(ctx, "unknown@example.com"))
+
+	// Close дожидается писем из очереди
+	require.NoError(t, a.Close(ctx))
+
+	sent := sender.messages("Сброс пароля")
+	require.Len(t, sent, 1)
+	require.Equal(t, testEmail, sent[0].To)
+
+	// После Close запросы принимаются, но письма не ставятся в очередь
+	require.NoError(t, a.RequestPasswordReset(ctx, testEmail))
+	require.ErrorIs(t, a.resetMail.Enqueue(testEmail), errMailQueueClosed)
+}
+
+func TestMailQueue_Full(t *testing.T) {
+	release := make(chan struct{})
+
+	q := newMailQueue(slog.New(slog.DiscardHandler), func(ctx context.Context, _ string) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+
+		return nil
+	})
+
+	// Воркеры заняты, дальше заполняется буфер
+	var err error
+	for range mailQueueWorkers + mailQueueSize + 1 {
+		if err = q.Enqueue("user@example.com"); err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, errMailQueueFull)
+
+	close(release)
+	require.NoError(t, q.Close(context.Background()))
+}
+
+func TestMailQueue_CloseTimeout(t *testing.T) {
+	q := newMailQueue(slog.New(slog.DiscardHandler), func(ctx context.Context, _ string) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	require.NoError(t, q.Enqueue("user@example.com"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Зависшая отправка прерывается по истечении ctx
+	require.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,410 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/logger/sl"
+	"go_grpc/internal/lib/opaque"
+	"go_grpc/internal/lib/secretbox"
+	"go_grpc/internal/lib/totp"
+	"go_grpc/internal/storage"
+)
+
+const (
+	recoveryCodeCount = 10 // Кодов восстановления на пользователя
+	recoveryCodeBytes = 10 // 80 бит: 16 символов base32
+	totpSkew          = 1  // Допуск на расхождение часов, шагов в каждую сторону
+)
+
+var (
+	ErrTwoFactorDisabled = errors.New("two-factor authentication is not configured")
+	ErrTOTPEnabled       = errors.New("totp already enabled")
+	ErrTOTPNotEnrolled   = errors.New("totp enrollment not started")
+	ErrInvalidCode       = errors.New("invalid code")
+)
+
+// TwoFactorPolicy - двухфакторная аутентификация
+type TwoFactorPolicy struct {
+	Issuer       string         // Название сервиса в приложении-аутентификаторе
+	ChallengeTTL time.Duration  // Сколько ждать код после проверки пароля
+	MaxAttempts  int            // Неверных кодов до отмены входа
+	Cipher       *secretbox.Box // Шифрование секретов TOTP, nil - 2FA выключена
+}
+
+type TwoFactorStorage interface {
+	SaveTOTP(ctx context.Context, userID int64, secret []byte, at time.Time) error
+	TOTP(ctx context.Context, userID int64) (models.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID int64, step int64, recoveryHashes [][]byte, at time.Time) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash []byte, at time.Time) error
+	SaveLoginChallenge(ctx context.Context, challenge models.LoginChallenge) error
+	LoginChallenge(ctx context.Context, tokenHash []byte) (models.LoginChallenge, error)
+	FailLoginChallenge(ctx context.Context, id int64, maxAttempts int, at time.Time) error
+	UseLoginChallenge(ctx context.Context, id int64, at time.Time) error
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// начало подключения TOTP. token - access-токен пользователя.
+// Возвращает ссылку otpauth:// для QR-кода и секрет для ручного ввода.
+// TOTP заработает после ConfirmTOTP
+func (a *Auth) EnrollTOTP(ctx context.Context, token string) (uri string, secret string, err error) {
+	const op = "Auth.EnrollTOTP"
+
+	log := a.log.With(slog.String("op", op))
+
+	if a.twoFactor.Cipher == nil {
+		return "", "", fmt.Errorf("%s: %w", op, ErrTwoFactorDisabled)
+	}
+
+	userID, err := a.authenticate(ctx, token)
+	if err != nil {
+		log.Warn("enrollment denied", sl.Err(err))
+
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", userID))
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	raw, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := a.twoFactor.Cipher.Seal(raw, totpAAD(userID))
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.twoFactorStorage.SaveTOTP(ctx, userID, sealed, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTOTPEnabled) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+		}
+
+		log.Error("failed to save totp", sl.Err(err))
+
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrollment started")
+
+	return totp.URI(a.twoFactor.Issuer, user.Email, raw), totp.EncodeSecret(raw), nil
+}
+
+// подтверждение TOTP первым кодом из приложения. Возвращает коды
+// восстановления: они показываются один раз, в БД только их хэши
+func (a *Auth) ConfirmTOTP(ctx context.Context, token string, code string) ([]string, error) {
+	const op = "Auth.ConfirmTOTP"
+
+	log := a.log.With(slog.String("op", op))
+
+	if a.twoFactor.Cipher == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrTwoFactorDisabled)
+	}
+
+	userID, err := a.authenticate(ctx, token)
+	if err != nil {
+		log.Warn("confirmation denied", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("user_id", userID))
+
+	t, err := a.twoFactorStorage.TOTP(ctx, userID)
+	if errors.Is(err, storage.ErrTOTPNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, ErrTOTPNotEnrolled)
+	}
+	if err != nil {
+		log.Error("failed to get totp", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if t.ConfirmedAt != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+	}
+
+	secret, err := a.twoFactor.Cipher.Open(t.Secret, totpAAD(userID))
+	if err != nil {
+		log.Error("failed to decrypt totp secret", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		log.Info("invalid totp code")
+
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.twoFactorStorage.ConfirmTOTP(ctx, userID, step, hashes, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTOTPEnabled) {
+			return nil, fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+		}
+
+		log.Error("failed to confirm totp", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enabled")
+
+	return codes, nil
+}
+
+// второй шаг входа: код из приложения или код восстановления
+// в обмен на challenge из Login
+func (a *Auth) LoginVerify2FA(ctx context.Context, challenge string, code string) (models.TokenPair, error) {
+	const op = "Auth.LoginVerify2FA"
+
+	log := a.log.With(slog.String("op", op))
+
+	if a.twoFactor.Cipher == nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrTwoFactorDisabled)
+	}
+
+	c, err := a.twoFactorStorage.LoginChallenge(ctx, opaque.Hash(challenge))
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		log.Error("failed to get login challenge", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if c.UsedAt != nil || !time.Now().Before(c.ExpiresAt) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	log = log.With(slog.Int64("user_id", c.UserID))
+
+	user, err := a.usrProvider.UserByID(ctx, c.UserID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Неверные коды блокируют вход так же, как неверные пароли: иначе
+	// код перебирали бы, получая новый challenge верным паролем
+	if err := a.checkLocked(ctx, user.Email); err != nil {
+		log.Warn("login is locked", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ok, err := a.checkSecondFactor(ctx, log, c.UserID, code)
+	if err != nil {
+		log.Error("failed to check second factor", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !ok {
+		log.Info("invalid second factor code")
+
+		if err := a.twoFactorStorage.FailLoginChallenge(ctx, c.ID, a.twoFactor.MaxAttempts, time.Now()); err != nil {
+			log.Error("failed to record invalid code", sl.Err(err))
+		}
+
+		a.loginFailed(ctx, log, user.Email)
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	if err := a.twoFactorStorage.UseLoginChallenge(ctx, c.ID, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTokenUsed) {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Счетчик неудач сбрасывается только после обоих факторов
+	if err := a.attempts.ResetLoginFailures(ctx, user.Email); err != nil {
+		log.Error("failed to reset login failures", sl.Err(err))
+	}
+
+	app, err := a.appProvider.App(ctx, c.AppID)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	family, err := newFamilyID()
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pair, err := a.issueTokens(ctx, user, app, family, 0)
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in with second factor")
+
+	return pair, nil
+}
+
+// twoFactorEnabled - подключен ли у пользователя TOTP
+func (a *Auth) twoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	t, err := a.twoFactorStorage.TOTP(ctx, userID)
+	if errors.Is(err, storage.ErrTOTPNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return t.ConfirmedAt != nil, nil
+}
+
+// newLoginChallenge начинает вход со вторым фактором
+func (a *Auth) newLoginChallenge(ctx context.Context, userID int64, appID int) (models.LoginResult, error) {
+	token, hash, err := opaque.New()
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(a.twoFactor.ChallengeTTL)
+
+	err = a.twoFactorStorage.SaveLoginChallenge(ctx, models.LoginChallenge{
+		UserID:    userID,
+		AppID:     appID,
+		TokenHash: hash,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	return models.LoginResult{Challenge: token, ChallengeExpiresAt: expiresAt}, nil
+}
+
+// checkSecondFactor проверяет код: 6 цифр - код TOTP, иначе код восстановления.
+// Каждый код принимается только один раз
+func (a *Auth) checkSecondFactor(ctx context.Context, log *slog.Logger, userID int64, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if isTOTPCode(code) {
+		t, err := a.twoFactorStorage.TOTP(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+
+		secret, err := a.twoFactor.Cipher.Open(t.Secret, totpAAD(userID))
+		if err != nil {
+			return false, err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+
+		err = a.twoFactorStorage.UseTOTPStep(ctx, userID, step)
+		if errors.Is(err, storage.ErrTokenUsed) {
+			log.Warn("totp code replayed")
+
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	err := a.twoFactorStorage.UseRecoveryCode(ctx, userID, opaque.Hash(normalizeRecoveryCode(code)), time.Now())
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	log.Info("recovery code used")
+
+	return true, nil
+}
+
+// isTOTPCode - ровно totp.Digits цифр ASCII. strconv.Atoi не подходит:
+// он принимает знак, и "+12345" считался бы кодом TOTP
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRecoveryCodes - коды восстановления вида XXXX-XXXX-XXXX-XXXX и их хэши
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([][]byte, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := recoveryEncoding.EncodeToString(b)
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, opaque.Hash(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode убирает дефисы и пробелы и приводит код к верхнему регистру
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, code)
+}
+
+// totpAAD привязывает шифротекст секрета к пользователю
+func totpAAD(userID int64) []byte {
+	return []byte("totp:" + strconv.FormatInt(userID, 10))
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"regexp"
+	"testing"
+	"time"
+
+	"go_grpc/internal/lib/opaque"
+	"go_grpc/internal/lib/totp"
+
+	"github.com/stretchr/testify/require"
+)
+
+// неверный второй фактор: по виду код восстановления, которого нет
+const wrongCode = "AAAA-BBBB-CCCC-DDDD"
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	format := regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`)
+	seen := make(map[string]bool)
+
+	for i, code := range codes {
+		require.Regexp(t, format, code)
+		require.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+
+		// Хэш считается от кода без оформления, как его введет пользователь
+		require.Equal(t, hashes[i], opaque.Hash(normalizeRecoveryCode(code)))
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	require.Equal(t, "ABCDEFGH23456777", normalizeRecoveryCode("abcd-efgh-2345-6777"))
+	require.Equal(t, "ABCDEFGH23456777", normalizeRecoveryCode("ABCD EFGH 2345 6777"))
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	require.True(t, isTOTPCode("012345"))
+	require.False(t, isTOTPCode("+12345"))
+	require.False(t, isTOTPCode("-12345"))
+	require.False(t, isTOTPCode("12345"))
+	require.False(t, isTOTPCode("1234567"))
+	require.False(t, isTOTPCode("12 345"))
+	require.False(t, isTOTPCode("１２３４５６")) // цифры не ASCII
+}
+
+// enableTOTP подключает пользователю TOTP и возвращает коды восстановления
+func enableTOTP(t *testing.T, a *Auth, appID int) []string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, encoded, err := a.EnrollTOTP(ctx, login(t, a, appID).AccessToken)
+	require.NoError(t, err)
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(encoded)
+	require.NoError(t, err)
+
+	codes, err := a.ConfirmTOTP(ctx, login(t, a, appID).AccessToken, totp.Code(secret, totp.Step(time.Now())))
+	require.NoError(t, err)
+
+	return codes
+}
+
+// challenge проверяет пароль и возвращает challenge второго шага
+func challenge(t *testing.T, a *Auth, appID int) string {
+	t.Helper()
+
+	result, err := a.Login(context.Background(), testEmail, testPassword, appID)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Challenge)
+
+	return result.Challenge
+}
+
+func TestLoginVerify2FA_WrongCodesLockLogin(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	recovery := enableTOTP(t, a, appID)
+	spare := challenge(t, a, appID)
+
+	// Верный пароль выдает новый challenge, но счетчик неудач не сбрасывает
+	for range a.lockout.MaxFailures {
+		_, err := a.LoginVerify2FA(ctx, challenge(t, a, appID), wrongCode)
+		require.ErrorIs(t, err, ErrInvalidCode)
+	}
+
+	var locked *LockedError
+
+	_, err := a.Login(ctx, testEmail, testPassword, appID)
+	require.ErrorAs(t, err, &locked)
+
+	// Пока вход заблокирован, не принимается и верный код
+	_, err = a.LoginVerify2FA(ctx, spare, recovery[0])
+	require.ErrorAs(t, err, &locked)
+}
+
+func TestLoginVerify2FA_ResetsFailuresAfterSecondFactor(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+	ctx := context.Background()
+
+	recovery := enableTOTP(t, a, appID)
+
+	fail := func(n int) {
+		t.Helper()
+
+		for range n {
+			_, err := a.LoginVerify2FA(ctx, challenge(t, a, appID), wrongCode)
+			require.ErrorIs(t, err, ErrInvalidCode)
+		}
+	}
+
+	fail(a.lockout.MaxFailures - 1)
+
+	pair, err := a.LoginVerify2FA(ctx, challenge(t, a, appID), recovery[0])
+	require.NoError(t, err)
+	require.NotEmpty(t, pair.AccessToken)
+
+	// После успешного входа счетчик начинается заново
+	fail(a.lockout.MaxFailures - 1)
+
+	challenge(t, a, appID)
+}
+
+// Если ключ шифрования убрали из конфига, вход с 2FA не выдает
+// challenge, который нельзя завершить
+func TestLogin_TwoFactorWithoutCipher(t *testing.T) {
+	a, storage := newTestAuth(t)
+	_, appID := newTestUser(t, a, storage)
+
+	enableTOTP(t, a, appID)
+
+	a.twoFactor.Cipher = nil
+
+	result, err := a.Login(context.Background(), testEmail, testPassword, appID)
+	require.ErrorIs(t, err, ErrTwoFactorDisabled)
+	require.Empty(t, result.Challenge)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"go_grpc/internal/lib/emailaddr"
+)
+
+// нарушение требований к полю запроса
+type FieldViolation struct {
+	Field       string // Поле запроса, например "password"
+	Description string // Что не так
+}
+
+// ValidationError - данные запроса не прошли проверку.
+// Содержит все нарушения сразу, чтобы клиент исправил их за один раз
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Description)
+	}
+
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// validateCredentials проверяет данные регистрации: email (уже нормализованный)
+// и пароль по политике
+func (a *Auth) validateCredentials(ctx context.Context, email string, password string) error {
+	var violations []FieldViolation
+
+	if err := emailaddr.Validate(email); err != nil {
+		violations = append(violations, FieldViolation{Field: "email", Description: "invalid email address"})
+	}
+
+	passViolations, err := a.passwordViolations(ctx, password, email, "password")
+	if err != nil {
+		return err
+	}
+
+	violations = append(violations, passViolations...)
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// validatePassword проверяет новый пароль пользователя по политике.
+// field - имя поля пароля в запросе
+func (a *Auth) validatePassword(ctx context.Context, password string, email string, field string) error {
+	violations, err := a.passwordViolations(ctx, password, email, field)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (a *Auth) passwordViolations(ctx context.Context, password string, email string, field string) ([]FieldViolation, error) {
+	problems, err := a.passwordPolicy.Check(ctx, password, email)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]FieldViolation, 0, len(problems))
+	for _, p := range problems {
+		violations = append(violations, FieldViolation{Field: field, Description: p})
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,209 @@
+package keys
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/logger/sl"
+	"go_grpc/internal/lib/secretbox"
+	"go_grpc/internal/storage"
+)
+
+// как часто проверять, не пора ли сменить ключи
+const maxCheckInterval = time.Hour
+
+// ротация ключей подписи токенов
+type Rotator struct {
+	mu        sync.Mutex // Rotate вызывается и по таймеру, и при создании приложения
+	log       *slog.Logger
+	storage   KeyStorage
+	algorithm string         // Алгоритм подписи: HS256 (без ключей), RS256 или EdDSA
+	period    time.Duration  // Как часто менять ключ
+	grace     time.Duration  // Сколько старый ключ принимается после смены
+	cipher    *secretbox.Box // Шифрование закрытых ключей в БД
+}
+
+type KeyStorage interface {
+	Apps(ctx context.Context) ([]models.App, error)
+	ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error)
+	RotateSigningKey(ctx context.Context, next models.SigningKey, verifyUntil time.Time) error
+	RetireSigningKey(ctx context.Context, appID int, verifyUntil time.Time) error
+	DeleteExpiredSigningKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// grace должен быть не меньше времени жизни access-токена,
+// иначе токены, подписанные старым ключом, перестанут проходить проверку раньше срока.
+// Закрытые ключи хранятся зашифрованными cipher, для RS256 и EdDSA он обязателен
+func New(
+	log *slog.Logger,
+	storage KeyStorage,
+	algorithm string,
+	period time.Duration,
+	grace time.Duration,
+	cipher *secretbox.Box,
+) (*Rotator, error) {
+	if algorithm != jwk.AlgHS256 && !jwk.Supported(algorithm) {
+		return nil, fmt.Errorf("%w: %q", jwk.ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if jwk.Supported(algorithm) && cipher == nil {
+		return nil, fmt.Errorf("encryption key is required for %s signing keys", algorithm)
+	}
+
+	if period <= 0 {
+		return nil, errors.New("key rotation period must be positive")
+	}
+
+	return &Rotator{
+		log:       log,
+		storage:   storage,
+		algorithm: algorithm,
+		period:    period,
+		grace:     grace,
+		cipher:    cipher,
+	}, nil
+}
+
+// Run проверяет ключи сразу и затем периодически, пока не отменен ctx
+func (r *Rotator) Run(ctx context.Context) {
+	const op = "keys.Rotator.Run"
+
+	log := r.log.With(slog.String("op", op))
+
+	interval := min(r.period, maxCheckInterval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Rotate(ctx); err != nil {
+			log.Error("failed to rotate signing keys", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate выпускает новые ключи приложениям, у которых ключа нет,
+// он старше period или другого алгоритма, и удаляет ключи,
+// срок проверки которых истек. При HS256 текущие ключи выводятся
+// из использования, и приложения возвращаются к подписи секретом
+func (r *Rotator) Rotate(ctx context.Context) error {
+	const op = "keys.Rotator.Rotate"
+
+	log := r.log.With(slog.String("op", op))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apps, err := r.storage.Apps(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+
+	for _, app := range apps {
+		if err := r.rotateApp(ctx, log, app, now); err != nil {
+			return fmt.Errorf("%s: app %d: %w", op, app.ID, err)
+		}
+	}
+
+	n, err := r.storage.DeleteExpiredSigningKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n > 0 {
+		log.Info("expired signing keys deleted", slog.Int64("count", n))
+	}
+
+	return nil
+}
+
+func (r *Rotator) rotateApp(ctx context.Context, log *slog.Logger, app models.App, now time.Time) error {
+	log = log.With(slog.Int("app_id", app.ID))
+
+	current, err := r.storage.ActiveSigningKey(ctx, app.ID)
+	hasKey := err == nil
+	if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+		return err
+	}
+
+	if r.algorithm == jwk.AlgHS256 {
+		if !hasKey {
+			return nil
+		}
+
+		log.Info("retiring signing key", slog.String("kid", current.KID))
+
+		return r.storage.RetireSigningKey(ctx, app.ID, now.Add(r.grace))
+	}
+
+	if hasKey && current.Algorithm == r.algorithm && now.Sub(current.CreatedAt) < r.period {
+		// Ключ, сохраненный без шифрования или другим ключом шифрования,
+		// подписывать не может, поэтому заменяется сразу
+		if _, err := PrivateKey(r.cipher, current); err == nil {
+			return nil
+		}
+
+		log.Warn("signing key cannot be decrypted, rotating", slog.String("kid", current.KID))
+	}
+
+	pair, err := jwk.Generate(r.algorithm)
+	if err != nil {
+		return err
+	}
+
+	private, err := r.cipher.Seal(pair.PrivateKey, privateKeyAAD(pair.KID))
+	if err != nil {
+		return err
+	}
+
+	next := models.SigningKey{
+		AppID:      app.ID,
+		KID:        pair.KID,
+		Algorithm:  pair.Algorithm,
+		PrivateKey: private,
+		PublicKey:  pair.PublicKey,
+		CreatedAt:  now,
+	}
+
+	if err := r.storage.RotateSigningKey(ctx, next, now.Add(r.grace)); err != nil {
+		return err
+	}
+
+	log.Info("signing key rotated", slog.String("kid", next.KID), slog.String("alg", next.Algorithm))
+
+	return nil
+}
+
+// PrivateKey расшифровывает закрытый ключ, выпущенный Rotator с тем же cipher
+func PrivateKey(cipher *secretbox.Box, key models.SigningKey) (crypto.Signer, error) {
+	if cipher == nil {
+		return nil, errors.New("signing key encryption is not configured")
+	}
+
+	der, err := cipher.Open(key.PrivateKey, privateKeyAAD(key.KID))
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.ParsePrivateKey(der)
+}
+
+// privateKeyAAD привязывает шифротекст закрытого ключа к его kid
+func privateKeyAAD(kid string) []byte {
+	return []byte("signing_key:" + kid)
+}
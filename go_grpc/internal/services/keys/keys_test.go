@@ -0,0 +1,158 @@
+package keys_test
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/domain/models"
+	"go_grpc/internal/lib/jwk"
+	"go_grpc/internal/lib/secretbox"
+	"go_grpc/internal/services/keys"
+	"go_grpc/internal/storage"
+	"go_grpc/internal/storage/sqlite"
+	"go_grpc/internal/storage/sqlite/sqlitetest"
+)
+
+func newCipher(t *testing.T) *secretbox.Box {
+	t.Helper()
+
+	key := make([]byte, secretbox.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	box, err := secretbox.New(key)
+	require.NoError(t, err)
+
+	return box
+}
+
+func newStorage(t *testing.T) (*sqlite.Storage, int) {
+	t.Helper()
+
+	s := sqlitetest.New(t)
+
+	appID, err := s.SaveApp(context.Background(), models.App{Name: "test", Secret: "secret", Issuer: "sso"})
+	require.NoError(t, err)
+
+	return s, appID
+}
+
+func newRotator(t *testing.T, s *sqlite.Storage, alg string, period time.Duration, cipher *secretbox.Box) *keys.Rotator {
+	t.Helper()
+
+	r, err := keys.New(slog.New(slog.DiscardHandler), s, alg, period, time.Hour, cipher)
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestNew_RequiresCipher(t *testing.T) {
+	s, _ := newStorage(t)
+
+	_, err := keys.New(slog.New(slog.DiscardHandler), s, jwk.AlgEdDSA, time.Hour, time.Hour, nil)
+	require.Error(t, err)
+
+	// HS256 подписывает секретом приложения, ключи не хранятся
+	_, err = keys.New(slog.New(slog.DiscardHandler), s, jwk.AlgHS256, time.Hour, time.Hour, nil)
+	require.NoError(t, err)
+}
+
+func TestRotate_EncryptsPrivateKey(t *testing.T) {
+	s, appID := newStorage(t)
+	ctx := context.Background()
+	cipher := newCipher(t)
+
+	require.NoError(t, newRotator(t, s, jwk.AlgEdDSA, time.Hour, cipher).Rotate(ctx))
+
+	key, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+
+	// В БД лежит не PKCS #8, а шифротекст
+	_, err = jwk.ParsePrivateKey(key.PrivateKey)
+	require.Error(t, err)
+
+	private, err := keys.PrivateKey(cipher, key)
+	require.NoError(t, err)
+
+	public, err := jwk.ParsePublicKey(key.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, public, private.Public())
+
+	_, err = keys.PrivateKey(newCipher(t), key)
+	require.ErrorIs(t, err, secretbox.ErrDecrypt)
+
+	// Шифротекст привязан к kid: под чужим kid не расшифровывается
+	key.KID = "other"
+	_, err = keys.PrivateKey(cipher, key)
+	require.ErrorIs(t, err, secretbox.ErrDecrypt)
+}
+
+func TestRotate_KeepsFreshKey(t *testing.T) {
+	s, appID := newStorage(t)
+	ctx := context.Background()
+	r := newRotator(t, s, jwk.AlgEdDSA, time.Hour, newCipher(t))
+
+	require.NoError(t, r.Rotate(ctx))
+
+	first, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Rotate(ctx))
+
+	second, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+	require.Equal(t, first.KID, second.KID)
+}
+
+func TestRotate_ReplacesUndecryptableKey(t *testing.T) {
+	s, appID := newStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, newRotator(t, s, jwk.AlgEdDSA, time.Hour, newCipher(t)).Rotate(ctx))
+
+	old, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+
+	// После смены ключа шифрования старый ключ подписывать не может
+	cipher := newCipher(t)
+	require.NoError(t, newRotator(t, s, jwk.AlgEdDSA, time.Hour, cipher).Rotate(ctx))
+
+	current, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+	require.NotEqual(t, old.KID, current.KID)
+
+	_, err = keys.PrivateKey(cipher, current)
+	require.NoError(t, err)
+
+	// Старый ключ только проверяет токены, закрытая часть стерта
+	retired, err := s.SigningKey(ctx, old.KID)
+	require.NoError(t, err)
+	require.NotNil(t, retired.VerifyUntil)
+	require.Empty(t, retired.PrivateKey)
+	require.Equal(t, old.PublicKey, retired.PublicKey)
+}
+
+func TestRotate_RetiresOnHS256(t *testing.T) {
+	s, appID := newStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, newRotator(t, s, jwk.AlgEdDSA, time.Hour, newCipher(t)).Rotate(ctx))
+
+	old, err := s.ActiveSigningKey(ctx, appID)
+	require.NoError(t, err)
+
+	require.NoError(t, newRotator(t, s, jwk.AlgHS256, time.Hour, nil).Rotate(ctx))
+
+	_, err = s.ActiveSigningKey(ctx, appID)
+	require.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	retired, err := s.SigningKey(ctx, old.KID)
+	require.NoError(t, err)
+	require.NotNil(t, retired.VerifyUntil)
+	require.Empty(t, retired.PrivateKey)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"go_grpc/internal/domain/models"
 	"go_grpc/internal/storage"
@@ -68,15 +69,14 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	const op = "storage.sqlite.User"
 
 	// Поиск пользователя по email
-	stmt, err := s.db.Prepare("SELECT id, email, pass_hash FROM users WHERE email = ?")
+	stmt, err := s.db.Prepare("SELECT id, email, pass_hash, is_admin, email_verified, tokens_revoked_at FROM users WHERE email = ?")
 	if err != nil {
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	row := stmt.QueryRowContext(ctx, email)
 
-	var user models.User
-	err = row.Scan(&user.ID, &user.Email, &user.PassHash)
+	user, err := scanUser(row)
 	if err != nil {
 		// Обработка случая "не найдено"
 		if errors.Is(err, sql.ErrNoRows) {
@@ -89,12 +89,57 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	return user, nil
 }
 
+// получение пользователя по ID
+func (s *Storage) UserByID(ctx context.Context, id int64) (models.User, error) {
+	const op = "storage.sqlite.UserByID"
+
+	stmt, err := s.db.Prepare("SELECT id, email, pass_hash, is_admin, email_verified, tokens_revoked_at FROM users WHERE id = ?")
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row := stmt.QueryRowContext(ctx, id)
+
+	user, err := scanUser(row)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func scanUser(row *sql.Row) (models.User, error) {
+	var (
+		user            models.User
+		tokensRevokedAt sql.NullTime
+	)
+
+	err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.IsAdmin, &user.EmailVerified, &tokensRevokedAt)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if tokensRevokedAt.Valid {
+		user.TokensRevokedAt = &tokensRevokedAt.Time
+	}
+
+	return user, nil
+}
+
+// получатель токенов приложения, по умолчанию - название приложения
+const appAudience = "COALESCE(NULLIF(audience, ''), name)"
+
 // получение приложения по ID
 func (s *Storage) App(ctx context.Context, id int) (models.App, error) {
 	const op = "storage.sqlite.App"
 
 	// Поиск приложения по ID
-	stmt, err := s.db.Prepare("SELECT id, name, secret FROM apps WHERE id = ?")
+	stmt, err := s.db.Prepare("SELECT id, name, secret, issuer, " + appAudience + " FROM apps WHERE id = ?")
 	if err != nil {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -102,7 +147,7 @@ func (s *Storage) App(ctx context.Context, id int) (models.App, error) {
 	row := stmt.QueryRowContext(ctx, id)
 
 	var app models.App
-	err = row.Scan(&app.ID, &app.Name, &app.Secret)
+	err = row.Scan(&app.ID, &app.Name, &app.Secret, &app.Issuer, &app.Audience)
 	if err != nil {
 		// Обработка случая "не найдено"
 		if errors.Is(err, sql.ErrNoRows) {
@@ -129,13 +174,1009 @@ func (s *Storage) RegisterNewUser(ctx context.Context, email string, pass string
 	return s.SaveUser(ctx, email, passHash)
 }
 
-// проверка прав администратора (заглушка)
+// проверка прав администратора
 func (s *Storage) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "storage.sqlite.IsAdmin"
 
-	// В текущей схеме нет таблицы админов, поэтому всегда false
-	// TODO: добавить таблицу админов и реальную логику
-	return false, nil
+	stmt, err := s.db.Prepare("SELECT is_admin FROM users WHERE id = ?")
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row := stmt.QueryRowContext(ctx, userID)
+
+	var isAdmin bool
+	err = row.Scan(&isAdmin)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return isAdmin, nil
+}
+
+// выдача или отзыв прав администратора
+func (s *Storage) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
+	const op = "storage.sqlite.SetAdmin"
+
+	stmt, err := s.db.Prepare("UPDATE users SET is_admin = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(ctx, isAdmin, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Ни одной строки не изменено - пользователя нет
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// сохранение нового refresh-токена
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.sqlite.SaveRefreshToken"
+
+	if err := saveRefreshToken(ctx, s.db, token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// execer - общий интерфейс *sql.DB и *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func saveRefreshToken(ctx context.Context, db execer, token models.RefreshToken) error {
+	_, err := db.ExecContext(ctx, `
+	INSERT INTO refresh_tokens(user_id, app_id, family, token_hash, created_at, expires_at)
+	VALUES(?, ?, ?, ?, ?, ?)`,
+		token.UserID, token.AppID, token.Family, token.TokenHash, token.CreatedAt, token.ExpiresAt,
+	)
+
+	return err
+}
+
+// получение refresh-токена по хэшу
+func (s *Storage) RefreshToken(ctx context.Context, tokenHash []byte) (models.RefreshToken, error) {
+	const op = "storage.sqlite.RefreshToken"
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT id, user_id, app_id, family, token_hash, created_at, expires_at, used_at, revoked_at
+	FROM refresh_tokens WHERE token_hash = ?`, tokenHash)
+
+	var (
+		token     models.RefreshToken
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	err := row.Scan(&token.ID, &token.UserID, &token.AppID, &token.Family, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// обмен refresh-токена на новый: старый помечается использованным,
+// новый сохраняется в той же транзакции. Если старый токен уже
+// использован или отозван (например, параллельным запросом),
+// возвращает storage.ErrTokenUsed
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldID int64, next models.RefreshToken) error {
+	const op = "storage.sqlite.RotateRefreshToken"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE refresh_tokens SET used_at = ?
+	WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL`, next.CreatedAt, oldID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenUsed)
+	}
+
+	if err := saveRefreshToken(ctx, tx, next); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// отзыв всех токенов цепочки (одного входа)
+func (s *Storage) RevokeTokenFamily(ctx context.Context, family string, at time.Time) error {
+	const op = "storage.sqlite.RevokeTokenFamily"
+
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family = ? AND revoked_at IS NULL", at, family,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// отзыв всех токенов пользователя (выход со всех устройств):
+// refresh-токены помечаются отозванными, а access-токены, выданные
+// до at, перестают проходить проверку
+func (s *Storage) RevokeUserTokens(ctx context.Context, userID int64, at time.Time) error {
+	const op = "storage.sqlite.RevokeUserTokens"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", at, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET tokens_revoked_at = ? WHERE id = ?", at, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// список всех приложений
+func (s *Storage) Apps(ctx context.Context) ([]models.App, error) {
+	const op = "storage.sqlite.Apps"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, secret, issuer, "+appAudience+" FROM apps ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var apps []models.App
+
+	for rows.Next() {
+		var app models.App
+		if err := rows.Scan(&app.ID, &app.Name, &app.Secret, &app.Issuer, &app.Audience); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		apps = append(apps, app)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// сохранение нового приложения. Пустой audience - название приложения
+func (s *Storage) SaveApp(ctx context.Context, app models.App) (int, error) {
+	const op = "storage.sqlite.SaveApp"
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO apps (name, secret, issuer, audience) VALUES (?, ?, ?, ?)",
+		app.Name, app.Secret, app.Issuer, app.Audience,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+
+		// Проверка на нарушение уникальности названия
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAppExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(id), nil
+}
+
+// замена секрета приложения
+func (s *Storage) SetAppSecret(ctx context.Context, appID int, secret string) error {
+	const op = "storage.sqlite.SetAppSecret"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE apps SET secret = ? WHERE id = ?", secret, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	return nil
+}
+
+// удаление приложения вместе с его ключами, refresh-токенами и
+// незавершенными входами. Внешние ключи в SQLite не включены,
+// поэтому связанные записи удаляются явно
+func (s *Storage) DeleteApp(ctx context.Context, appID int) error {
+	const op = "storage.sqlite.DeleteApp"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"signing_keys", "refresh_tokens", "login_challenges"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE app_id = ?", appID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM apps WHERE id = ?", appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+const signingKeyColumns = "id, app_id, kid, algorithm, private_key, public_key, created_at, verify_until"
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSigningKey(row rowScanner) (models.SigningKey, error) {
+	var (
+		key         models.SigningKey
+		verifyUntil sql.NullTime
+	)
+
+	err := row.Scan(&key.ID, &key.AppID, &key.KID, &key.Algorithm,
+		&key.PrivateKey, &key.PublicKey, &key.CreatedAt, &verifyUntil)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	if verifyUntil.Valid {
+		key.VerifyUntil = &verifyUntil.Time
+	}
+
+	return key, nil
+}
+
+// текущий ключ подписи приложения
+func (s *Storage) ActiveSigningKey(ctx context.Context, appID int) (models.SigningKey, error) {
+	const op = "storage.sqlite.ActiveSigningKey"
+
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+signingKeyColumns+" FROM signing_keys WHERE app_id = ? AND verify_until IS NULL", appID,
+	)
+
+	key, err := scanSigningKey(row)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, storage.ErrKeyNotFound)
+		}
+
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// получение ключа подписи по kid
+func (s *Storage) SigningKey(ctx context.Context, kid string) (models.SigningKey, error) {
+	const op = "storage.sqlite.SigningKey"
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+signingKeyColumns+" FROM signing_keys WHERE kid = ?", kid)
+
+	key, err := scanSigningKey(row)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, storage.ErrKeyNotFound)
+		}
+
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// ключи, которыми на момент now можно проверять токены:
+// текущие и не истекшие после ротации. appID == 0 - всех приложений.
+// Время хранится в UTC, чтобы строки в БД сравнивались правильно
+func (s *Storage) VerificationKeys(ctx context.Context, appID int, now time.Time) ([]models.SigningKey, error) {
+	const op = "storage.sqlite.VerificationKeys"
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT `+signingKeyColumns+` FROM signing_keys
+	WHERE (? = 0 OR app_id = ?) AND (verify_until IS NULL OR verify_until > ?)
+	ORDER BY app_id, created_at DESC`, appID, appID, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+
+	for rows.Next() {
+		key, err := scanSigningKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// ротация ключа приложения: текущий ключ остается для проверки
+// до verifyUntil, next становится текущим. Закрытый ключ старого
+// больше не нужен и стирается
+func (s *Storage) RotateSigningKey(ctx context.Context, next models.SigningKey, verifyUntil time.Time) error {
+	const op = "storage.sqlite.RotateSigningKey"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE signing_keys SET verify_until = ?, private_key = x'' WHERE app_id = ? AND verify_until IS NULL",
+		verifyUntil.UTC(), next.AppID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO signing_keys(app_id, kid, algorithm, private_key, public_key, created_at)
+	VALUES(?, ?, ?, ?, ?, ?)`,
+		next.AppID, next.KID, next.Algorithm, next.PrivateKey, next.PublicKey, next.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// вывод текущего ключа приложения из использования без замены:
+// он остается только для проверки до verifyUntil, закрытый ключ стирается
+func (s *Storage) RetireSigningKey(ctx context.Context, appID int, verifyUntil time.Time) error {
+	const op = "storage.sqlite.RetireSigningKey"
+
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE signing_keys SET verify_until = ?, private_key = x'' WHERE app_id = ? AND verify_until IS NULL",
+		verifyUntil.UTC(), appID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// удаление ключей, срок проверки которых истек
+func (s *Storage) DeleteExpiredSigningKeys(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.sqlite.DeleteExpiredSigningKeys"
+
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM signing_keys WHERE verify_until IS NOT NULL AND verify_until <= ?", now.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// время, до которого вход по email заблокирован. Нулевое - не заблокирован
+func (s *Storage) LoginLockedUntil(ctx context.Context, email string) (time.Time, error) {
+	const op = "storage.sqlite.LoginLockedUntil"
+
+	var lockedUntil sql.NullTime
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT locked_until FROM login_attempts WHERE email = ?", email,
+	).Scan(&lockedUntil)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockedUntil.Time, nil
+}
+
+// учет неудачной попытки входа. Счетчик начинается заново, если
+// предыдущая неудача была раньше resetBefore. Возвращает число
+// неудач подряд с учетом этой
+func (s *Storage) RecordLoginFailure(ctx context.Context, email string, at, resetBefore time.Time) (int, error) {
+	const op = "storage.sqlite.RecordLoginFailure"
+
+	var failures int
+
+	err := s.db.QueryRowContext(ctx, `
+	INSERT INTO login_attempts(email, failures, last_failure_at) VALUES(?, 1, ?)
+	ON CONFLICT(email) DO UPDATE SET
+		failures = CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END,
+		last_failure_at = excluded.last_failure_at
+	RETURNING failures`, email, at.UTC(), resetBefore.UTC()).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+// блокировка входа по email до until
+func (s *Storage) LockLogin(ctx context.Context, email string, until time.Time) error {
+	const op = "storage.sqlite.LockLogin"
+
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE login_attempts SET locked_until = ? WHERE email = ?", until.UTC(), email,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// сброс неудачных попыток после успешного входа
+func (s *Storage) ResetLoginFailures(ctx context.Context, email string) error {
+	const op = "storage.sqlite.ResetLoginFailures"
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE email = ?", email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// сохранение одноразового токена из письма. Прежние неиспользованные
+// токены пользователя с тем же назначением перестают действовать
+func (s *Storage) SaveUserToken(ctx context.Context, token models.UserToken) error {
+	const op = "storage.sqlite.SaveUserToken"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE user_tokens SET used_at = ?
+	WHERE user_id = ? AND purpose = ? AND used_at IS NULL`,
+		token.CreatedAt.UTC(), token.UserID, token.Purpose,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO user_tokens (user_id, purpose, token_hash, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?)`,
+		token.UserID, token.Purpose, token.TokenHash, token.CreatedAt.UTC(), token.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// поиск одноразового токена по назначению и хэшу
+func (s *Storage) UserToken(ctx context.Context, purpose string, tokenHash []byte) (models.UserToken, error) {
+	const op = "storage.sqlite.UserToken"
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT id, user_id, purpose, token_hash, created_at, expires_at, used_at
+	FROM user_tokens WHERE purpose = ? AND token_hash = ?`, purpose, tokenHash)
+
+	var (
+		token  models.UserToken
+		usedAt sql.NullTime
+	)
+
+	err := row.Scan(&token.ID, &token.UserID, &token.Purpose, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt, &usedAt)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UserToken{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+
+		return models.UserToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+// useUserToken помечает токен использованным. Если его уже использовали
+// (например, параллельным запросом), возвращает storage.ErrTokenUsed
+func useUserToken(ctx context.Context, tx *sql.Tx, tokenID int64, at time.Time) error {
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", at.UTC(), tokenID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return storage.ErrTokenUsed
+	}
+
+	return nil
+}
+
+// подтверждение email по токену из письма
+func (s *Storage) VerifyEmail(ctx context.Context, tokenID int64, userID int64, at time.Time) error {
+	const op = "storage.sqlite.VerifyEmail"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := useUserToken(ctx, tx, tokenID, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email_verified = TRUE WHERE id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// смена пароля по токену сброса. Пользователь доказал, что владеет
+// email, поэтому email считается подтвержденным. Все сессии и токены
+// пользователя отзываются, счетчик неудачных входов сбрасывается
+func (s *Storage) ResetPassword(ctx context.Context, tokenID int64, userID int64, passHash []byte, at time.Time) error {
+	const op = "storage.sqlite.ResetPassword"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := useUserToken(ctx, tx, tokenID, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE users SET pass_hash = ?, email_verified = TRUE, tokens_revoked_at = ?
+	WHERE id = ?`, passHash, at, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", at, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM login_attempts WHERE email = (SELECT email FROM users WHERE id = ?)", userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// начало подключения TOTP: сохраняет новый секрет. Неподтвержденный
+// секрет заменяется, подключенный TOTP - нет (storage.ErrTOTPEnabled)
+func (s *Storage) SaveTOTP(ctx context.Context, userID int64, secret []byte, at time.Time) error {
+	const op = "storage.sqlite.SaveTOTP"
+
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO user_totp (user_id, secret, created_at) VALUES (?, ?, ?)
+	ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, created_at = excluded.created_at
+	WHERE user_totp.confirmed_at IS NULL`, userID, secret, at.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPEnabled)
+	}
+
+	return nil
+}
+
+// TOTP пользователя, в том числе неподтвержденный
+func (s *Storage) TOTP(ctx context.Context, userID int64) (models.TOTP, error) {
+	const op = "storage.sqlite.TOTP"
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT user_id, secret, created_at, confirmed_at, last_step
+	FROM user_totp WHERE user_id = ?`, userID)
+
+	var (
+		t           models.TOTP
+		confirmedAt sql.NullTime
+	)
+
+	err := row.Scan(&t.UserID, &t.Secret, &t.CreatedAt, &confirmedAt, &t.LastStep)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.TOTP{}, fmt.Errorf("%s: %w", op, storage.ErrTOTPNotFound)
+		}
+
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if confirmedAt.Valid {
+		t.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return t, nil
+}
+
+// подтверждение TOTP первым кодом. Прежние коды восстановления
+// заменяются новыми
+func (s *Storage) ConfirmTOTP(ctx context.Context, userID int64, step int64, recoveryHashes [][]byte, at time.Time) error {
+	const op = "storage.sqlite.ConfirmTOTP"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE user_totp SET confirmed_at = ?, last_step = ?
+	WHERE user_id = ? AND confirmed_at IS NULL`, at.UTC(), step, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPEnabled)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, hash := range recoveryHashes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// запоминает шаг принятого кода TOTP. Код того же или более раннего
+// шага уже использован: storage.ErrTokenUsed
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.sqlite.UseTOTPStep"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?", step, userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenUsed)
+	}
+
+	return nil
+}
+
+// использование кода восстановления. Неизвестный или уже
+// использованный код - storage.ErrTokenNotFound
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int64, codeHash []byte, at time.Time) error {
+	const op = "storage.sqlite.UseRecoveryCode"
+
+	res, err := s.db.ExecContext(ctx, `
+	UPDATE recovery_codes SET used_at = ?
+	WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`, at.UTC(), userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+
+	return nil
+}
+
+// сохранение незавершенного входа
+func (s *Storage) SaveLoginChallenge(ctx context.Context, challenge models.LoginChallenge) error {
+	const op = "storage.sqlite.SaveLoginChallenge"
+
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO login_challenges (user_id, app_id, token_hash, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?)`,
+		challenge.UserID, challenge.AppID, challenge.TokenHash,
+		challenge.CreatedAt.UTC(), challenge.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// поиск незавершенного входа по хэшу токена
+func (s *Storage) LoginChallenge(ctx context.Context, tokenHash []byte) (models.LoginChallenge, error) {
+	const op = "storage.sqlite.LoginChallenge"
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT id, user_id, app_id, token_hash, created_at, expires_at, attempts, used_at
+	FROM login_challenges WHERE token_hash = ?`, tokenHash)
+
+	var (
+		c      models.LoginChallenge
+		usedAt sql.NullTime
+	)
+
+	err := row.Scan(&c.ID, &c.UserID, &c.AppID, &c.TokenHash, &c.CreatedAt, &c.ExpiresAt, &c.Attempts, &usedAt)
+	if err != nil {
+		// Обработка случая "не найдено"
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginChallenge{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+
+		return models.LoginChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if usedAt.Valid {
+		c.UsedAt = &usedAt.Time
+	}
+
+	return c, nil
+}
+
+// учет неверного кода. После maxAttempts неверных кодов
+// вход отменяется, пароль придется ввести заново
+func (s *Storage) FailLoginChallenge(ctx context.Context, id int64, maxAttempts int, at time.Time) error {
+	const op = "storage.sqlite.FailLoginChallenge"
+
+	_, err := s.db.ExecContext(ctx, `
+	UPDATE login_challenges
+	SET attempts = attempts + 1,
+	    used_at = CASE WHEN attempts + 1 >= ? THEN ? ELSE used_at END
+	WHERE id = ? AND used_at IS NULL`, maxAttempts, at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// завершение входа. Уже завершенный вход - storage.ErrTokenUsed
+func (s *Storage) UseLoginChallenge(ctx context.Context, id int64, at time.Time) error {
+	const op = "storage.sqlite.UseLoginChallenge"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE login_challenges SET used_at = ? WHERE id = ? AND used_at IS NULL", at.UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenUsed)
+	}
+
+	return nil
+}
+
+// приведение сохраненных email к виду normalize. Адреса, которые после
+// нормализации совпадают с другими, не меняются: они возвращаются
+// сгруппированными по нормализованному email, такие аккаунты надо
+// объединить вручную
+func (s *Storage) NormalizeEmails(ctx context.Context, normalize func(string) string) (map[string][]string, error) {
+	const op = "storage.sqlite.NormalizeEmails"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT email FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups := make(map[string][]string)
+
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			rows.Close()
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		normalized := normalize(email)
+		groups[normalized] = append(groups[normalized], email)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	conflicts := make(map[string][]string)
+
+	for normalized, emails := range groups {
+		if len(emails) > 1 {
+			conflicts[normalized] = emails
+
+			continue
+		}
+
+		if emails[0] == normalized {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, "UPDATE users SET email = ? WHERE email = ?", normalized, emails[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return conflicts, nil
 }
 
 // закрытие соединения с БД
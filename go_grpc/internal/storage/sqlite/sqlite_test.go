@@ -0,0 +1,64 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go_grpc/internal/lib/emailaddr"
+	"go_grpc/internal/storage"
+	"go_grpc/internal/storage/sqlite/sqlitetest"
+)
+
+func TestNormalizeEmails(t *testing.T) {
+	s := sqlitetest.New(t)
+	ctx := context.Background()
+
+	// Адреса, сохраненные до нормализации
+	ids := make(map[string]int64)
+	for _, email := range []string{
+		"user@example.com",
+		"Иван@Пример.рф",
+		"E\u0301mile@Example.com",
+		"Dup@Example.com",
+		"dup@example.com",
+		"DUP@EXAMPLE.COM",
+	} {
+		id, err := s.SaveUser(ctx, email, []byte("hash"))
+		require.NoError(t, err)
+
+		ids[email] = id
+	}
+
+	conflicts, err := s.NormalizeEmails(ctx, emailaddr.Normalize)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"dup@example.com": {"Dup@Example.com", "dup@example.com", "DUP@EXAMPLE.COM"},
+	}, conflicts)
+
+	for email, normalized := range map[string]string{
+		"Иван@Пример.рф":          "иван@пример.рф",
+		"E\u0301mile@Example.com": "\u00e9mile@example.com",
+		"user@example.com":        "user@example.com",
+	} {
+		user, err := s.User(ctx, normalized)
+		require.NoError(t, err)
+		require.Equal(t, ids[email], user.ID)
+	}
+
+	// Совпадающие адреса не меняются
+	for _, email := range []string{"Dup@Example.com", "DUP@EXAMPLE.COM"} {
+		user, err := s.User(ctx, email)
+		require.NoError(t, err)
+		require.Equal(t, ids[email], user.ID)
+	}
+
+	_, err = s.User(ctx, "Иван@Пример.рф")
+	require.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	// Повторный запуск ничего не меняет
+	again, err := s.NormalizeEmails(ctx, emailaddr.Normalize)
+	require.NoError(t, err)
+	require.Equal(t, conflicts, again)
+}
@@ -0,0 +1,44 @@
+// Package sqlitetest создает для тестов хранилище SQLite
+// во временном каталоге со всеми миграциями
+package sqlitetest
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go_grpc/internal/storage/sqlite"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/require"
+)
+
+// New создает пустую БД с примененными миграциями и закрывает ее после теста
+func New(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sso.db")
+
+	m, err := migrate.New("file://"+migrationsPath(), "sqlite3://"+path+"?x-migrations-table=migrations")
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+
+	srcErr, dbErr := m.Close()
+	require.NoError(t, srcErr)
+	require.NoError(t, dbErr)
+
+	storage, err := sqlite.New(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	return storage
+}
+
+// migrationsPath - каталог migrations в корне модуля
+func migrationsPath() string {
+	_, file, _, _ := runtime.Caller(0)
+
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "migrations")
+}
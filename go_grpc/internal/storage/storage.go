@@ -3,7 +3,13 @@ package storage
 import "errors"
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
+	ErrUserExists    = errors.New("user already exists")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrAppNotFound   = errors.New("app not found")
+	ErrAppExists     = errors.New("app already exists")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrTokenUsed     = errors.New("token already used")
+	ErrKeyNotFound   = errors.New("signing key not found")
+	ErrTOTPNotFound  = errors.New("totp not found")
+	ErrTOTPEnabled   = errors.New("totp already enabled")
 )
@@ -0,0 +1,4 @@
+-- Откат миграции: удаление двухфакторной аутентификации
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS user_totp;
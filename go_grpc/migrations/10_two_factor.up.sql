@@ -0,0 +1,33 @@
+-- TOTP пользователя. secret зашифрован (AES-256-GCM) ключом из конфига.
+-- confirmed_at NULL - подключение начато, но не подтверждено кодом.
+-- last_step - шаг последнего принятого кода, чтобы код нельзя было повторить
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id      INTEGER  PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret       BLOB     NOT NULL,
+    created_at   DATETIME NOT NULL,
+    confirmed_at DATETIME,
+    last_step    INTEGER  NOT NULL DEFAULT 0
+);
+
+-- Коды восстановления на случай потери устройства. Хранится только SHA-256
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         INTEGER PRIMARY KEY,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  BLOB     NOT NULL UNIQUE,
+    used_at    DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes (user_id);
+
+-- Незавершенные входы: пароль проверен, ждем второй фактор.
+-- Хранится только SHA-256 токена challenge
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id          INTEGER PRIMARY KEY,
+    user_id     INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id      INTEGER  NOT NULL,
+    token_hash  BLOB     NOT NULL UNIQUE,
+    created_at  DATETIME NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    attempts    INTEGER  NOT NULL DEFAULT 0,
+    used_at     DATETIME
+);
@@ -0,0 +1,2 @@
+-- Откат миграции: стертые ключи восстановить нельзя, они и не нужны
+SELECT 1;
@@ -0,0 +1,5 @@
+-- Закрытые ключи теперь хранятся зашифрованными. Выведенным из
+-- использования ключам закрытая часть не нужна: они только проверяют
+-- уже выданные токены, поэтому открытые копии стираются.
+-- Текущие незашифрованные ключи заменяет ротация при запуске
+UPDATE signing_keys SET private_key = x'' WHERE verify_until IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Откат миграции: удаление флага администратора
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,2 @@
+-- Откат миграции: удаление refresh-токенов
+DROP TABLE IF EXISTS refresh_tokens;
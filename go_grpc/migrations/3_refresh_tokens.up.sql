@@ -0,0 +1,17 @@
+-- Refresh-токены. Хранится только SHA-256 токена.
+-- family - цепочка токенов одного входа: при повторном использовании
+-- старого токена отзывается вся цепочка
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          INTEGER PRIMARY KEY,
+    user_id     INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id      INTEGER  NOT NULL,
+    family      TEXT     NOT NULL,
+    token_hash  BLOB     NOT NULL UNIQUE,
+    created_at  DATETIME NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    used_at     DATETIME,
+    revoked_at  DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family);
@@ -0,0 +1,2 @@
+-- Откат миграции: удаление момента отзыва токенов
+ALTER TABLE users DROP COLUMN tokens_revoked_at;
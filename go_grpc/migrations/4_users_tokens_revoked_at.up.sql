@@ -0,0 +1,3 @@
+-- Момент выхода со всех устройств: access-токены,
+-- выданные раньше, считаются отозванными
+ALTER TABLE users ADD COLUMN tokens_revoked_at DATETIME;
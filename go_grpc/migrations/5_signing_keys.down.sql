@@ -0,0 +1,2 @@
+-- Откат миграции: удаление ключей подписи
+DROP TABLE IF EXISTS signing_keys;
@@ -0,0 +1,15 @@
+-- Ключи подписи токенов приложений (RS256, EdDSA).
+-- verify_until IS NULL - текущий ключ приложения, остальные после
+-- ротации только проверяют уже выданные токены до verify_until
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id           INTEGER PRIMARY KEY,
+    app_id       INTEGER  NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    kid          TEXT     NOT NULL UNIQUE,
+    algorithm    TEXT     NOT NULL,
+    private_key  BLOB     NOT NULL,
+    public_key   BLOB     NOT NULL,
+    created_at   DATETIME NOT NULL,
+    verify_until DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_app ON signing_keys (app_id);
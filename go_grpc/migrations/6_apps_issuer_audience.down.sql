@@ -0,0 +1,3 @@
+-- Откат миграции: удаление издателя и получателя токенов
+ALTER TABLE apps DROP COLUMN audience;
+ALTER TABLE apps DROP COLUMN issuer;
@@ -0,0 +1,4 @@
+-- Издатель (iss) и получатель (aud) токенов приложения.
+-- Пустой получатель - название приложения
+ALTER TABLE apps ADD COLUMN issuer TEXT NOT NULL DEFAULT 'sso';
+ALTER TABLE apps ADD COLUMN audience TEXT NOT NULL DEFAULT '';
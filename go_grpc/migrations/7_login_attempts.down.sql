@@ -0,0 +1,2 @@
+-- Откат миграции: удаление неудачных попыток входа
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,9 @@
+-- Неудачные попытки входа по email. Записи есть и для
+-- несуществующих пользователей, чтобы блокировка не выдавала,
+-- зарегистрирован ли email
+CREATE TABLE IF NOT EXISTS login_attempts (
+    email           TEXT     PRIMARY KEY,
+    failures        INTEGER  NOT NULL,
+    last_failure_at DATETIME NOT NULL,
+    locked_until    DATETIME
+);
@@ -0,0 +1,3 @@
+-- Откат миграции: удаление токенов из писем и признака подтверждения email
+DROP TABLE IF EXISTS user_tokens;
+ALTER TABLE users DROP COLUMN email_verified;
@@ -0,0 +1,18 @@
+-- Подтверждение email. Уже зарегистрированные пользователи
+-- входили без подтверждения, поэтому считаются подтвержденными
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET email_verified = TRUE;
+
+-- Одноразовые токены из писем: подтверждение email и сброс пароля.
+-- Хранится только SHA-256 токена
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id          INTEGER PRIMARY KEY,
+    user_id     INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose     TEXT     NOT NULL,
+    token_hash  BLOB     NOT NULL UNIQUE,
+    created_at  DATETIME NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    used_at     DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user ON user_tokens (user_id, purpose);
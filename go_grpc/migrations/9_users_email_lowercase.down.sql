@@ -0,0 +1,2 @@
+-- Откат миграции: исходный регистр email не сохранялся, восстанавливать нечего
+SELECT 1;
@@ -0,0 +1,10 @@
+-- Email теперь хранится нормализованным, в нижнем регистре.
+-- Адреса, у которых уже есть двойник в другом регистре, не трогаем:
+-- такие аккаунты нужно объединить вручную. lower() в SQLite меняет
+-- только ASCII, остальные адреса нормализует мигратор после миграций
+UPDATE users SET email = lower(email)
+WHERE email <> lower(email)
+  AND NOT EXISTS (
+      SELECT 1 FROM users AS other
+      WHERE other.id <> users.id AND lower(other.email) = lower(users.email)
+  );
@@ -0,0 +1,88 @@
+// Package ssoclient - клиент SSO для сервисов, которые принимают
+// выданные SSO токены. Проверка идет через ValidateToken, поэтому
+// сервисам не нужны секреты приложений и разбор JWT.
+package ssoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ssov1 "go_grpc/gen/go/sso"
+
+	"google.golang.org/grpc"
+)
+
+// токен недействителен: подпись неверна, срок истек или токен отозван
+var ErrInactiveToken = errors.New("token is not active")
+
+// данные пользователя из действительного токена
+type TokenInfo struct {
+	UserID    int64     // ID пользователя
+	Email     string    // Email пользователя
+	AppID     int       // ID приложения, для которого выдан токен
+	Roles     []string  // Роли пользователя на момент выдачи
+	IsAdmin   bool      // Права администратора на момент выдачи
+	Issuer    string    // Издатель токена (iss)
+	Audience  []string  // Получатели токена (aud)
+	TokenID   string    // Уникальный ID токена (jti)
+	IssuedAt  time.Time // Время выдачи
+	ExpiresAt time.Time // Время истечения токена
+}
+
+// клиент SSO
+type Client struct {
+	conn *grpc.ClientConn
+	api  ssov1.AuthClient
+}
+
+// создание клиента. Параметры соединения (TLS и т.п.) передаются в opts,
+// например grpc.WithTransportCredentials(insecure.NewCredentials())
+func New(addr string, opts ...grpc.DialOption) (*Client, error) {
+	const op = "ssoclient.New"
+
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Client{conn: conn, api: ssov1.NewAuthClient(conn)}, nil
+}
+
+// проверка access-токена. Для недействительного токена
+// возвращает ErrInactiveToken
+func (c *Client) ValidateToken(ctx context.Context, token string) (TokenInfo, error) {
+	const op = "ssoclient.ValidateToken"
+
+	if token == "" {
+		return TokenInfo{}, fmt.Errorf("%s: %w", op, ErrInactiveToken)
+	}
+
+	resp, err := c.api.ValidateToken(ctx, &ssov1.ValidateTokenRequest{Token: token})
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !resp.GetActive() {
+		return TokenInfo{}, fmt.Errorf("%s: %w", op, ErrInactiveToken)
+	}
+
+	return TokenInfo{
+		UserID:    resp.GetUserId(),
+		Email:     resp.GetEmail(),
+		AppID:     int(resp.GetAppId()),
+		Roles:     resp.GetRoles(),
+		IsAdmin:   resp.GetIsAdmin(),
+		Issuer:    resp.GetIss(),
+		Audience:  resp.GetAud(),
+		TokenID:   resp.GetJti(),
+		IssuedAt:  time.Unix(resp.GetIat(), 0),
+		ExpiresAt: time.Unix(resp.GetExp(), 0),
+	}, nil
+}
+
+// закрытие соединения с SSO
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,125 @@
+package ssoclient_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	ssov1 "go_grpc/gen/go/sso"
+	"go_grpc/pkg/ssoclient"
+)
+
+const (
+	activeToken   = "active"
+	inactiveToken = "inactive"
+)
+
+// fakeSSO отвечает на ValidateToken заранее заданными данными
+type fakeSSO struct {
+	ssov1.UnimplementedAuthServer
+	calls int
+}
+
+func (s *fakeSSO) ValidateToken(_ context.Context, req *ssov1.ValidateTokenRequest) (*ssov1.ValidateTokenResponse, error) {
+	s.calls++
+
+	switch req.GetToken() {
+	case activeToken:
+		return &ssov1.ValidateTokenResponse{
+			Active:  true,
+			UserId:  42,
+			Email:   "user@example.com",
+			AppId:   1,
+			Roles:   []string{"user", "admin"},
+			IsAdmin: true,
+			Iss:     "sso",
+			Aud:     []string{"billing"},
+			Jti:     "jti-1",
+			Iat:     1700000000,
+			Exp:     1700003600,
+		}, nil
+	case inactiveToken:
+		return &ssov1.ValidateTokenResponse{Active: false}, nil
+	default:
+		return nil, status.Error(codes.Unavailable, "sso is down")
+	}
+}
+
+// newClient запускает fakeSSO в памяти и подключает к нему клиента
+func newClient(t *testing.T) (*ssoclient.Client, *fakeSSO) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	sso := &fakeSSO{}
+
+	srv := grpc.NewServer()
+	ssov1.RegisterAuthServer(srv, sso)
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := ssoclient.New("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, sso
+}
+
+func TestValidateToken_Active(t *testing.T) {
+	client, _ := newClient(t)
+
+	info, err := client.ValidateToken(context.Background(), activeToken)
+	require.NoError(t, err)
+
+	require.Equal(t, ssoclient.TokenInfo{
+		UserID:    42,
+		Email:     "user@example.com",
+		AppID:     1,
+		Roles:     []string{"user", "admin"},
+		IsAdmin:   true,
+		Issuer:    "sso",
+		Audience:  []string{"billing"},
+		TokenID:   "jti-1",
+		IssuedAt:  time.Unix(1700000000, 0),
+		ExpiresAt: time.Unix(1700003600, 0),
+	}, info)
+}
+
+func TestValidateToken_Inactive(t *testing.T) {
+	client, _ := newClient(t)
+
+	_, err := client.ValidateToken(context.Background(), inactiveToken)
+	require.ErrorIs(t, err, ssoclient.ErrInactiveToken)
+}
+
+func TestValidateToken_Empty(t *testing.T) {
+	client, sso := newClient(t)
+
+	// Пустой токен отклоняется без запроса к SSO
+	_, err := client.ValidateToken(context.Background(), "")
+	require.ErrorIs(t, err, ssoclient.ErrInactiveToken)
+	require.Zero(t, sso.calls)
+}
+
+func TestValidateToken_Unavailable(t *testing.T) {
+	client, _ := newClient(t)
+
+	// Ошибка SSO не выдается за недействительный токен
+	_, err := client.ValidateToken(context.Background(), "other")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ssoclient.ErrInactiveToken)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/users"
+)
+
+// credentials - результат rotate-credentials
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// cmdRotateCredentials генерирует новый пароль BasicAuth. Если в конфиге задан
+// файл пользователей, пароль (и при необходимости роль) меняется в нем, и
+// сервер подхватит его по SIGHUP. Иначе пароль записывается в конфиг-файл
+// и применяется после перезапуска.
+func cmdRotateCredentials(a *app, args []string) error {
+	fs := flag.NewFlagSet("rotate-credentials", flag.ContinueOnError)
+	user := fs.String("user", a.cfg.HTTPServer.User, "BasicAuth user name")
+	role := fs.String("role", "", "user role (reader, writer, admin), only with users_file")
+
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+
+	if fs.NArg() != 0 || *user == "" {
+		return usageError("usage: rotate-credentials [--user name] [--role role]")
+	}
+
+	password, err := newPassword()
+	if err != nil {
+		return err
+	}
+
+	var hint string
+
+	if usersFile := a.cfg.HTTPServer.UsersFile; usersFile != "" {
+		if err := setUserPassword(usersFile, *user, password, users.Role(*role)); err != nil {
+			return fmt.Errorf("update users file %s: %w", usersFile, err)
+		}
+
+		hint = fmt.Sprintf("credentials updated in %s, send SIGHUP to the server to apply", usersFile)
+	} else {
+		if *role != "" {
+			return usageError("--role requires http_server.users_file")
+		}
+
+		configPath := config.Path()
+		if configPath == "" {
+			return fmt.Errorf("config file is not set, use --config or CONFIG_PATH")
+		}
+
+		if err := setCredentials(configPath, *user, password); err != nil {
+			return fmt.Errorf("update config %s: %w", configPath, err)
+		}
+
+		if os.Getenv("HTTP_SERVER_PASSWORD") != "" {
+			fmt.Fprintln(os.Stderr, "warning: HTTP_SERVER_PASSWORD is set and overrides the password from the config file")
+		}
+
+		hint = fmt.Sprintf("credentials updated in %s, restart the server to apply", configPath)
+	}
+
+	creds := credentials{User: *user, Password: password}
+
+	return a.print(creds, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, hint)
+		fmt.Fprintf(w, "User:\t%s\n", creds.User)
+		fmt.Fprintf(w, "Password:\t%s\n", creds.Password)
+	})
+}
+
+func cmdVacuum(a *app, args []string) error {
+	if len(args) != 0 {
+		return usageError("usage: vacuum")
+	}
+
+	before := fileSize(a.cfg.StoragePath)
+
+	if err := a.storage.Vacuum(a.ctx); err != nil {
+		return err
+	}
+
+	after := fileSize(a.cfg.StoragePath)
+
+	res := struct {
+		SizeBefore int64 `json:"size_before"`
+		SizeAfter  int64 `json:"size_after"`
+	}{before, after}
+
+	return a.print(res, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "database vacuumed: %d -> %d bytes\n", before, after)
+	})
+}
+
+// newPassword генерирует криптографически стойкий случайный пароль
+func newPassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate password: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// setCredentials меняет http_server.user и http_server.password в YAML-файле,
+// сохраняя остальное содержимое и комментарии
+func setCredentials(path string, user string, password string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("config root is not a mapping")
+	}
+
+	server := mappingValue(doc.Content[0], "http_server")
+	if server == nil || server.Kind != yaml.MappingNode {
+		return fmt.Errorf("http_server section not found")
+	}
+
+	setScalar(server, "user", user)
+	setScalar(server, "password", password)
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// setUserPassword записывает хэш нового пароля в файл пользователей.
+// Нового пользователя добавляет с ролью role (по умолчанию writer).
+func setUserPassword(path string, name string, password string, role users.Role) error {
+	list, err := users.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	idx := slices.IndexFunc(list, func(u users.User) bool { return u.Name == name })
+	if idx == -1 {
+		if role == "" {
+			role = users.RoleWriter
+		}
+
+		list = append(list, users.User{Name: name, Role: role})
+		idx = len(list) - 1
+	}
+
+	list[idx].PasswordHash = string(hash)
+	if role != "" {
+		list[idx].Role = role
+	}
+
+	return users.WriteFile(path, list)
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func setScalar(m *yaml.Node, key string, value string) {
+	if v := mappingValue(m, key); v != nil {
+		v.Kind = yaml.ScalarNode
+		v.Tag = "!!str"
+		v.Value = value
+
+		return
+	}
+
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+
+	"url-shortener/internal/users"
+)
+
+func TestSetCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	const original = `env: "local" # окружение
+storage_path: "./storage/storage.db"
+http_server:
+  address: "localhost:8082"
+  # Учетные данные BasicAuth
+  user: "myuser"
+  password: "old"
+`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o640))
+
+	require.NoError(t, setCredentials(path, "admin", "new-password"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Комментарии и остальные поля сохраняются
+	assert.Contains(t, string(data), "# окружение")
+	assert.Contains(t, string(data), "# Учетные данные BasicAuth")
+	assert.NotContains(t, string(data), "old")
+
+	var cfg struct {
+		Env        string `yaml:"env"`
+		HTTPServer struct {
+			Address  string `yaml:"address"`
+			User     string `yaml:"user"`
+			Password string `yaml:"password"`
+		} `yaml:"http_server"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	assert.Equal(t, "local", cfg.Env)
+	assert.Equal(t, "localhost:8082", cfg.HTTPServer.Address)
+	assert.Equal(t, "admin", cfg.HTTPServer.User)
+	assert.Equal(t, "new-password", cfg.HTTPServer.Password)
+
+	// Права на файл не меняются
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestSetCredentials_NoServerSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("env: local\n"), 0o600))
+
+	err := setCredentials(path, "admin", "password")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_server")
+}
+
+func TestSetUserPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+
+	// Файла еще нет: пользователь добавляется с ролью writer
+	require.NoError(t, setUserPassword(path, "alice", "first", ""))
+
+	list, err := users.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, users.RoleWriter, list[0].Role)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(list[0].PasswordHash), []byte("first")))
+
+	require.NoError(t, setUserPassword(path, "bob", "bob-password", users.RoleReader))
+
+	// Смена пароля без роли сохраняет прежнюю роль
+	require.NoError(t, setUserPassword(path, "alice", "second", ""))
+
+	list, err = users.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "alice", list[0].Name)
+	assert.Equal(t, users.RoleWriter, list[0].Role)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(list[0].PasswordHash), []byte("second")))
+	assert.Equal(t, users.RoleReader, list[1].Role)
+
+	// Смена роли существующего пользователя
+	require.NoError(t, setUserPassword(path, "bob", "bob-password", users.RoleAdmin))
+
+	list, err = users.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, users.RoleAdmin, list[1].Role)
+}
+
+func TestSetUserPassword_InvalidRole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+
+	require.Error(t, setUserPassword(path, "alice", "password", users.Role("root")))
+
+	_, err := os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+// Длина генерируемого алиаса, как в HTTP API
+const aliasLength = 6
+
+// link - ссылка в выводе команд get и create
+type link struct {
+	Alias     string `json:"alias"`
+	URL       string `json:"url"`
+	Protected bool   `json:"protected"`
+}
+
+// record - ссылка в файле импорта/экспорта. Пароль переносится
+// в виде bcrypt-хэша, поэтому защищенные ссылки остаются защищенными.
+type record struct {
+	Alias    string `json:"alias"`
+	URL      string `json:"url"`
+	PassHash []byte `json:"pass_hash,omitempty"`
+	// Владелец переносится без команды: ID команд в разных инсталляциях не совпадают
+	Owner        string                `json:"owner,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	Destinations []models.Destination  `json:"destinations,omitempty"`
+	Rules        []models.RedirectRule `json:"rules,omitempty"`
+}
+
+func cmdCreate(a *app, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	password := fs.String("password", "", "password protecting the link")
+	tagList := fs.String("tags", "", "comma-separated link tags")
+
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return usageError("usage: create [--password p] [--tags a,b] <url> [alias]")
+	}
+
+	var linkTags []string
+	if *tagList != "" {
+		linkTags = tags.Normalize(strings.Split(*tagList, ","))
+	}
+
+	if err := validateTags(linkTags); err != nil {
+		return err
+	}
+
+	urlToSave := fs.Arg(0)
+	if err := validator.New().Var(urlToSave, "required,url"); err != nil {
+		return fmt.Errorf("%q is not a valid URL", urlToSave)
+	}
+
+	alias := fs.Arg(1)
+	if alias == "" {
+		alias = random.NewRandomString(aliasLength)
+	}
+
+	var passHash []byte
+	if *password != "" {
+		var err error
+
+		passHash, err = bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("hash password: %w", err)
+		}
+	}
+
+	if _, err := a.storage.SaveURL(a.ctx, models.URL{Alias: alias, URL: urlToSave, Tags: linkTags}, passHash); err != nil {
+		if errors.Is(err, storage.ErrURLExists) {
+			return fmt.Errorf("alias %q already exists", alias)
+		}
+
+		return err
+	}
+
+	a.recordAudit(models.AuditEvent{Action: models.AuditActionCreate, Alias: alias, NewURL: urlToSave})
+
+	l := link{Alias: alias, URL: urlToSave, Protected: passHash != nil}
+
+	return a.print(l, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "created %s -> %s\n", l.Alias, l.URL)
+	})
+}
+
+func cmdGet(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: get <alias>")
+	}
+
+	alias := args[0]
+
+	urlFound, err := a.storage.GetURL(a.ctx, alias)
+	if err != nil {
+		return notFound(alias, err)
+	}
+
+	passHash, err := a.storage.GetURLPassHash(a.ctx, alias)
+	if err != nil {
+		return err
+	}
+
+	l := link{Alias: alias, URL: urlFound, Protected: len(passHash) > 0}
+
+	return a.print(l, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Alias:\t%s\n", l.Alias)
+		fmt.Fprintf(w, "URL:\t%s\n", l.URL)
+		fmt.Fprintf(w, "Protected:\t%t\n", l.Protected)
+	})
+}
+
+func cmdDelete(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: delete <alias>")
+	}
+
+	alias := args[0]
+
+	oldURL, err := a.storage.GetURL(a.ctx, alias)
+	if err != nil {
+		return notFound(alias, err)
+	}
+
+	if err := a.storage.DeleteURL(a.ctx, alias); err != nil {
+		return notFound(alias, err)
+	}
+
+	a.recordAudit(models.AuditEvent{Action: models.AuditActionDelete, Alias: alias, OldURL: oldURL})
+
+	return a.print(link{Alias: alias, URL: oldURL}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "deleted %s\n", alias)
+	})
+}
+
+func cmdList(a *app, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	broken := fs.Bool("broken", false, "show only links that failed the last check")
+
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+
+	list := a.storage.ListURLs
+	if *broken {
+		list = a.storage.BrokenURLs
+	}
+
+	urls, err := list(a.ctx)
+	if err != nil {
+		return err
+	}
+
+	if urls == nil {
+		urls = []models.URL{}
+	}
+
+	return a.print(urls, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ALIAS\tURL\tSTATUS\tCHECKED")
+
+		for _, u := range urls {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.Alias, u.URL, checkStatus(u), checkedAt(u))
+		}
+	})
+}
+
+func cmdExport(a *app, args []string) error {
+	if len(args) > 1 {
+		return usageError("usage: export [file|-]")
+	}
+
+	urls, err := a.storage.ListURLs(a.ctx)
+	if err != nil {
+		return err
+	}
+
+	records := make([]record, 0, len(urls))
+
+	for _, u := range urls {
+		passHash, err := a.storage.GetURLPassHash(a.ctx, u.Alias)
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			return err
+		}
+
+		records = append(records, record{
+			Alias:        u.Alias,
+			URL:          u.URL,
+			PassHash:     passHash,
+			Owner:        u.Owner.User,
+			Tags:         u.Tags,
+			Destinations: u.Destinations,
+			Rules:        u.Rules,
+		})
+	}
+
+	var out io.Writer = a.out
+
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.OpenFile(args[0], os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	// Экспорт всегда в JSON, независимо от --json
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+
+	if out != a.out && !a.json {
+		fmt.Fprintf(a.out, "exported %d links to %s\n", len(records), args[0])
+	}
+
+	return nil
+}
+
+// importResult - итог команды import
+type importResult struct {
+	Imported int      `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+func cmdImport(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: import <file|->")
+	}
+
+	var in io.Reader = os.Stdin
+
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	var records []record
+	if err := json.NewDecoder(in).Decode(&records); err != nil {
+		return fmt.Errorf("decode import file: %w", err)
+	}
+
+	// Проверяем весь файл до записи, чтобы не импортировать его наполовину
+	validate := validator.New()
+	for i, rec := range records {
+		if rec.Alias == "" {
+			return fmt.Errorf("record %d: alias is required", i)
+		}
+
+		if err := validate.Var(rec.URL, "required,url"); err != nil {
+			return fmt.Errorf("record %d (%s): %q is not a valid URL", i, rec.Alias, rec.URL)
+		}
+
+		if err := validateTags(rec.Tags); err != nil {
+			return fmt.Errorf("record %d (%s): %w", i, rec.Alias, err)
+		}
+
+		for _, d := range rec.Destinations {
+			if err := validate.Var(d.URL, "required,url"); err != nil || d.Weight <= 0 {
+				return fmt.Errorf("record %d (%s): invalid destination %q with weight %d", i, rec.Alias, d.URL, d.Weight)
+			}
+		}
+
+		for _, rule := range rec.Rules {
+			if err := validate.Var(rule.URL, "required,url"); err != nil {
+				return fmt.Errorf("record %d (%s): invalid rule URL %q", i, rec.Alias, rule.URL)
+			}
+		}
+	}
+
+	res := importResult{Skipped: []string{}}
+
+	for _, rec := range records {
+		_, err := a.storage.SaveURL(a.ctx, models.URL{
+			Alias:        rec.Alias,
+			URL:          rec.URL,
+			Owner:        models.Owner{User: rec.Owner},
+			Tags:         rec.Tags,
+			Destinations: rec.Destinations,
+			Rules:        rec.Rules,
+		}, rec.PassHash)
+		if errors.Is(err, storage.ErrURLExists) {
+			res.Skipped = append(res.Skipped, rec.Alias)
+
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("import %s: %w", rec.Alias, err)
+		}
+
+		a.recordAudit(models.AuditEvent{Action: models.AuditActionCreate, Alias: rec.Alias, NewURL: rec.URL})
+
+		res.Imported++
+	}
+
+	return a.print(res, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "imported %d links, skipped %d existing\n", res.Imported, len(res.Skipped))
+
+		for _, alias := range res.Skipped {
+			fmt.Fprintf(w, "  skipped %s\n", alias)
+		}
+	})
+}
+
+// recordAudit записывает действие CLI в журнал аудита.
+// Изменение уже выполнено, поэтому ошибка только выводится.
+func (a *app) recordAudit(event models.AuditEvent) {
+	event.Actor = actor
+
+	if err := a.auditor.Record(a.ctx, event); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: failed to record audit event:", err)
+	}
+}
+
+func validateTags(linkTags []string) error {
+	for _, tag := range linkTags {
+		if !tags.Valid(tag) {
+			return fmt.Errorf("%q is not a valid tag: use lowercase letters, digits, '-', '_' and '/'", tag)
+		}
+	}
+
+	return nil
+}
+
+func notFound(alias string, err error) error {
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return fmt.Errorf("alias %q not found", alias)
+	}
+
+	return err
+}
+
+func checkStatus(u models.URL) string {
+	switch {
+	case u.CheckedAt == nil:
+		return "-"
+	case u.CheckError != "":
+		return "error"
+	default:
+		return fmt.Sprint(u.LastStatus)
+	}
+}
+
+func checkedAt(u models.URL) string {
+	if u.CheckedAt == nil {
+		return "-"
+	}
+
+	return u.CheckedAt.Local().Format(time.DateTime)
+}
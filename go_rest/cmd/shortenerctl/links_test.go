@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"url-shortener/internal/domain/models"
+)
+
+func TestExportImport(t *testing.T) {
+	src, _ := newTestApp(t)
+
+	require.NoError(t, cmdCreate(src, []string{"--password", "secret", "--tags", "Promo,docs/api", "https://example.com/a", "a"}))
+	require.NoError(t, cmdCreate(src, []string{"https://example.com/b", "b"}))
+
+	file := filepath.Join(t.TempDir(), "links.json")
+	require.NoError(t, cmdExport(src, []string{file}))
+
+	// Файл экспорта содержит пароль только в виде хэша
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	dst, out := newTestApp(t)
+
+	require.NoError(t, cmdImport(dst, []string{file}))
+
+	var res importResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+	assert.Equal(t, importResult{Imported: 2, Skipped: []string{}}, res)
+
+	// Защищенная ссылка остается защищенной тем же паролем
+	passHash, err := dst.storage.GetURLPassHash(dst.ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, bcrypt.CompareHashAndPassword(passHash, []byte("secret")))
+
+	u, err := dst.storage.URL(dst.ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", u.URL)
+	assert.Equal(t, []string{"docs/api", "promo"}, u.Tags)
+
+	// Повторный импорт пропускает существующие алиасы
+	out.Reset()
+	require.NoError(t, cmdImport(dst, []string{file}))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+	assert.Equal(t, importResult{Imported: 0, Skipped: []string{"a", "b"}}, res)
+
+	// Импорт записывается в журнал аудита
+	events, err := dst.storage.AuditEvents(dst.ctx, "b")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditActionCreate, events[0].Action)
+	assert.Equal(t, actor, events[0].Actor)
+}
+
+func TestImport_InvalidRecord(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	file := filepath.Join(t.TempDir(), "links.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[
+		{"alias": "ok", "url": "https://example.com/"},
+		{"alias": "bad", "url": "not a url"}
+	]`), 0o600))
+
+	err := cmdImport(a, []string{file})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "record 1 (bad)")
+
+	// Файл проверяется целиком, поэтому и корректная запись не импортирована
+	urls, err := a.storage.ListURLs(a.ctx)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
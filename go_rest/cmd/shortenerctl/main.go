@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"url-shortener/internal/audit"
+	"url-shortener/internal/config"
+	"url-shortener/internal/storage/sqlite"
+)
+
+// Имя, под которым действия CLI попадают в журнал аудита
+const actor = "shortenerctl"
+
+const usage = `Usage: shortenerctl [--config path] [--json] <command> [arguments]
+
+Commands:
+  create [--password p] [--tags a,b] <url> [alias]
+                                       create a short link
+  get <alias>                          show a link
+  delete <alias>                       delete a link
+  list [--broken]                      list links
+  import <file|->                      import links from a JSON file
+  export [file|-]                      export links to a JSON file
+  rotate-credentials [--user name] [--role role]
+                                       generate a new BasicAuth password
+  vacuum                               compact the database file
+
+The config file is taken from --config or the CONFIG_PATH environment variable.
+Any config field can be overridden with an environment variable.
+`
+
+// app - общее состояние для всех команд
+type app struct {
+	ctx     context.Context
+	cfg     *config.Config
+	storage *sqlite.Storage
+	auditor *audit.Auditor
+	json    bool
+	out     io.Writer
+}
+
+type command func(a *app, args []string) error
+
+var commands = map[string]command{
+	"create":             cmdCreate,
+	"get":                cmdGet,
+	"delete":             cmdDelete,
+	"list":               cmdList,
+	"import":             cmdImport,
+	"export":             cmdExport,
+	"rotate-credentials": cmdRotateCredentials,
+	"vacuum":             cmdVacuum,
+}
+
+func main() {
+	var jsonOutput bool
+
+	flag.BoolVar(&jsonOutput, "json", false, "print output as JSON")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad()
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		fatal(err)
+	}
+	defer storage.Close()
+
+	// Логи CLI нужны только при ошибках
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	auditor, err := audit.New(log, storage, cfg.Audit.FilePath)
+	if err != nil {
+		fatal(err)
+	}
+	defer auditor.Close()
+
+	a := &app{
+		ctx:     context.Background(),
+		cfg:     cfg,
+		storage: storage,
+		auditor: auditor,
+		json:    jsonOutput,
+		out:     os.Stdout,
+	}
+
+	if err := cmd(a, flag.Args()[1:]); err != nil {
+		var uErr usageError
+		if errors.As(err, &uErr) {
+			fmt.Fprintf(os.Stderr, "%s\n\n", err)
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		fatal(err)
+	}
+}
+
+// usageError - ошибка в аргументах команды
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// print выводит v в формате JSON или вызывает human для текстового вывода
+func (a *app) print(v any, human func(w *tabwriter.Writer)) error {
+	if a.json {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	human(w)
+
+	return w.Flush()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
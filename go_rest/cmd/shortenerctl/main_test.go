@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/audit"
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage/sqlite"
+)
+
+// newTestApp создает app с пустой БД во временном каталоге и JSON-выводом в буфер
+func newTestApp(t *testing.T) (*app, *bytes.Buffer) {
+	t.Helper()
+
+	cfg := &config.Config{StoragePath: filepath.Join(t.TempDir(), "storage.db")}
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	auditor, err := audit.New(slogdiscard.NewDiscardLogger(), storage, "")
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+
+	return &app{
+		ctx:     context.Background(),
+		cfg:     cfg,
+		storage: storage,
+		auditor: auditor,
+		json:    true,
+		out:     out,
+	}, out
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -12,58 +17,217 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/natefinch/lumberjack.v2"
 
+	"url-shortener/internal/audit"
 	"url-shortener/internal/config"
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/events/kafka"
+	"url-shortener/internal/events/nop"
+	apikeyCreate "url-shortener/internal/http-server/handlers/apikey/create"
+	apikeyDelete "url-shortener/internal/http-server/handlers/apikey/delete"
+	apikeyList "url-shortener/internal/http-server/handlers/apikey/list"
 	"url-shortener/internal/http-server/handlers/redirect"
+	tagList "url-shortener/internal/http-server/handlers/tag/list"
+	tagMerge "url-shortener/internal/http-server/handlers/tag/merge"
+	tagRename "url-shortener/internal/http-server/handlers/tag/rename"
+	teamCreate "url-shortener/internal/http-server/handlers/team/create"
+	teamMemberAdd "url-shortener/internal/http-server/handlers/team/member/add"
+	teamMemberRemove "url-shortener/internal/http-server/handlers/team/member/remove"
+	teamURLs "url-shortener/internal/http-server/handlers/team/urls"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/history"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/rules"
 	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/transfer"
+	mwAuth "url-shortener/internal/http-server/middleware/auth"
 	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	mwTracing "url-shortener/internal/http-server/middleware/tracing"
+	"url-shortener/internal/lib/geoip"
 	"url-shortener/internal/lib/logger/handlers/slogpretty"
+	"url-shortener/internal/lib/logger/handlers/slogredact"
+	"url-shortener/internal/lib/logger/handlers/slogsampling"
+	"url-shortener/internal/lib/logger/handlers/slogtrace"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/linkcheck"
 	"url-shortener/internal/storage/sqlite"
-)
-
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
+	"url-shortener/internal/tracing"
+	"url-shortener/internal/users"
 )
 
 func main() {
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to print config:", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	log, logCloser, err := setupLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to set up logger:", err)
+		os.Exit(1)
+	}
+	defer logCloser.Close()
+
 	log = log.With(slog.String("env", cfg.Env)) // к каждому сообщению будет добавляться поле с информацией о текущем окружении
 
 	log.Info("initializing server", slog.String("address", cfg.Address)) // Помимо сообщения выведем параметр с адресом
 	log.Debug("logger debug mode enabled")
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("failed to initialize tracing", sl.Err(err))
+		os.Exit(1)
+	}
+
 	storage, err := sqlite.New(cfg.StoragePath)
 	if err != nil {
 		log.Error("failed to initialize storage", sl.Err(err))
 		os.Exit(1)
 	}
 
+	auditor, err := audit.New(log, storage, cfg.Audit.FilePath)
+	if err != nil {
+		log.Error("failed to initialize audit", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// Без Kafka события просто отбрасываются
+	var publisher events.Publisher = nop.New()
+	if cfg.Kafka.Enabled {
+		publisher, err = kafka.New(log, cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.ClientID)
+		if err != nil {
+			log.Error("failed to initialize event publisher", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	apiUsers, err := setupUsers(cfg.HTTPServer)
+	if err != nil {
+		log.Error("failed to load users", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("users loaded", slog.Int("count", apiUsers.Len()))
+
+	// Без базы GeoIP правила по странам не срабатывают
+	var geo redirect.GeoLocator
+	if cfg.GeoIPFile != "" {
+		geoDB, err := geoip.Open(cfg.GeoIPFile)
+		if err != nil {
+			log.Error("failed to load geoip database", sl.Err(err))
+			os.Exit(1)
+		}
+
+		log.Info("geoip database loaded", slog.Int("ranges", geoDB.Len()))
+
+		geo = geoDB
+	}
+
+	// По SIGHUP перечитываем файл пользователей без перезапуска
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			if err := apiUsers.Reload(); err != nil {
+				log.Error("failed to reload users", sl.Err(err))
+
+				continue
+			}
+
+			log.Info("users reloaded", slog.Int("count", apiUsers.Len()))
+		}
+	}()
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID) // Добавляет request_id в каждый запрос, для трейсинга
-	router.Use(middleware.Logger)    // Логирование всех запросов
+	router.Use(mwTracing.New())      // Серверный спан на каждый маршрут, продолжает traceparent клиента
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer) // Если где-то внутри сервера (обработчика запроса) произойдет паника, приложение не должно упасть
 	router.Use(middleware.URLFormat) // Парсер URLов поступающих запросов
 
 	// Все пути этого роутера будут начинаться с префикса `/url`
 	router.Route("/url", func(r chi.Router) {
-		// Подключаем авторизацию
-		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
-			// Передаем в middleware креды
-			cfg.HTTPServer.User: cfg.HTTPServer.Password,
-		}))
-
-		r.Post("/", save.New(log, storage))
-		// TODO: add DELETE /url/{id}
+		// Подключаем авторизацию: API-ключ или BasicAuth
+		r.Use(mwAuth.APIKey(log, storage))
+		r.Use(mwAuth.BasicAuth(log, "url-shortener", apiUsers))
+
+		// Читать могут все пользователи, изменять - writer и admin.
+		// API-ключам нужны соответствующие права. Изменять и удалять
+		// чужие ссылки могут только участники команды-владельца и администраторы.
+		canRead := mwAuth.Require(users.RoleReader, models.ScopeReadStats)
+		canCreate := mwAuth.Require(users.RoleWriter, models.ScopeCreate)
+		canDelete := mwAuth.Require(users.RoleWriter, models.ScopeDelete)
+
+		r.With(canRead).Get("/", list.New(log, storage))
+		r.With(canCreate).Post("/", save.New(log, storage, auditor, publisher))
+		r.With(canDelete).Delete("/{alias}", delete.New(log, storage, auditor, publisher))
+		r.With(canRead).Get("/{alias}/history", history.New(log, storage))
+		r.With(canCreate).Post("/{alias}/transfer", transfer.New(log, storage, auditor))
+		r.With(canCreate).Put("/{alias}/rules", rules.New(log, storage, auditor))
+	})
+
+	// Ссылки команды видят ее участники
+	router.Route("/teams", func(r chi.Router) {
+		r.Use(mwAuth.APIKey(log, storage))
+		r.Use(mwAuth.BasicAuth(log, "url-shortener", apiUsers))
+
+		r.With(mwAuth.Require(users.RoleReader, models.ScopeReadStats)).Get("/{id}/urls", teamURLs.New(log, storage))
+	})
+
+	// Список тегов с числом ссылок
+	router.Route("/tags", func(r chi.Router) {
+		r.Use(mwAuth.APIKey(log, storage))
+		r.Use(mwAuth.BasicAuth(log, "url-shortener", apiUsers))
+
+		r.With(mwAuth.Require(users.RoleReader, models.ScopeReadStats)).Get("/", tagList.New(log, storage))
 	})
 
-	router.Get("/{alias}", redirect.New(log, storage))
+	// Управление API-ключами, командами и тегами доступно только администраторам
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(mwAuth.BasicAuth(log, "url-shortener", apiUsers))
+		r.Use(mwAuth.RequireRole(users.RoleAdmin))
+
+		r.Get("/api-keys", apikeyList.New(log, storage))
+		r.Post("/api-keys", apikeyCreate.New(log, storage))
+		r.Delete("/api-keys/{id}", apikeyDelete.New(log, storage))
+
+		r.Post("/teams", teamCreate.New(log, storage))
+		r.Put("/teams/{id}/members/{member}", teamMemberAdd.New(log, storage))
+		r.Delete("/teams/{id}/members/{member}", teamMemberRemove.New(log, storage))
+
+		r.Post("/tags/rename", tagRename.New(log, storage))
+		r.Post("/tags/merge", tagMerge.New(log, storage))
+	})
+
+	// POST используется формой ввода пароля для защищенных ссылок
+	redirectHandler := redirect.New(log, storage, cfg.HTTPServer.CookieSecret, publisher, geo)
+	router.Get("/{alias}", redirectHandler)
+	router.Post("/{alias}", redirectHandler)
+
+	// Контекст фоновых задач, отменяется при остановке сервера
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	if cfg.LinkCheck.Enabled {
+		checker := linkcheck.New(
+			log, storage, cfg.LinkCheck.Interval, cfg.LinkCheck.Timeout, cfg.LinkCheck.Concurrency,
+		)
+
+		go checker.Run(bgCtx)
+	}
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
@@ -89,6 +253,8 @@ func main() {
 	<-done
 	log.Info("stopping server")
 
+	bgCancel()
+
 	// TODO: move timeout to config
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -99,34 +265,126 @@ func main() {
 		return
 	}
 
-	// TODO: close storage
+	// Дожидаемся доставки событий, отправленных до остановки
+	if err := publisher.Close(); err != nil {
+		log.Error("failed to close event publisher", sl.Err(err))
+	}
+
+	if err := auditor.Close(); err != nil {
+		log.Error("failed to close audit log", sl.Err(err))
+	}
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+	}
+
+	// Отправляем спаны, накопленные до остановки
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to stop tracing", sl.Err(err))
+	}
 
 	log.Info("server stopped")
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+// setupUsers загружает пользователей API из файла, а если он не задан -
+// создает единственного администратора из user/password конфига
+func setupUsers(cfg config.HTTPServer) (*users.Users, error) {
+	if cfg.UsersFile != "" {
+		return users.Load(cfg.UsersFile)
+	}
+
+	if cfg.User == "" || cfg.Password == "" {
+		return nil, errors.New("either http_server.users_file or http_server.user and password must be set")
+	}
 
-	switch env {
-	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDev:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	passHash, err := bcrypt.GenerateFromPassword([]byte(cfg.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
 	}
 
-	return log
+	return users.FromList([]users.User{{
+		Name:         cfg.User,
+		PasswordHash: string(passHash),
+		Role:         users.RoleAdmin,
+	}})
 }
 
-func setupPrettySlog() *slog.Logger {
-	opts := slogpretty.PrettyHandlerOptions{
-		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		},
+// setupLogger создает логгер по настройкам log из конфига. Формат и уровень,
+// не заданные явно, выбираются по окружению. Возвращаемый io.Closer
+// закрывает файл логов.
+func setupLogger(cfg *config.Config) (*slog.Logger, io.Closer, error) {
+	format, level := config.LogFormatJSON, slog.LevelInfo
+
+	switch cfg.Env {
+	case config.EnvLocal:
+		format, level = config.LogFormatPretty, slog.LevelDebug
+	case config.EnvDev:
+		format, level = config.LogFormatJSON, slog.LevelDebug
+	case config.EnvProd:
+		format, level = config.LogFormatJSON, slog.LevelInfo
+	default:
+		return nil, nil, fmt.Errorf("unknown env %q", cfg.Env)
+	}
+
+	if cfg.Log.Format != "" {
+		format = cfg.Log.Format
+	}
+
+	if cfg.Log.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+			return nil, nil, fmt.Errorf("invalid log level: %w", err)
+		}
+	}
+
+	var (
+		out    io.Writer = os.Stdout
+		closer io.Closer = io.NopCloser(nil)
+	)
+
+	if cfg.Log.Output == config.LogOutputFile {
+		// Файл ротируется по размеру, старые файлы удаляются по числу и возрасту
+		file := &lumberjack.Logger{
+			Filename:   cfg.Log.File.Path,
+			MaxSize:    cfg.Log.File.MaxSizeMB,
+			MaxBackups: cfg.Log.File.MaxBackups,
+			MaxAge:     cfg.Log.File.MaxAgeDays,
+			Compress:   cfg.Log.File.Compress,
+		}
+
+		out, closer = file, file
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+
+	switch format {
+	case config.LogFormatText:
+		handler = slog.NewTextHandler(out, opts)
+	case config.LogFormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	case config.LogFormatPretty:
+		handler = slogpretty.PrettyHandlerOptions{SlogOpts: opts}.NewPrettyHandler(out)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	// trace_id и span_id из контекста запроса
+	handler = slogtrace.NewHandler(handler)
+
+	// Секреты скрываются до сэмплирования и записи
+	handler = slogredact.NewHandler(handler, slogredact.Options{
+		Keys:        append(slices.Clone(slogredact.DefaultKeys), cfg.Log.RedactKeys...),
+		QueryParams: append(slices.Clone(slogredact.DefaultQueryParams), cfg.Log.RedactQueryParams...),
+	})
+
+	if cfg.Log.Sampling.Enabled {
+		handler = slogsampling.NewHandler(handler, slogsampling.Options{
+			Tick:       cfg.Log.Sampling.Tick,
+			First:      cfg.Log.Sampling.First,
+			Thereafter: cfg.Log.Sampling.Thereafter,
+		})
+	}
 
-	return slog.New(handler)
+	return slog.New(handler), closer, nil
 }
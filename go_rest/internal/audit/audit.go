@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// EventSaver сохраняет события аудита в БД
+type EventSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// Auditor записывает события в таблицу audit и, если задан файл,
+// дублирует их в него в формате JSON lines.
+type Auditor struct {
+	log   *slog.Logger
+	saver EventSaver
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// New создает Auditor. Пустой filePath отключает запись в файл.
+func New(log *slog.Logger, saver EventSaver, filePath string) (*Auditor, error) {
+	const op = "audit.New"
+
+	a := &Auditor{
+		log:   log.With(slog.String("component", "audit")),
+		saver: saver,
+	}
+
+	if filePath == "" {
+		return a, nil
+	}
+
+	// Файл открывается только на дозапись
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.file = f
+	a.enc = json.NewEncoder(f)
+
+	return a, nil
+}
+
+// Record сохраняет событие. Если время не задано, используется текущее.
+func (a *Auditor) Record(ctx context.Context, event models.AuditEvent) error {
+	const op = "audit.Record"
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	if err := a.saver.SaveAuditEvent(ctx, event); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if a.enc == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Событие уже есть в БД, поэтому ошибку записи в файл только логируем
+	if err := a.enc.Encode(event); err != nil {
+		a.log.ErrorContext(ctx, "failed to write audit event to file", sl.Err(err))
+	}
+
+	return nil
+}
+
+// Close закрывает файл журнала, если он был открыт
+func (a *Auditor) Close() error {
+	if a.file == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.file.Close()
+}
@@ -1,48 +1,393 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv" // для конфигурирования
+	"gopkg.in/yaml.v3"
 )
 
 // internal/config/config.go
 
+// Любое поле можно переопределить переменной окружения из тега env.
+// Переменные окружения имеют приоритет над конфиг-файлом.
 type Config struct {
-	Env         string `yaml:"env" env-default:"development"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
+	Env         string `yaml:"env" env:"ENV" env-default:"local"` // Окружение - local, dev или prod
+	StoragePath string `yaml:"storage_path" env:"STORAGE_PATH"`
 	HTTPServer  `yaml:"http_server"`
+	Log         LogConfig       `yaml:"log"`
+	Audit       AuditConfig     `yaml:"audit"`
+	LinkCheck   LinkCheckConfig `yaml:"link_check"`
+	Kafka       KafkaConfig     `yaml:"kafka"`
+	Tracing     TracingConfig   `yaml:"tracing"`
+}
+
+// Окружения
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+// Форматы логов
+const (
+	LogFormatText   = "text"
+	LogFormatJSON   = "json"
+	LogFormatPretty = "pretty" // Цветной вывод для локальной разработки
+)
+
+// Куда писать логи
+const (
+	LogOutputStdout = "stdout"
+	LogOutputFile   = "file"
+)
+
+// настройки логирования. Формат и уровень по умолчанию зависят от окружения:
+// local - pretty/debug, dev - json/debug, prod - json/info
+type LogConfig struct {
+	Format   string            `yaml:"format" env:"LOG_FORMAT"` // text, json или pretty
+	Level    string            `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn или error
+	Output   string            `yaml:"output" env:"LOG_OUTPUT" env-default:"stdout"`
+	File     LogFileConfig     `yaml:"file"`
+	Sampling LogSamplingConfig `yaml:"sampling"`
+	// Дополнительные ключи атрибутов и параметры URL, значения которых
+	// скрываются в логах (к стандартным password, token, key и т.д.)
+	RedactKeys        []string `yaml:"redact_keys" env:"LOG_REDACT_KEYS" env-separator:","`
+	RedactQueryParams []string `yaml:"redact_query_params" env:"LOG_REDACT_QUERY_PARAMS" env-separator:","`
+}
+
+// настройки записи логов в файл с ротацией
+type LogFileConfig struct {
+	Path       string `yaml:"path" env:"LOG_FILE_PATH"`
+	MaxSizeMB  int    `yaml:"max_size_mb" env:"LOG_FILE_MAX_SIZE_MB" env-default:"100"` // Размер файла, после которого он ротируется
+	MaxBackups int    `yaml:"max_backups" env:"LOG_FILE_MAX_BACKUPS" env-default:"5"`   // Сколько старых файлов хранить
+	MaxAgeDays int    `yaml:"max_age_days" env:"LOG_FILE_MAX_AGE_DAYS" env-default:"30"`
+	Compress   bool   `yaml:"compress" env:"LOG_FILE_COMPRESS" env-default:"false"` // Сжимать старые файлы gzip
+}
+
+// настройки сэмплирования: в каждом интервале Tick из одинаковых сообщений
+// пишутся первые First, а затем каждое Thereafter-е. Ошибки пишутся всегда.
+type LogSamplingConfig struct {
+	Enabled    bool          `yaml:"enabled" env:"LOG_SAMPLING_ENABLED" env-default:"false"`
+	Tick       time.Duration `yaml:"tick" env:"LOG_SAMPLING_TICK" env-default:"1s"`
+	First      int           `yaml:"first" env:"LOG_SAMPLING_FIRST" env-default:"100"`
+	Thereafter int           `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER" env-default:"100"`
+}
+
+// настройки публикации событий о ссылках в Kafka
+type KafkaConfig struct {
+	Enabled  bool     `yaml:"enabled" env:"KAFKA_ENABLED" env-default:"false"`
+	Brokers  []string `yaml:"brokers" env:"KAFKA_BROKERS" env-separator:"," env-default:"localhost:9092"`
+	Topic    string   `yaml:"topic" env:"KAFKA_TOPIC" env-default:"url-shortener-events"`
+	ClientID string   `yaml:"client_id" env:"KAFKA_CLIENT_ID" env-default:"url-shortener"`
+}
+
+// Экспортеры трассировки
+const (
+	TracingExporterStdout = "stdout" // Спаны пишутся в stdout, удобно для отладки
+	TracingExporterOTLP   = "otlp"   // Спаны отправляются коллектору по OTLP/HTTP
+)
+
+// настройки трассировки OpenTelemetry
+type TracingConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"TRACING_ENABLED" env-default:"false"`
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"stdout"` // stdout или otlp
+	// Адрес коллектора OTLP/HTTP (host:port)
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	Insecure bool   `yaml:"insecure" env:"TRACING_INSECURE" env-default:"false"` // Отправлять без TLS
+	// Доля трассируемых запросов от 0 до 1. Если у запроса уже есть
+	// traceparent, решение о сэмплировании берется из него
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"url-shortener"`
+}
+
+// настройки фоновой проверки доступности сохраненных URL
+type LinkCheckConfig struct {
+	Enabled     bool          `yaml:"enabled" env:"LINK_CHECK_ENABLED" env-default:"false"`
+	Interval    time.Duration `yaml:"interval" env:"LINK_CHECK_INTERVAL" env-default:"1h"`
+	Timeout     time.Duration `yaml:"timeout" env:"LINK_CHECK_TIMEOUT" env-default:"10s"` // Таймаут проверки одного URL
+	Concurrency int           `yaml:"concurrency" env:"LINK_CHECK_CONCURRENCY" env-default:"4"`
+}
+
+type AuditConfig struct {
+	// Файл для дублирования журнала аудита в формате JSON lines.
+	// Если не задан, события пишутся только в БД
+	FilePath string `yaml:"file_path" env:"AUDIT_FILE_PATH"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"0.0.0.0:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"5s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	Address     string        `yaml:"address" env:"HTTP_SERVER_ADDRESS" env-default:"0.0.0.0:8080"`
+	Timeout     time.Duration `yaml:"timeout" env:"HTTP_SERVER_TIMEOUT" env-default:"5s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	// Файл с пользователями API (bcrypt-хэши паролей и роли).
+	// Если не задан, используется единственный пользователь User/Password с ролью admin
+	UsersFile string `yaml:"users_file" env:"HTTP_SERVER_USERS_FILE"`
+	User      string `yaml:"user" env:"HTTP_SERVER_USER"`
+	Password  string `yaml:"password" env:"HTTP_SERVER_PASSWORD"`
+	// Ключ для подписи cookie, открывающих доступ к ссылкам с паролем
+	CookieSecret string `yaml:"cookie_secret" env:"HTTP_SERVER_COOKIE_SECRET"`
+	// CSV-файл с диапазонами IP и кодами стран для правил перенаправления.
+	// Если не задан, правила по странам не срабатывают
+	GeoIPFile string `yaml:"geoip_file" env:"HTTP_SERVER_GEOIP_FILE"`
 }
 
+// Флаг регистрируется при импорте пакета, чтобы его разбирал flag.Parse
+// в любой команде, которая читает конфиг
+var configPathFlag = flag.String("config", "", "path to config file (overrides CONFIG_PATH)")
+
+// Минимальные длины секретов в prod
+const (
+	minProdPasswordLen     = 12
+	minProdCookieSecretLen = 32
+)
+
+const redacted = "[REDACTED]"
+
+// MustLoad загружает и проверяет конфигурацию, а при ошибке завершает программу
 func MustLoad() *Config {
-	// Получаем путь до конфиг-файла из env-переменной CONFIG_PATH
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		log.Fatal("CONFIG_PATH environment variable is not set")
+	cfg, err := Load(Path())
+	if err != nil {
+		log.Fatalf("invalid config:\n%s", err)
 	}
 
-	// Проверяем существование конфиг-файла
-	if _, err := os.Stat(configPath); err != nil {
-		log.Fatalf("error opening config file: %s", err)
+	return cfg
+}
+
+// Path возвращает путь к конфиг-файлу (флаг -config > env CONFIG_PATH).
+// Пустая строка означает, что конфигурация читается только из окружения.
+func Path() string {
+	if !flag.Parsed() {
+		flag.Parse()
 	}
 
+	if *configPathFlag != "" {
+		return *configPathFlag
+	}
+
+	return os.Getenv("CONFIG_PATH")
+}
+
+// Load читает конфиг-файл (если путь не пустой), применяет переменные
+// окружения и проверяет результат
+func Load(configPath string) (*Config, error) {
 	var cfg Config
 
-	// Читаем конфиг-файл и заполняем нашу структуру
-	err := cleanenv.ReadConfig(configPath, &cfg)
+	if configPath == "" {
+		// Без файла все значения берутся из окружения и значений по умолчанию
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("error reading config from env: %w", err)
+		}
+	} else {
+		// Проверяем существование конфиг-файла
+		if _, err := os.Stat(configPath); err != nil {
+			return nil, fmt.Errorf("error opening config file: %w", err)
+		}
+
+		// Читаем конфиг-файл, затем переменные окружения
+		if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Validate проверяет конфигурацию и возвращает сразу все найденные проблемы
+func (c *Config) Validate() error {
+	var errs []error
+
+	problem := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvLocal, EnvDev, EnvProd:
+	default:
+		problem("env %q is unknown, use %s, %s or %s", c.Env, EnvLocal, EnvDev, EnvProd)
+	}
+
+	if c.StoragePath == "" {
+		problem("storage_path is required")
+	}
+
+	if err := validateAddress(c.Address); err != nil {
+		problem("http_server.address %q is invalid: %w", c.Address, err)
+	}
+
+	if c.Timeout <= 0 {
+		problem("http_server.timeout must be positive")
+	}
+
+	if c.IdleTimeout < 0 {
+		problem("http_server.idle_timeout must not be negative")
+	}
+
+	if c.UsersFile == "" && (c.User == "" || c.Password == "") {
+		problem("either http_server.users_file or http_server.user and http_server.password must be set")
+	}
+
+	if c.CookieSecret == "" {
+		problem("http_server.cookie_secret is required")
+	}
+
+	// В prod слабые секреты недопустимы
+	if c.Env == EnvProd {
+		if c.UsersFile == "" && c.Password != "" && weakPassword(c.User, c.Password) {
+			problem("http_server.password is too weak for prod: use at least %d characters, different from the user name", minProdPasswordLen)
+		}
+
+		if c.CookieSecret != "" && len(c.CookieSecret) < minProdCookieSecretLen {
+			problem("http_server.cookie_secret is too short for prod: use at least %d characters", minProdCookieSecretLen)
+		}
+	}
+
+	errs = append(errs, c.Log.validate()...)
+
+	if c.LinkCheck.Enabled {
+		if c.LinkCheck.Interval <= 0 {
+			problem("link_check.interval must be positive")
+		}
+
+		if c.LinkCheck.Timeout <= 0 {
+			problem("link_check.timeout must be positive")
+		}
+
+		if c.LinkCheck.Concurrency < 1 {
+			problem("link_check.concurrency must be at least 1")
+		}
+	}
+
+	if c.Kafka.Enabled {
+		if len(c.Kafka.Brokers) == 0 {
+			problem("kafka.brokers must not be empty")
+		}
+
+		if c.Kafka.Topic == "" {
+			problem("kafka.topic is required")
+		}
+	}
+
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case TracingExporterStdout:
+		case TracingExporterOTLP:
+			if err := validateAddress(c.Tracing.Endpoint); err != nil {
+				problem("tracing.endpoint %q is invalid: %w", c.Tracing.Endpoint, err)
+			}
+		default:
+			problem("tracing.exporter %q is unknown, use %s or %s",
+				c.Tracing.Exporter, TracingExporterStdout, TracingExporterOTLP)
+		}
+
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			problem("tracing.sample_ratio must be between 0 and 1")
+		}
+
+		if c.Tracing.ServiceName == "" {
+			problem("tracing.service_name is required")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c LogConfig) validate() []error {
+	var errs []error
+
+	switch c.Format {
+	case "", LogFormatText, LogFormatJSON, LogFormatPretty:
+	default:
+		errs = append(errs, fmt.Errorf("log.format %q is unknown, use %s, %s or %s",
+			c.Format, LogFormatText, LogFormatJSON, LogFormatPretty))
+	}
+
+	if c.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+			errs = append(errs, fmt.Errorf("log.level %q is unknown, use debug, info, warn or error", c.Level))
+		}
+	}
+
+	switch c.Output {
+	case LogOutputStdout:
+	case LogOutputFile:
+		if c.File.Path == "" {
+			errs = append(errs, fmt.Errorf("log.file.path is required when log.output is %s", LogOutputFile))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("log.output %q is unknown, use %s or %s", c.Output, LogOutputStdout, LogOutputFile))
+	}
+
+	if c.Sampling.Enabled {
+		if c.Sampling.Tick <= 0 {
+			errs = append(errs, fmt.Errorf("log.sampling.tick must be positive"))
+		}
+
+		if c.Sampling.First < 0 || c.Sampling.Thereafter < 0 {
+			errs = append(errs, fmt.Errorf("log.sampling.first and log.sampling.thereafter must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+// Redacted возвращает копию конфигурации со скрытыми секретами
+func (c Config) Redacted() Config {
+	if c.Password != "" {
+		c.Password = redacted
+	}
+
+	if c.CookieSecret != "" {
+		c.CookieSecret = redacted
+	}
+
+	// Срезы общие с исходной конфигурацией, копируем их
+	c.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	c.Log.RedactKeys = append([]string(nil), c.Log.RedactKeys...)
+	c.Log.RedactQueryParams = append([]string(nil), c.Log.RedactQueryParams...)
+
+	return c
+}
+
+// Print выводит итоговую конфигурацию в формате YAML, скрывая секреты
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func validateAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
 	if err != nil {
-		log.Fatalf("error reading config file: %s", err)
+		return err
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
 	}
 
-	return &cfg
+	return nil
+}
+
+func weakPassword(user string, password string) bool {
+	return len(password) < minProdPasswordLen || password == user
 }
@@ -0,0 +1,140 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+const validConfig = `
+env: "local"
+storage_path: "./storage.db"
+http_server:
+  address: "localhost:8082"
+  timeout: 4s
+  user: "myuser"
+  password: "mypass"
+  cookie_secret: "secret"
+`
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	return path
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := writeConfig(t, validConfig)
+
+	t.Setenv("HTTP_SERVER_ADDRESS", "localhost:9090")
+	t.Setenv("KAFKA_BROKERS", "k1:9092,k2:9092")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	require.Equal(t, "localhost:9090", cfg.Address)
+	require.Equal(t, []string{"k1:9092", "k2:9092"}, cfg.Kafka.Brokers)
+	require.Equal(t, "./storage.db", cfg.StoragePath)
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	t.Setenv("STORAGE_PATH", "/tmp/storage.db")
+	t.Setenv("HTTP_SERVER_USERS_FILE", "/etc/url-shortener/users.yaml")
+	t.Setenv("HTTP_SERVER_COOKIE_SECRET", "secret")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	require.Equal(t, "/tmp/storage.db", cfg.StoragePath)
+	require.Equal(t, "0.0.0.0:8080", cfg.Address)
+}
+
+func TestValidate_AllProblems(t *testing.T) {
+	cfg := config.Config{
+		Env: "prod",
+		HTTPServer: config.HTTPServer{
+			Address:      "localhost",
+			Timeout:      0,
+			User:         "admin",
+			Password:     "admin",
+			CookieSecret: "short",
+		},
+		LinkCheck: config.LinkCheckConfig{Enabled: true, Interval: 0, Timeout: 1, Concurrency: 0},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	for _, msg := range []string{
+		"storage_path is required",
+		`http_server.address "localhost" is invalid`,
+		"http_server.timeout must be positive",
+		"http_server.password is too weak for prod",
+		"http_server.cookie_secret is too short for prod",
+		"link_check.interval must be positive",
+		"link_check.concurrency must be at least 1",
+	} {
+		require.ErrorContains(t, err, msg)
+	}
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, validConfig))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Print(&buf))
+
+	out := buf.String()
+	require.Contains(t, out, "[REDACTED]")
+	require.Contains(t, out, "timeout: 4s")
+	require.NotContains(t, out, "mypass")
+	require.NotContains(t, out, "secret\n")
+
+	// Исходная конфигурация не меняется
+	require.Equal(t, "mypass", cfg.Password)
+}
+
+func TestValidate_Log(t *testing.T) {
+	t.Setenv("ENV", "development")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_OUTPUT", "file")
+
+	_, err := config.Load(writeConfig(t, validConfig))
+	require.Error(t, err)
+
+	for _, msg := range []string{
+		`env "development" is unknown`,
+		`log.format "xml" is unknown`,
+		`log.level "verbose" is unknown`,
+		"log.file.path is required",
+	} {
+		require.ErrorContains(t, err, msg)
+	}
+}
+
+func TestValidate_Tracing(t *testing.T) {
+	t.Setenv("TRACING_ENABLED", "true")
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("TRACING_ENDPOINT", "collector")
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+
+	_, err := config.Load(writeConfig(t, validConfig))
+	require.Error(t, err)
+
+	for _, msg := range []string{
+		`tracing.endpoint "collector" is invalid`,
+		"tracing.sample_ratio must be between 0 and 1",
+	} {
+		require.ErrorContains(t, err, msg)
+	}
+}
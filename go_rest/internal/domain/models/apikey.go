@@ -0,0 +1,39 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Права API-ключа
+const (
+	ScopeCreate    = "create"     // Создание ссылок
+	ScopeDelete    = "delete"     // Удаление ссылок
+	ScopeReadStats = "read-stats" // Просмотр ссылок и их истории
+)
+
+// Scopes - все известные права API-ключа
+var Scopes = []string{ScopeCreate, ScopeDelete, ScopeReadStats}
+
+// APIKey - ключ доступа к API для машинных клиентов (CI и т.п.).
+// Сам ключ не хранится, только его хэш и префикс для поиска.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope сообщает, что ключ дает право scope
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Expired сообщает, что срок действия ключа истек к моменту now
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Действия над ссылками, которые попадают в журнал аудита
+const (
+	AuditActionCreate = "create"
+	AuditActionDelete = "delete"
+	// Передача ссылки другому владельцу или команде
+	AuditActionTransfer = "transfer"
+	// Замена правил перенаправления
+	AuditActionRules = "rules"
+)
+
+// AuditEvent - запись журнала аудита об изменении ссылки
+type AuditEvent struct {
+	Time      time.Time `json:"time"`                 // Время события
+	Actor     string    `json:"actor"`                // Кто выполнил действие
+	Action    string    `json:"action"`               // Что было сделано
+	Alias     string    `json:"alias"`                // Алиас ссылки
+	OldURL    string    `json:"old_url,omitempty"`    // URL до изменения
+	NewURL    string    `json:"new_url,omitempty"`    // URL после изменения
+	OldOwner  string    `json:"old_owner,omitempty"`  // Владелец до передачи
+	NewOwner  string    `json:"new_owner,omitempty"`  // Владелец после передачи
+	RequestID string    `json:"request_id,omitempty"` // ID запроса для связи с логами
+}
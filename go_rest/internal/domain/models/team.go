@@ -0,0 +1,32 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Team - команда, которой могут принадлежать ссылки.
+// Участники команды могут просматривать и изменять ее ссылки.
+type Team struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Members   []string  `json:"members"` // Имена пользователей и API-ключей ("apikey:<name>")
+}
+
+// Owner - владелец ссылки: пользователь, создавший ее или получивший
+// при передаче, и, опционально, команда
+type Owner struct {
+	User   string `json:"owner,omitempty"`
+	TeamID *int64 `json:"team_id,omitempty"`
+}
+
+// String описывает владельца для журнала аудита
+func (o Owner) String() string {
+	if o.TeamID == nil {
+		return o.User
+	}
+
+	return fmt.Sprintf("%s (team %d)", o.User, *o.TeamID)
+}
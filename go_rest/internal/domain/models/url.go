@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// URL - сохраненная короткая ссылка
+type URL struct {
+	Alias string `json:"alias"` // Алиас ссылки
+	URL   string `json:"url"`   // Исходный URL
+
+	// Владелец ссылки. У ссылок, созданных до появления владельцев, он пустой
+	Owner
+
+	Tags []string `json:"tags,omitempty"` // Теги ссылки, "/" разделяет папки
+
+	// Адреса для разбиения трафика (A/B-тест). Пусто - у ссылки один адрес URL,
+	// иначе URL совпадает с адресом первого варианта
+	Destinations []Destination `json:"destinations,omitempty"`
+
+	// Правила перенаправления по языку, устройству и стране посетителя.
+	// Если ни одно правило не подошло, используются URL или Destinations
+	Rules []RedirectRule `json:"rules,omitempty"`
+
+	// Результат последней проверки доступности URL
+	LastStatus int        `json:"last_status,omitempty"` // HTTP-статус ответа
+	CheckError string     `json:"check_error,omitempty"` // Ошибка запроса, если ответа не было
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`  // Время проверки, nil - еще не проверялась
+}
+
+// Redirect - данные ссылки, нужные для перехода по ней
+type Redirect struct {
+	URL          string
+	PassHash     []byte // nil - ссылка без пароля
+	Destinations []Destination
+	Rules        []RedirectRule
+}
+
+// Broken сообщает, что при последней проверке URL оказался недоступен
+func (u URL) Broken() bool {
+	return u.CheckError != "" || u.LastStatus >= 400
+}
+
+// Destination - вариант ссылки с разбиением трафика. Доля посетителей,
+// попадающих на вариант, равна его весу, деленному на сумму весов
+type Destination struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Классы устройств для правил перенаправления
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceDesktop = "desktop"
+)
+
+// RedirectRule - правило перенаправления. Правило подходит, если посетитель
+// удовлетворяет всем заданным условиям, а внутри условия - любому из значений.
+// Пустое условие не проверяется.
+type RedirectRule struct {
+	Languages []string `json:"languages,omitempty"` // Языки из Accept-Language: "ru", "en-us"
+	Devices   []string `json:"devices,omitempty"`   // ios, android или desktop
+	Countries []string `json:"countries,omitempty"` // Коды стран по базе GeoIP: "RU", "DE"
+	URL       string   `json:"url"`                 // Куда перенаправить
+}
+
+// Tag - тег и число ссылок с ним
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,29 @@
+package events
+
+import "time"
+
+// Типы событий об изменениях и переходах по ссылкам
+const (
+	TypeLinkCreated = "link.created"
+	TypeLinkDeleted = "link.deleted"
+	TypeLinkClicked = "link.clicked"
+)
+
+// Event - событие сервиса. Публикуется в JSON с ключом Alias,
+// поэтому все события одной ссылки попадают в одну партицию по порядку.
+type Event struct {
+	Type      string    `json:"type"`                 // Тип события
+	Alias     string    `json:"alias"`                // Алиас ссылки
+	URL       string    `json:"url,omitempty"`        // URL ссылки
+	Variant   *int      `json:"variant,omitempty"`    // Номер варианта (с 0) для ссылок с разбиением трафика
+	Rule      *int      `json:"rule,omitempty"`       // Номер сработавшего правила перенаправления (с 0)
+	Actor     string    `json:"actor,omitempty"`      // Кто выполнил действие
+	RequestID string    `json:"request_id,omitempty"` // ID запроса для связи с логами
+	Time      time.Time `json:"time"`                 // Время события
+}
+
+// Publisher публикует события сервиса
+type Publisher interface {
+	Publish(event Event) error
+	Close() error
+}
@@ -0,0 +1,204 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"url-shortener/internal/events"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+const (
+	// Сколько раз переотправлять событие после ошибки доставки
+	maxRetries = 3
+	// Сколько ждать доставки оставшихся событий при остановке
+	flushTimeout = 10 * time.Second
+	// Как часто при остановке проверять, не появились ли повторные отправки
+	flushInterval = 100 * time.Millisecond
+)
+
+var errClosed = errors.New("publisher is closed")
+
+// Publisher публикует события в Kafka. Доставка best-effort: каждое
+// сообщение ждет отчета о доставке (delivery report) и при ошибке
+// отправляется повторно до maxRetries раз. Событие теряется, если все
+// попытки неудачны, если оно не доставлено за flushTimeout при остановке
+// или если процесс упал: очередь продюсера хранится только в памяти.
+// Потери логируются и учитываются в ошибке Close.
+type Publisher struct {
+	log      *slog.Logger
+	producer *kafka.Producer
+	topic    string
+	done     chan struct{}
+
+	mu      sync.RWMutex // Не дает отправлять сообщения после закрытия продюсера
+	closed  bool
+	pending atomic.Int64 // События, по которым еще нет окончательного результата
+	dropped atomic.Int64 // События, которые так и не удалось доставить
+}
+
+func New(log *slog.Logger, brokers []string, topic string, clientID string) (*Publisher, error) {
+	const op = "events.kafka.New"
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		// Адреса брокеров Kafka для первоначального подключения
+		"bootstrap.servers": strings.Join(brokers, ","),
+		// Ожидание подтверждения от всех реплик
+		"acks": "all",
+		// Повторы внутри librdkafka не будут создавать дубликаты
+		"enable.idempotence": true,
+		// Идентификатор клиента для мониторинга и отладки
+		"client.id": clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p := &Publisher{
+		log:      log.With(slog.String("component", "events/kafka")),
+		producer: producer,
+		topic:    topic,
+		done:     make(chan struct{}),
+	}
+
+	go p.handleEvents()
+
+	return p, nil
+}
+
+// Publish ставит событие в очередь на отправку. Ключ сообщения - алиас,
+// поэтому события одной ссылки упорядочены внутри партиции.
+func (p *Publisher) Publish(event events.Event) error {
+	const op = "events.kafka.Publish"
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Счетчик увеличивается до отправки: отчет о доставке может прийти раньше,
+	// чем produce вернет управление
+	p.pending.Add(1)
+
+	err = p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
+		Key:            []byte(event.Alias),
+		Value:          value,
+		Opaque:         0, // Номер попытки
+	})
+	if err != nil {
+		p.pending.Add(-1)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// produce ставит сообщение в очередь продюсера, если он еще не закрыт
+func (p *Publisher) produce(msg *kafka.Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return errClosed
+	}
+
+	return p.producer.Produce(msg, nil)
+}
+
+// handleEvents обрабатывает отчеты о доставке и переотправляет
+// сообщения, которые не удалось доставить
+func (p *Publisher) handleEvents() {
+	defer close(p.done)
+
+	for e := range p.producer.Events() {
+		switch ev := e.(type) {
+		case *kafka.Message:
+			if ev.TopicPartition.Error == nil {
+				p.pending.Add(-1)
+
+				continue
+			}
+
+			p.retry(ev)
+		case kafka.Error:
+			p.log.Error("kafka error", sl.Err(ev))
+		}
+	}
+}
+
+func (p *Publisher) retry(msg *kafka.Message) {
+	attempt, _ := msg.Opaque.(int)
+
+	log := p.log.With(
+		slog.String("key", string(msg.Key)),
+		slog.Int("attempt", attempt+1),
+		sl.Err(msg.TopicPartition.Error),
+	)
+
+	if attempt >= maxRetries {
+		log.Error("failed to deliver event, giving up", slog.String("event", string(msg.Value)))
+
+		p.drop()
+
+		return
+	}
+
+	log.Warn("failed to deliver event, retrying")
+
+	err := p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Opaque:         attempt + 1,
+	})
+	if err != nil {
+		log.Error("failed to requeue event", slog.String("event", string(msg.Value)), sl.Err(err))
+
+		p.drop()
+	}
+}
+
+func (p *Publisher) drop() {
+	p.dropped.Add(1)
+	p.pending.Add(-1)
+}
+
+// Close дожидается доставки оставшихся событий, включая повторные
+// отправки, но не дольше flushTimeout, и закрывает продюсер
+func (p *Publisher) Close() error {
+	const op = "events.kafka.Close"
+
+	// Flush возвращается, как только очередь продюсера пуста, а повторная
+	// отправка попадает в нее уже после отчета о доставке. Поэтому ждем,
+	// пока не будет окончательного результата по каждому событию
+	deadline := time.Now().Add(flushTimeout)
+
+	for p.pending.Load() > 0 && time.Now().Before(deadline) {
+		p.producer.Flush(int(flushInterval.Milliseconds()))
+	}
+
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	// Close закрывает канал Events, после чего завершается handleEvents
+	p.producer.Close()
+	<-p.done
+
+	lost := p.pending.Load() + p.dropped.Load()
+	if lost > 0 {
+		return fmt.Errorf("%s: %d events were not delivered", op, lost)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"sync"
+
+	"url-shortener/internal/events"
+)
+
+// Publisher хранит опубликованные события в памяти. Используется в тестах
+type Publisher struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func New() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(event events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+
+	return nil
+}
+
+// Events возвращает копию опубликованных событий в порядке публикации
+func (p *Publisher) Events() []events.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := make([]events.Event, len(p.events))
+	copy(res, p.events)
+
+	return res
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
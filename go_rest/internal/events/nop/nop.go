@@ -0,0 +1,19 @@
+package nop
+
+import "url-shortener/internal/events"
+
+// Publisher отбрасывает все события. Используется, когда публикация выключена
+type Publisher struct{}
+
+func New() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(_ events.Event) error {
+	// Просто игнорируем событие
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package create
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Request struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=create delete read-stats"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	// Сам ключ возвращается только один раз, при создании
+	Key    string         `json:"key,omitempty"`
+	APIKey *models.APIKey `json:"api_key,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=APIKeySaver
+type APIKeySaver interface {
+	SaveAPIKey(ctx context.Context, key models.APIKey, keyHash []byte) (int64, error)
+}
+
+// New создает API-ключ для машинного клиента
+func New(log *slog.Logger, keySaver APIKeySaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		now := time.Now().UTC()
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+			log.InfoContext(r.Context(), "api key expiry is in the past")
+
+			render.JSON(w, r, resp.Error("field ExpiresAt must be in the future"))
+
+			return
+		}
+
+		key, prefix, err := apikey.Generate()
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to generate api key", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to create api key"))
+
+			return
+		}
+
+		apiKey := models.APIKey{
+			Name:      req.Name,
+			Prefix:    prefix,
+			Scopes:    req.Scopes,
+			CreatedBy: auth.Actor(r.Context()),
+			CreatedAt: now,
+			ExpiresAt: req.ExpiresAt,
+		}
+
+		apiKey.ID, err = keySaver.SaveAPIKey(r.Context(), apiKey, apikey.Hash(key))
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to save api key", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to create api key"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "api key created",
+			slog.Int64("id", apiKey.ID),
+			slog.String("name", apiKey.Name),
+			slog.String("prefix", apiKey.Prefix),
+			slog.String("actor", apiKey.CreatedBy),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Key:      key,
+			APIKey:   &apiKey,
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package create_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/apikey/create"
+	"url-shortener/internal/http-server/handlers/apikey/create/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/users"
+)
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		respError string
+		mockError error
+	}{
+		{
+			name:  "Success",
+			input: `{"name": "ci", "scopes": ["create", "read-stats"]}`,
+		},
+		{
+			name:  "With expiry",
+			input: `{"name": "ci", "scopes": ["delete"], "expires_at": "2999-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "No scopes",
+			input:     `{"name": "ci", "scopes": []}`,
+			respError: "field Scopes is not valid",
+		},
+		{
+			name:      "Unknown scope",
+			input:     `{"name": "ci", "scopes": ["admin"]}`,
+			respError: "field Scopes[0] is not valid",
+		},
+		{
+			name:      "Empty name",
+			input:     `{"scopes": ["create"]}`,
+			respError: "field Name is a required field",
+		},
+		{
+			name:      "Expired",
+			input:     `{"name": "ci", "scopes": ["create"], "expires_at": "2000-01-01T00:00:00Z"}`,
+			respError: "field ExpiresAt must be in the future",
+		},
+		{
+			name:      "SaveAPIKey Error",
+			input:     `{"name": "ci", "scopes": ["create"]}`,
+			respError: "failed to create api key",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keySaverMock := mocks.NewAPIKeySaver(t)
+
+			var savedHash []byte
+
+			if tc.respError == "" || tc.mockError != nil {
+				keySaverMock.On("SaveAPIKey", mock.Anything, mock.MatchedBy(func(k models.APIKey) bool {
+					return k.Name == "ci" && k.CreatedBy == "admin" && k.Prefix != ""
+				}), mock.Anything).
+					Run(func(args mock.Arguments) { savedHash = args.Get(2).([]byte) }).
+					Return(int64(1), tc.mockError).
+					Once()
+			}
+
+			handler := create.New(slogdiscard.NewDiscardLogger(), keySaverMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "admin", Role: users.RoleAdmin}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp create.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError != "" {
+				require.Empty(t, resp.Key)
+
+				return
+			}
+
+			// Хранится только хэш, и он соответствует выданному ключу
+			require.NotNil(t, resp.APIKey)
+			require.True(t, apikey.Verify(resp.Key, savedHash))
+
+			prefix, ok := apikey.Prefix(resp.Key)
+			require.True(t, ok)
+			require.Equal(t, resp.APIKey.Prefix, prefix)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=APIKeyDeleter
+type APIKeyDeleter interface {
+	DeleteAPIKey(ctx context.Context, id int64) error
+}
+
+// New отзывает API-ключ. Ключ перестает приниматься сразу.
+func New(log *slog.Logger, keyDeleter APIKeyDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid api key id", slog.String("id", chi.URLParam(r, "id")))
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		err = keyDeleter.DeleteAPIKey(r.Context(), id)
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			log.InfoContext(r.Context(), "api key not found", slog.Int64("id", id))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to delete api key", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to delete api key"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "api key deleted", slog.Int64("id", id), slog.String("actor", auth.Actor(r.Context())))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,80 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/apikey/delete"
+	"url-shortener/internal/http-server/handlers/apikey/delete/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		id        string
+		keyID     int64
+		respError string
+		mockError error
+	}{
+		{
+			name:  "Success",
+			id:    "1",
+			keyID: 1,
+		},
+		{
+			name:      "Invalid id",
+			id:        "abc",
+			respError: "invalid request",
+		},
+		{
+			name:      "Not found",
+			id:        "42",
+			keyID:     42,
+			respError: "not found",
+			mockError: storage.ErrAPIKeyNotFound,
+		},
+		{
+			name:      "DeleteAPIKey Error",
+			id:        "1",
+			keyID:     1,
+			respError: "failed to delete api key",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyDeleterMock := mocks.NewAPIKeyDeleter(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				keyDeleterMock.On("DeleteAPIKey", mock.Anything, tc.keyID).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/admin/api-keys/{id}", delete.New(slogdiscard.NewDiscardLogger(), keyDeleterMock))
+
+			req, err := http.NewRequest(http.MethodDelete, "/admin/api-keys/"+tc.id, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var res resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			require.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
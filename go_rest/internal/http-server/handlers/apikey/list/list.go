@@ -0,0 +1,56 @@
+package list
+
+import (
+	"context"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=APIKeyLister
+type APIKeyLister interface {
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+}
+
+// New возвращает список API-ключей. Сами ключи не хранятся,
+// поэтому в ответе есть только их префиксы.
+func New(log *slog.Logger, keyLister APIKeyLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		keys, err := keyLister.ListAPIKeys(r.Context())
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list api keys", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		if keys == nil {
+			keys = []models.APIKey{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			APIKeys:  keys,
+		})
+	}
+}
@@ -1,28 +1,98 @@
 package redirect
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"html/template"
+	"net"
 	"net/http"
+	"net/netip"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
+	"golang.org/x/crypto/bcrypt"
 
 	"log/slog" // для логирования
 
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/cookie"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/ratelimit"
+	"url-shortener/internal/lib/rules"
+	"url-shortener/internal/lib/split"
 	"url-shortener/internal/storage"
 )
 
+// TODO: move to config when needed
+const (
+	unlockCookieName = "unlock"
+	unlockCookieTTL  = 24 * time.Hour
+
+	// Не больше maxUnlockAttempts неверных паролей за unlockWindow
+	// для пары alias + IP
+	maxUnlockAttempts = 5
+	unlockWindow      = 15 * time.Minute
+
+	// Cookie с ID посетителя, по которому выбирается вариант ссылки
+	visitorCookieName = "vid"
+	visitorCookieTTL  = 365 * 24 * time.Hour
+)
+
+var unlockForm = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Protected link</title></head>
+<body>
+<form method="POST">
+  <p>This link is password protected.</p>
+  {{if .}}<p style="color:red">{{.}}</p>{{end}}
+  <input type="password" name="password" autofocus>
+  <button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
 // URLGetter is an interface for getting url by alias.
 
 //go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetRedirect(ctx context.Context, alias string) (models.Redirect, error)
+}
+
+// GeoLocator определяет страну посетителя по IP-адресу
+type GeoLocator interface {
+	Country(addr netip.Addr) string
+}
+
+// EventPublisher публикует события о ссылках для других сервисов
+type EventPublisher interface {
+	Publish(event events.Event) error
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// New возвращает обработчик редиректа. Для ссылок с паролем на GET
+// отдается форма ввода пароля, а на POST пароль проверяется и
+// выставляется подписанная cookie, после чего выполняется редирект.
+//
+// Сначала проверяются правила перенаправления ссылки (язык, устройство,
+// страна по geo; geo может быть nil - тогда правила по странам не срабатывают).
+// Если ни одно не подошло, для ссылок с несколькими вариантами адреса вариант
+// выбирается по весам детерминированно по ID посетителя из cookie, поэтому
+// повторные переходы ведут на тот же вариант.
+func New(
+	log *slog.Logger,
+	urlGetter URLGetter,
+	cookieSecret string,
+	publisher EventPublisher,
+	geo GeoLocator,
+) http.HandlerFunc {
+	limiter := ratelimit.New(maxUnlockAttempts, unlockWindow)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -33,32 +103,218 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
-			log.Info("alias is empty")
+			log.InfoContext(r.Context(), "alias is empty")
 
 			render.JSON(w, r, resp.Error("invalid request"))
 
 			return
 		}
 
-		resURL, err := urlGetter.GetURL(alias)
+		link, err := urlGetter.GetRedirect(r.Context(), alias)
 		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Info("url not found", "alias", alias)
+			log.InfoContext(r.Context(), "url not found", "alias", alias)
 
 			render.JSON(w, r, resp.Error("not found"))
 
 			return
 		}
 		if err != nil {
-			log.Error("failed to get url", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to get url", sl.Err(err))
 
 			render.JSON(w, r, resp.Error("internal error"))
 
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
+		if len(link.PassHash) > 0 && !unlocked(r, cookieSecret, alias, link.PassHash) {
+			if !unlock(log, w, r, limiter, cookieSecret, alias, link.PassHash) {
+				return
+			}
+		}
+
+		resURL := link.URL
+
+		var variant, rule *int
+
+		if len(link.Rules) > 0 {
+			if idx := rules.Match(link.Rules, client(r, geo)); idx >= 0 {
+				rule = &idx
+				resURL = link.Rules[idx].URL
+			}
+		}
+
+		// Разбиение трафика - запасной вариант, если правила не сработали
+		if rule == nil && len(link.Destinations) > 0 {
+			if idx := pickDestination(w, r, alias, link.Destinations); idx >= 0 {
+				variant = &idx
+				resURL = link.Destinations[idx].URL
+			}
+		}
+
+		log.InfoContext(r.Context(), "got url",
+			slog.String("url", resURL),
+			slog.Any("variant", variant),
+			slog.Any("rule", rule),
+		)
+
+		err = publisher.Publish(events.Event{
+			Type:      events.TypeLinkClicked,
+			Alias:     alias,
+			URL:       resURL,
+			Variant:   variant,
+			Rule:      rule,
+			RequestID: middleware.GetReqID(r.Context()),
+			Time:      time.Now().UTC(),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to publish event", sl.Err(err))
+		}
 
 		// redirect to found url
 		http.Redirect(w, r, resURL, http.StatusFound)
 	}
 }
+
+// client собирает свойства посетителя для проверки правил
+func client(r *http.Request, geo GeoLocator) rules.Client {
+	c := rules.Client{
+		Language: rules.PreferredLanguage(r.Header.Get("Accept-Language")),
+		Device:   rules.DeviceClass(r.UserAgent()),
+	}
+
+	if geo != nil {
+		if addr, err := netip.ParseAddr(clientIP(r)); err == nil {
+			c.Country = geo.Country(addr)
+		}
+	}
+
+	return c
+}
+
+// pickDestination выбирает вариант ссылки для посетителя. Посетителю без
+// cookie выдается новый ID, чтобы следующие переходы вели на тот же вариант.
+func pickDestination(w http.ResponseWriter, r *http.Request, alias string, destinations []models.Destination) int {
+	visitor := ""
+	if c, err := r.Cookie(visitorCookieName); err == nil {
+		visitor = c.Value
+	}
+
+	if visitor == "" {
+		visitor = newVisitorID()
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     visitorCookieName,
+			Value:    visitor,
+			Path:     "/",
+			Expires:  time.Now().Add(visitorCookieTTL),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	weights := make([]int, len(destinations))
+	for i, d := range destinations {
+		weights[i] = d.Weight
+	}
+
+	// В ключ входит alias, чтобы варианты разных ссылок выбирались независимо
+	return split.Pick(weights, visitor+"|"+alias)
+}
+
+func newVisitorID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read не возвращает ошибок
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// unlocked проверяет, есть ли у клиента действующая cookie для ссылки
+func unlocked(r *http.Request, secret string, alias string, passHash []byte) bool {
+	c, err := r.Cookie(unlockCookieName)
+	if err != nil {
+		return false
+	}
+
+	return cookie.Verify([]byte(secret), cookiePayload(alias, passHash), c.Value) == nil
+}
+
+// unlock показывает форму ввода пароля или проверяет присланный пароль.
+// Возвращает true, если пароль верный и можно делать редирект.
+func unlock(
+	log *slog.Logger,
+	w http.ResponseWriter,
+	r *http.Request,
+	limiter *ratelimit.Limiter,
+	secret string,
+	alias string,
+	passHash []byte,
+) bool {
+	if r.Method != http.MethodPost {
+		renderUnlockForm(log, w, http.StatusOK, "")
+
+		return false
+	}
+
+	key := alias + "|" + clientIP(r)
+
+	// Попытка засчитывается до проверки пароля: bcrypt медленный,
+	// и параллельные запросы иначе успели бы пройти лимит
+	if !limiter.Reserve(key) {
+		log.WarnContext(r.Context(), "too many unlock attempts", slog.String("alias", alias))
+
+		renderUnlockForm(log, w, http.StatusTooManyRequests, "Too many attempts, try again later")
+
+		return false
+	}
+
+	err := bcrypt.CompareHashAndPassword(passHash, []byte(r.PostFormValue("password")))
+	if err != nil {
+		log.InfoContext(r.Context(), "invalid link password", slog.String("alias", alias))
+
+		renderUnlockForm(log, w, http.StatusUnauthorized, "Wrong password")
+
+		return false
+	}
+
+	limiter.Reset(key)
+
+	// Подпись включает хэш пароля, поэтому смена пароля
+	// делает ранее выданные cookie недействительными
+	expires := time.Now().Add(unlockCookieTTL)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookieName,
+		Value:    cookie.Sign([]byte(secret), cookiePayload(alias, passHash), expires),
+		Path:     "/" + alias,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return true
+}
+
+func renderUnlockForm(log *slog.Logger, w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := unlockForm.Execute(w, msg); err != nil {
+		log.Error("failed to render unlock form", sl.Err(err))
+	}
+}
+
+func cookiePayload(alias string, passHash []byte) string {
+	return alias + "|" + string(passHash)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
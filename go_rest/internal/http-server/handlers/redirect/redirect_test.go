@@ -1,19 +1,32 @@
 package redirect_test
 
 import (
+	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/events/memory"
+	"url-shortener/internal/events/nop"
 	"url-shortener/internal/http-server/handlers/redirect"
 	"url-shortener/internal/http-server/handlers/redirect/mocks"
 	"url-shortener/internal/lib/api"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
 )
 
+const testCookieSecret = "test-secret"
+
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -34,12 +47,14 @@ func TestSaveHandler(t *testing.T) {
 			urlGetterMock := mocks.NewURLGetter(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
+				urlGetterMock.On("GetRedirect", mock.Anything, tc.alias).
+					Return(models.Redirect{URL: tc.url}, tc.mockError).Once()
 			}
 
+			publisher := memory.New()
+
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, publisher, nil))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
@@ -49,6 +64,336 @@ func TestSaveHandler(t *testing.T) {
 
 			// Check the final URL after redirection.
 			assert.Equal(t, tc.url, redirectedToURL)
+
+			// Переход публикуется как событие link.clicked
+			published := publisher.Events()
+			require.Len(t, published, 1)
+			assert.Equal(t, events.TypeLinkClicked, published[0].Type)
+			assert.Equal(t, tc.alias, published[0].Alias)
+			assert.Nil(t, published[0].Variant)
+		})
+	}
+}
+
+func TestProtectedRedirect(t *testing.T) {
+	const (
+		alias    = "protected"
+		target   = "https://www.google.com/"
+		password = "secret"
+	)
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetRedirect", mock.Anything, alias).
+		Return(models.Redirect{URL: target, PassHash: passHash}, nil)
+
+	r := chi.NewRouter()
+	publisher := memory.New()
+	h := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, publisher, nil)
+	r.Get("/{alias}", h)
+	r.Post("/{alias}", h)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Без cookie отдается форма ввода пароля
+	resp, err := client.Get(ts.URL + "/" + alias)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, publisher.Events(), "form must not count as a click")
+
+	// Неверный пароль
+	resp, err = client.PostForm(ts.URL+"/"+alias, url.Values{"password": {"wrong"}})
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Верный пароль - редирект и cookie
+	resp, err = client.PostForm(ts.URL+"/"+alias, url.Values{"password": {password}})
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, target, resp.Header.Get("Location"))
+
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+
+	// С cookie редирект выполняется сразу
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/"+alias, nil)
+	require.NoError(t, err)
+	req.AddCookie(cookies[0])
+
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	// Подделанная cookie не принимается
+	req, err = http.NewRequest(http.MethodGet, ts.URL+"/"+alias, nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: cookies[0].Name, Value: cookies[0].Value + "x"})
+
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestProtectedRedirect_RateLimit(t *testing.T) {
+	const alias = "protected"
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetRedirect", mock.Anything, alias).
+		Return(models.Redirect{URL: "https://www.google.com/", PassHash: passHash}, nil)
+
+	r := chi.NewRouter()
+	r.Post("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, nop.New(), nil))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	codes := make([]int, 0, 6)
+	for i := 0; i < 6; i++ {
+		resp, err := http.PostForm(ts.URL+"/"+alias, url.Values{"password": {"wrong"}})
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		codes = append(codes, resp.StatusCode)
+	}
+
+	// Первые пять попыток проверяются, шестая отклоняется лимитером
+	assert.Equal(t, http.StatusUnauthorized, codes[4])
+	assert.Equal(t, http.StatusTooManyRequests, codes[5])
+}
+
+func TestProtectedRedirect_ParallelRateLimit(t *testing.T) {
+	const alias = "protected"
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetRedirect", mock.Anything, alias).
+		Return(models.Redirect{URL: "https://www.google.com/", PassHash: passHash}, nil)
+
+	r := chi.NewRouter()
+	r.Post("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, nop.New(), nil))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	const requests = 20
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		codes = map[int]int{}
+	)
+
+	for range requests {
+		wg.Go(func() {
+			resp, err := http.PostForm(ts.URL+"/"+alias, url.Values{"password": {"wrong"}})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_ = resp.Body.Close()
+
+			mu.Lock()
+			codes[resp.StatusCode]++
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	// Параллельные запросы не обходят лимит: пароль проверяется ровно пять раз
+	assert.Equal(t, 5, codes[http.StatusUnauthorized])
+	assert.Equal(t, requests-5, codes[http.StatusTooManyRequests])
+}
+
+func TestSplitRedirect(t *testing.T) {
+	const alias = "ab"
+
+	destinations := []models.Destination{
+		{URL: "https://a.example.com/", Weight: 1},
+		{URL: "https://b.example.com/", Weight: 1},
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetRedirect", mock.Anything, alias).
+		Return(models.Redirect{URL: destinations[0].URL, Destinations: destinations}, nil)
+
+	publisher := memory.New()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, publisher, nil))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	get := func(visitor *http.Cookie) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/"+alias, nil)
+		require.NoError(t, err)
+
+		if visitor != nil {
+			req.AddCookie(visitor)
+		}
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		return resp
+	}
+
+	// Новому посетителю выдается cookie с ID
+	resp := get(nil)
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+
+	visitor := cookies[0]
+	first := resp.Header.Get("Location")
+
+	// С той же cookie посетитель всегда попадает на тот же вариант
+	for range 5 {
+		resp := get(visitor)
+		assert.Equal(t, first, resp.Header.Get("Location"))
+		assert.Empty(t, resp.Cookies(), "cookie must not be reissued")
+	}
+
+	// Разные посетители попадают на оба варианта
+	seen := map[string]bool{}
+	for i := range 50 {
+		resp := get(&http.Cookie{Name: visitor.Name, Value: "visitor-" + strconv.Itoa(i)})
+		seen[resp.Header.Get("Location")] = true
+	}
+	assert.Len(t, seen, 2)
+
+	// Вариант записывается в событие перехода
+	published := publisher.Events()
+	require.NotEmpty(t, published)
+	for _, e := range published {
+		require.NotNil(t, e.Variant)
+		assert.Equal(t, destinations[*e.Variant].URL, e.URL)
+	}
+}
+
+// countries - GeoLocator для тестов: IP -> код страны
+type countries map[string]string
+
+func (c countries) Country(addr netip.Addr) string {
+	return c[addr.String()]
+}
+
+func TestRedirectRules(t *testing.T) {
+	const alias = "app"
+
+	redirectRules := []models.RedirectRule{
+		{Devices: []string{models.DeviceIOS}, URL: "https://apps.apple.com/app"},
+		{Devices: []string{models.DeviceAndroid}, URL: "https://play.google.com/app"},
+		{Countries: []string{"DE"}, URL: "https://example.de/"},
+		{Languages: []string{"ru"}, URL: "https://example.ru/"},
+	}
+
+	cases := []struct {
+		name     string
+		ua       string
+		lang     string
+		ip       string
+		wantURL  string
+		wantRule *int
+	}{
+		{
+			name:     "iPhone",
+			ua:       "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			lang:     "ru",
+			wantURL:  "https://apps.apple.com/app",
+			wantRule: ptr(0),
+		},
+		{
+			name:     "Android",
+			ua:       "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			wantURL:  "https://play.google.com/app",
+			wantRule: ptr(1),
+		},
+		{
+			name:     "Country from GeoIP",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			lang:     "ru",
+			ip:       "10.0.0.1",
+			wantURL:  "https://example.de/",
+			wantRule: ptr(2),
+		},
+		{
+			name:     "Language",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			lang:     "ru-RU,ru;q=0.9,en;q=0.8",
+			wantURL:  "https://example.ru/",
+			wantRule: ptr(3),
+		},
+		{
+			name:    "Default",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			lang:    "en-US,ru;q=0.5",
+			wantURL: "https://example.com/",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetRedirect", mock.Anything, alias).
+				Return(models.Redirect{URL: "https://example.com/", Rules: redirectRules}, nil).Once()
+
+			publisher := memory.New()
+			geo := countries{"10.0.0.1": "DE"}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, testCookieSecret, publisher, geo))
+
+			req := httptest.NewRequest(http.MethodGet, "/"+alias, nil)
+			req.Header.Set("User-Agent", tc.ua)
+			req.Header.Set("Accept-Language", tc.lang)
+
+			if tc.ip != "" {
+				req.RemoteAddr = tc.ip + ":12345"
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.wantURL, rr.Header().Get("Location"))
+
+			// Сработавшее правило записывается в событие перехода
+			published := publisher.Events()
+			require.Len(t, published, 1)
+			assert.Equal(t, tc.wantURL, published[0].URL)
+			assert.Equal(t, tc.wantRule, published[0].Rule)
 		})
 	}
 }
+
+func ptr(i int) *int {
+	return &i
+}
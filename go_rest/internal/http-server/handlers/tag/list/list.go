@@ -0,0 +1,56 @@
+package list
+
+import (
+	"context"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Tags []models.Tag `json:"tags"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=TagLister
+type TagLister interface {
+	ListTags(ctx context.Context) ([]models.Tag, error)
+}
+
+// New возвращает все теги с числом ссылок у каждого
+func New(log *slog.Logger, tagLister TagLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tag.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tags, err := tagLister.ListTags(r.Context())
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list tags", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		// Пустой список отдаем как [], а не null
+		if tags == nil {
+			tags = []models.Tag{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Tags:     tags,
+		})
+	}
+}
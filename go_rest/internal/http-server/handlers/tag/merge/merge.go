@@ -0,0 +1,107 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	From []string `json:"from" validate:"required,min=1,max=20,dive,tag"`
+	To   string   `json:"to" validate:"required,tag"`
+}
+
+type Response struct {
+	resp.Response
+	// Сколько ссылок получили тег To
+	Merged int64 `json:"merged"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=TagMerger
+type TagMerger interface {
+	MergeTags(ctx context.Context, from []string, to string) (int64, error)
+}
+
+// New объединяет теги From в тег To: ссылки с любым из тегов From
+// получают тег To, а теги From удаляются
+func New(log *slog.Logger, tagMerger TagMerger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tag.merge.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		req.To = tags.Normalize([]string{req.To})[0]
+
+		if err := tags.NewValidator().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		merged, err := tagMerger.MergeTags(r.Context(), req.From, req.To)
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.InfoContext(r.Context(), "tag not found", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to merge tags", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to merge tags"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "tags merged",
+			slog.Any("from", req.From),
+			slog.String("to", req.To),
+			slog.Int64("merged", merged),
+			slog.String("actor", auth.Actor(r.Context())),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Merged:   merged,
+		})
+	}
+}
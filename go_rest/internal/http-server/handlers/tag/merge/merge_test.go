@@ -0,0 +1,87 @@
+package merge_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/tag/merge"
+	"url-shortener/internal/http-server/handlers/tag/merge/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestMergeHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		from      []string
+		to        string
+		mockError error
+		respError string
+	}{
+		{
+			name: "Success",
+			body: `{"from": ["promo", "promo-2025"], "to": "Marketing/Promo"}`,
+			from: []string{"promo", "promo-2025"},
+			to:   "marketing/promo",
+		},
+		{
+			name:      "Empty from",
+			body:      `{"from": [], "to": "promo"}`,
+			respError: "field From is not valid",
+		},
+		{
+			name:      "Invalid from tag",
+			body:      `{"from": ["bad tag"], "to": "promo"}`,
+			respError: "field From[0] is not a valid tag: use lowercase letters, digits, '-', '_' and '/'",
+		},
+		{
+			name:      "Empty to",
+			body:      `{"from": ["promo"]}`,
+			respError: "field To is a required field",
+		},
+		{
+			name:      "Tag not found",
+			body:      `{"from": ["missing"], "to": "promo"}`,
+			from:      []string{"missing"},
+			to:        "promo",
+			mockError: storage.ErrTagNotFound,
+			respError: "not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mergerMock := mocks.NewTagMerger(t)
+
+			if tc.to != "" {
+				mergerMock.On("MergeTags", mock.Anything, tc.from, tc.to).
+					Return(int64(3), tc.mockError).Once()
+			}
+
+			handler := merge.New(slogdiscard.NewDiscardLogger(), mergerMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/tags/merge", strings.NewReader(tc.body))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body merge.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, int64(3), body.Merged)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TagRenamer is an autogenerated mock type for the TagRenamer type
+type TagRenamer struct {
+	mock.Mock
+}
+
+// RenameTag provides a mock function with given fields: ctx, oldName, newName
+func (_m *TagRenamer) RenameTag(ctx context.Context, oldName string, newName string) error {
+	ret := _m.Called(ctx, oldName, newName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameTag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, oldName, newName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewTagRenamer creates a new instance of TagRenamer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTagRenamer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TagRenamer {
+	mock := &TagRenamer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,105 @@
+package rename
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	From string `json:"from" validate:"required,tag"`
+	To   string `json:"to" validate:"required,tag,nefield=From"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=TagRenamer
+type TagRenamer interface {
+	RenameTag(ctx context.Context, oldName string, newName string) error
+}
+
+// New переименовывает тег у всех ссылок. Если тег To уже существует,
+// теги нужно объединить через merge.
+func New(log *slog.Logger, tagRenamer TagRenamer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tag.rename.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		// Старое имя не нормализуем: искать нужно ровно тот тег, что сохранен
+		req.To = tags.Normalize([]string{req.To})[0]
+
+		if err := tags.NewValidator().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		err = tagRenamer.RenameTag(r.Context(), req.From, req.To)
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.InfoContext(r.Context(), "tag not found", slog.String("tag", req.From))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrTagExists) {
+			log.InfoContext(r.Context(), "tag already exists", slog.String("tag", req.To))
+
+			render.JSON(w, r, resp.Error("tag already exists, merge tags instead"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to rename tag", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to rename tag"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "tag renamed",
+			slog.String("from", req.From),
+			slog.String("to", req.To),
+			slog.String("actor", auth.Actor(r.Context())),
+		)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,83 @@
+package rename_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/tag/rename"
+	"url-shortener/internal/http-server/handlers/tag/rename/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestRenameHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		to        string
+		mockError error
+		respError string
+	}{
+		{
+			name: "Success",
+			body: `{"from": "promo", "to": " Campaigns/Promo "}`,
+			to:   "campaigns/promo",
+		},
+		{
+			name:      "Same name",
+			body:      `{"from": "promo", "to": "promo"}`,
+			respError: "field To is not valid",
+		},
+		{
+			name:      "Invalid to",
+			body:      `{"from": "promo", "to": "a//b"}`,
+			respError: "field To is not a valid tag: use lowercase letters, digits, '-', '_' and '/'",
+		},
+		{
+			name:      "Not found",
+			body:      `{"from": "promo", "to": "sale"}`,
+			to:        "sale",
+			mockError: storage.ErrTagNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "Target exists",
+			body:      `{"from": "promo", "to": "sale"}`,
+			to:        "sale",
+			mockError: storage.ErrTagExists,
+			respError: "tag already exists, merge tags instead",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			renamerMock := mocks.NewTagRenamer(t)
+
+			if tc.to != "" {
+				renamerMock.On("RenameTag", mock.Anything, "promo", tc.to).
+					Return(tc.mockError).Once()
+			}
+
+			handler := rename.New(slogdiscard.NewDiscardLogger(), renamerMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/tags/rename", strings.NewReader(tc.body))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package create
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// Пользователи и API-ключи ("apikey:<name>"), которые сразу войдут в команду
+	Members []string `json:"members,omitempty" validate:"dive,required,max=64"`
+}
+
+type Response struct {
+	resp.Response
+	Team *models.Team `json:"team,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=TeamCreator
+type TeamCreator interface {
+	CreateTeam(ctx context.Context, team models.Team) (int64, error)
+}
+
+// New создает команду
+func New(log *slog.Logger, teamCreator TeamCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.team.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		team := models.Team{
+			Name:      req.Name,
+			CreatedBy: auth.Actor(r.Context()),
+			CreatedAt: time.Now().UTC(),
+			Members:   req.Members,
+		}
+		if team.Members == nil {
+			team.Members = []string{}
+		}
+
+		team.ID, err = teamCreator.CreateTeam(r.Context(), team)
+		if errors.Is(err, storage.ErrTeamExists) {
+			log.InfoContext(r.Context(), "team already exists", slog.String("name", req.Name))
+
+			render.JSON(w, r, resp.Error("team already exists"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to create team", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to create team"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "team created",
+			slog.Int64("id", team.ID),
+			slog.String("name", team.Name),
+			slog.String("actor", team.CreatedBy),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Team:     &team,
+		})
+	}
+}
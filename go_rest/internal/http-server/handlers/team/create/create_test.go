@@ -0,0 +1,82 @@
+package create_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/team/create"
+	"url-shortener/internal/http-server/handlers/team/create/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockError error
+		respError string
+	}{
+		{
+			name: "Success",
+			body: `{"name": "growth", "members": ["alice", "apikey:ci"]}`,
+		},
+		{
+			name:      "Empty name",
+			body:      `{"members": ["alice"]}`,
+			respError: "field Name is a required field",
+		},
+		{
+			name:      "Empty member",
+			body:      `{"name": "growth", "members": [""]}`,
+			respError: "field Members[0] is a required field",
+		},
+		{
+			name:      "Team exists",
+			body:      `{"name": "growth"}`,
+			mockError: storage.ErrTeamExists,
+			respError: "team already exists",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			creatorMock := mocks.NewTeamCreator(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				creatorMock.On("CreateTeam", mock.Anything, mock.MatchedBy(func(team models.Team) bool {
+					return team.Name == "growth" && team.CreatedBy == "admin"
+				})).Return(int64(1), tc.mockError).Once()
+			}
+
+			handler := create.New(slogdiscard.NewDiscardLogger(), creatorMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/teams", strings.NewReader(tc.body))
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "admin", Role: users.RoleAdmin}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body create.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, int64(1), body.Team.ID)
+				require.Equal(t, []string{"alice", "apikey:ci"}, body.Team.Members)
+			}
+		})
+	}
+}
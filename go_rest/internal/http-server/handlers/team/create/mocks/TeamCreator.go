@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/internal/domain/models"
+)
+
+// TeamCreator is an autogenerated mock type for the TeamCreator type
+type TeamCreator struct {
+	mock.Mock
+}
+
+// CreateTeam provides a mock function with given fields: ctx, team
+func (_m *TeamCreator) CreateTeam(ctx context.Context, team models.Team) (int64, error) {
+	ret := _m.Called(ctx, team)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTeam")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Team) (int64, error)); ok {
+		return rf(ctx, team)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Team) int64); ok {
+		r0 = rf(ctx, team)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Team) error); ok {
+		r1 = rf(ctx, team)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTeamCreator creates a new instance of TeamCreator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTeamCreator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TeamCreator {
+	mock := &TeamCreator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
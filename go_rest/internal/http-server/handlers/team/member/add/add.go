@@ -0,0 +1,75 @@
+package add
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Не длиннее имени пользователя или API-ключа с префиксом "apikey:"
+const maxMemberLen = 64
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=MemberAdder
+type MemberAdder interface {
+	AddTeamMember(ctx context.Context, teamID int64, member string) error
+}
+
+// New добавляет пользователя или API-ключ ("apikey:<name>") в команду
+func New(log *slog.Logger, memberAdder MemberAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.team.member.add.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		teamID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		member := chi.URLParam(r, "member")
+		if err != nil || member == "" || len(member) > maxMemberLen {
+			log.InfoContext(r.Context(), "invalid team member",
+				slog.String("id", chi.URLParam(r, "id")), slog.String("member", member),
+			)
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		err = memberAdder.AddTeamMember(r.Context(), teamID, member)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			log.InfoContext(r.Context(), "team not found", slog.Int64("team_id", teamID))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to add team member", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to add team member"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "team member added",
+			slog.Int64("team_id", teamID),
+			slog.String("member", member),
+			slog.String("actor", auth.Actor(r.Context())),
+		)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
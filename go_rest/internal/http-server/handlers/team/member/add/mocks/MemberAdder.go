@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MemberAdder is an autogenerated mock type for the MemberAdder type
+type MemberAdder struct {
+	mock.Mock
+}
+
+// AddTeamMember provides a mock function with given fields: ctx, teamID, member
+func (_m *MemberAdder) AddTeamMember(ctx context.Context, teamID int64, member string) error {
+	ret := _m.Called(ctx, teamID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTeamMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, teamID, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMemberAdder creates a new instance of MemberAdder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMemberAdder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MemberAdder {
+	mock := &MemberAdder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,87 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "url-shortener/internal/domain/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TeamURLLister is an autogenerated mock type for the TeamURLLister type
+type TeamURLLister struct {
+	mock.Mock
+}
+
+// IsTeamMember provides a mock function with given fields: ctx, teamID, member
+func (_m *TeamURLLister) IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error) {
+	ret := _m.Called(ctx, teamID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTeamMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(ctx, teamID, member)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, teamID, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, teamID, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TeamURLs provides a mock function with given fields: ctx, teamID
+func (_m *TeamURLLister) TeamURLs(ctx context.Context, teamID int64) ([]models.URL, error) {
+	ret := _m.Called(ctx, teamID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TeamURLs")
+	}
+
+	var r0 []models.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.URL, error)); ok {
+		return rf(ctx, teamID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.URL); ok {
+		r0 = rf(ctx, teamID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, teamID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTeamURLLister creates a new instance of TeamURLLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTeamURLLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TeamURLLister {
+	mock := &TeamURLLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
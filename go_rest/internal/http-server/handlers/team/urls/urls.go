@@ -0,0 +1,97 @@
+package urls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	URLs []models.URL `json:"urls"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=TeamURLLister
+type TeamURLLister interface {
+	TeamURLs(ctx context.Context, teamID int64) ([]models.URL, error)
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// New возвращает ссылки команды. Список видят участники команды
+// и администраторы.
+func New(log *slog.Logger, lister TeamURLLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.team.urls.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		teamID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid team id", slog.String("id", chi.URLParam(r, "id")))
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		ok, err := auth.InTeam(r.Context(), lister, teamID)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to check team membership", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+		if !ok {
+			log.WarnContext(r.Context(), "team access denied",
+				slog.Int64("team_id", teamID), slog.String("actor", auth.Actor(r.Context())),
+			)
+
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		urls, err := lister.TeamURLs(r.Context(), teamID)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			log.InfoContext(r.Context(), "team not found", slog.Int64("team_id", teamID))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list team urls", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		// Пустой список отдаем как [], а не null
+		if urls == nil {
+			urls = []models.URL{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+		})
+	}
+}
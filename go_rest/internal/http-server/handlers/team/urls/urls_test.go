@@ -0,0 +1,102 @@
+package urls_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/team/urls"
+	"url-shortener/internal/http-server/handlers/team/urls/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+func TestTeamURLsHandler(t *testing.T) {
+	teamID := int64(2)
+	teamURLs := []models.URL{{Alias: "a", URL: "https://example.com", Owner: models.Owner{User: "bob", TeamID: &teamID}}}
+
+	cases := []struct {
+		name      string
+		path      string
+		user      users.User
+		member    bool
+		listErr   error
+		respError string
+		urls      []models.URL
+	}{
+		{
+			name:   "Member",
+			path:   "/teams/2/urls",
+			user:   users.User{Name: "alice", Role: users.RoleReader},
+			member: true,
+			urls:   teamURLs,
+		},
+		{
+			name:      "Not a member",
+			path:      "/teams/2/urls",
+			user:      users.User{Name: "alice", Role: users.RoleWriter},
+			respError: "forbidden",
+		},
+		{
+			name: "Admin",
+			path: "/teams/2/urls",
+			user: users.User{Name: "root", Role: users.RoleAdmin},
+			urls: teamURLs,
+		},
+		{
+			name:      "Team not found",
+			path:      "/teams/2/urls",
+			user:      users.User{Name: "root", Role: users.RoleAdmin},
+			listErr:   storage.ErrTeamNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "Invalid id",
+			path:      "/teams/abc/urls",
+			user:      users.User{Name: "alice", Role: users.RoleReader},
+			respError: "invalid request",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			listerMock := mocks.NewTeamURLLister(t)
+
+			if tc.respError != "invalid request" && tc.user.Role != users.RoleAdmin {
+				listerMock.On("IsTeamMember", mock.Anything, teamID, tc.user.Name).Return(tc.member, nil).Once()
+			}
+
+			if tc.urls != nil || tc.listErr != nil {
+				listerMock.On("TeamURLs", mock.Anything, teamID).Return(tc.urls, tc.listErr).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/teams/{id}/urls", urls.New(slogdiscard.NewDiscardLogger(), listerMock))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body urls.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, tc.urls, body.URLs)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=URLDeleter
+type URLDeleter interface {
+	URL(ctx context.Context, alias string) (models.URL, error)
+	DeleteURL(ctx context.Context, alias string) error
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// Auditor записывает изменения ссылок в журнал аудита
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=Auditor
+type Auditor interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// EventPublisher публикует события о ссылках для других сервисов
+type EventPublisher interface {
+	Publish(event events.Event) error
+}
+
+func New(log *slog.Logger, urlDeleter URLDeleter, auditor Auditor, publisher EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.InfoContext(r.Context(), "alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		// Владелец нужен для проверки прав, а старый URL - для журнала аудита
+		link, err := urlDeleter.URL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		ok, err := auth.CanEdit(r.Context(), urlDeleter, link)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to check access", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+		if !ok {
+			log.WarnContext(r.Context(), "delete denied",
+				slog.String("alias", alias), slog.String("actor", auth.Actor(r.Context())),
+			)
+
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		oldURL := link.URL
+
+		err = urlDeleter.DeleteURL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			// Ссылку успели удалить параллельным запросом
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to delete url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to delete url"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "url deleted", slog.String("alias", alias))
+
+		actor := auth.Actor(r.Context())
+
+		err = auditor.Record(r.Context(), models.AuditEvent{
+			Actor:     actor,
+			Action:    models.AuditActionDelete,
+			Alias:     alias,
+			OldURL:    oldURL,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to record audit event", sl.Err(err))
+		}
+
+		err = publisher.Publish(events.Event{
+			Type:      events.TypeLinkDeleted,
+			Alias:     alias,
+			URL:       oldURL,
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+			Time:      time.Now().UTC(),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to publish event", sl.Err(err))
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,146 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/events/memory"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/delete/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	teamID := int64(3)
+
+	cases := []struct {
+		name        string
+		alias       string
+		url         string
+		respError   string
+		owner       models.Owner
+		member      bool
+		getError    error
+		deleteError error
+	}{
+		{
+			name:  "Success",
+			alias: "test_alias",
+			url:   "https://google.com",
+		},
+		{
+			name:      "Not found",
+			alias:     "missing",
+			respError: "not found",
+			getError:  storage.ErrURLNotFound,
+		},
+		{
+			name:        "DeleteURL Error",
+			alias:       "test_alias",
+			url:         "https://google.com",
+			respError:   "failed to delete url",
+			deleteError: errors.New("unexpected error"),
+		},
+		{
+			name:  "Own link",
+			alias: "test_alias",
+			url:   "https://google.com",
+			owner: models.Owner{User: "user"},
+		},
+		{
+			name:      "Someone else's link",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			owner:     models.Owner{User: "other"},
+			respError: "forbidden",
+		},
+		{
+			name:   "Team link",
+			alias:  "test_alias",
+			url:    "https://google.com",
+			owner:  models.Owner{User: "other", TeamID: &teamID},
+			member: true,
+		},
+		{
+			name:      "Other team's link",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			owner:     models.Owner{User: "other", TeamID: &teamID},
+			respError: "forbidden",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlDeleterMock := mocks.NewURLDeleter(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			link := models.URL{Alias: tc.alias, URL: tc.url, Owner: tc.owner}
+			urlDeleterMock.On("URL", mock.Anything, tc.alias).Return(link, tc.getError).Once()
+
+			if tc.owner.TeamID != nil {
+				urlDeleterMock.On("IsTeamMember", mock.Anything, *tc.owner.TeamID, "user").Return(tc.member, nil).Once()
+			}
+
+			if tc.getError == nil && tc.respError != "forbidden" {
+				urlDeleterMock.On("DeleteURL", mock.Anything, tc.alias).Return(tc.deleteError).Once()
+			}
+
+			if tc.respError == "" {
+				auditorMock.On("Record", mock.Anything, mock.MatchedBy(func(e models.AuditEvent) bool {
+					return e.Action == models.AuditActionDelete &&
+						e.Alias == tc.alias &&
+						e.OldURL == tc.url &&
+						e.Actor == "user"
+				})).Return(nil).Once()
+			}
+
+			publisher := memory.New()
+
+			r := chi.NewRouter()
+			r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, auditorMock, publisher))
+
+			req, err := http.NewRequest(http.MethodDelete, "/url/"+tc.alias, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "user", Role: users.RoleWriter}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, []string{events.TypeLinkDeleted}, eventTypes(publisher.Events()))
+			} else {
+				require.Empty(t, publisher.Events())
+			}
+		})
+	}
+}
+
+func eventTypes(published []events.Event) []string {
+	types := make([]string, 0, len(published))
+	for _, e := range published {
+		types = append(types, e.Type)
+	}
+
+	return types
+}
@@ -0,0 +1,115 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Events []models.AuditEvent `json:"events,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=HistoryProvider
+type HistoryProvider interface {
+	URL(ctx context.Context, alias string) (models.URL, error)
+	AuditEvents(ctx context.Context, alias string) ([]models.AuditEvent, error)
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// New возвращает историю изменений ссылки. Историю видят те, кто может
+// изменять ссылку: текущий владелец, его команда и администраторы.
+// Историю ссылки без владельца видят только writer и admin, историю
+// удаленной ссылки - только администраторы.
+func New(log *slog.Logger, historyProvider HistoryProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.InfoContext(r.Context(), "alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		// Права проверяются по текущему владельцу ссылки, а не по автору
+		// создания: после передачи историю видит уже новый владелец
+		link, err := historyProvider.URL(r.Context(), alias)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			if !auth.IsAdmin(r.Context()) {
+				log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+				render.JSON(w, r, resp.Error("not found"))
+
+				return
+			}
+		case err != nil:
+			log.ErrorContext(r.Context(), "failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		default:
+			ok, err := auth.CanViewHistory(r.Context(), historyProvider, link)
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to check access", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+			if !ok {
+				log.WarnContext(r.Context(), "history access denied",
+					slog.String("alias", alias), slog.String("actor", auth.Actor(r.Context())),
+				)
+
+				render.JSON(w, r, resp.Error("forbidden"))
+
+				return
+			}
+		}
+
+		events, err := historyProvider.AuditEvents(r.Context(), alias)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get history", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		if len(events) == 0 {
+			log.InfoContext(r.Context(), "history not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Events:   events,
+		})
+	}
+}
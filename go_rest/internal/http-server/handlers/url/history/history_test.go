@@ -0,0 +1,145 @@
+package history_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/url/history"
+	"url-shortener/internal/http-server/handlers/url/history/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	const teamID int64 = 7
+
+	// alice создала ссылку и передала ее bob в команду 7
+	events := []models.AuditEvent{
+		{Actor: "alice", Action: models.AuditActionCreate, Alias: "a", NewURL: "https://example.com"},
+		{Actor: "alice", Action: models.AuditActionTransfer, Alias: "a", OldOwner: "alice", NewOwner: "bob"},
+	}
+
+	link := models.URL{
+		Alias: "a",
+		URL:   "https://example.com",
+		Owner: models.Owner{User: "bob", TeamID: ptr(teamID)},
+	}
+
+	// Ссылка, созданная до появления владельцев
+	ownerless := models.URL{Alias: "a", URL: "https://example.com"}
+
+	cases := []struct {
+		name       string
+		user       users.User
+		link       *models.URL // nil - ссылка удалена
+		teamMember *bool       // nil - членство в команде не проверяется
+		events     []models.AuditEvent
+		respError  string
+	}{
+		{
+			name:   "New owner",
+			user:   users.User{Name: "bob", Role: users.RoleReader},
+			link:   &link,
+			events: events,
+		},
+		{
+			name:       "Team member",
+			user:       users.User{Name: "carol", Role: users.RoleReader},
+			link:       &link,
+			teamMember: ptr(true),
+			events:     events,
+		},
+		{
+			name:       "Previous owner",
+			user:       users.User{Name: "alice", Role: users.RoleWriter},
+			link:       &link,
+			teamMember: ptr(false),
+			respError:  "forbidden",
+		},
+		{
+			name:   "Admin",
+			user:   users.User{Name: "root", Role: users.RoleAdmin},
+			link:   &link,
+			events: events,
+		},
+		{
+			name:      "Ownerless link, reader",
+			user:      users.User{Name: "carol", Role: users.RoleReader},
+			link:      &ownerless,
+			respError: "forbidden",
+		},
+		{
+			name:   "Ownerless link, writer",
+			user:   users.User{Name: "carol", Role: users.RoleWriter},
+			link:   &ownerless,
+			events: events,
+		},
+		{
+			name:      "Deleted link",
+			user:      users.User{Name: "bob", Role: users.RoleReader},
+			respError: "not found",
+		},
+		{
+			name:   "Deleted link, admin",
+			user:   users.User{Name: "root", Role: users.RoleAdmin},
+			events: events,
+		},
+		{
+			name:      "No history",
+			user:      users.User{Name: "root", Role: users.RoleAdmin},
+			respError: "not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			providerMock := mocks.NewHistoryProvider(t)
+
+			if tc.link != nil {
+				providerMock.On("URL", mock.Anything, "a").Return(*tc.link, nil).Once()
+			} else {
+				providerMock.On("URL", mock.Anything, "a").Return(models.URL{}, storage.ErrURLNotFound).Once()
+			}
+
+			if tc.teamMember != nil {
+				providerMock.On("IsTeamMember", mock.Anything, teamID, tc.user.Name).Return(*tc.teamMember, nil).Once()
+			}
+
+			if tc.respError != "forbidden" && (tc.link != nil || tc.user.Role == users.RoleAdmin) {
+				providerMock.On("AuditEvents", mock.Anything, "a").Return(tc.events, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/history", history.New(slogdiscard.NewDiscardLogger(), providerMock))
+
+			req, err := http.NewRequest(http.MethodGet, "/url/a/history", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var resp history.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				require.Len(t, resp.Events, len(tc.events))
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,116 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/internal/domain/models"
+)
+
+// HistoryProvider is an autogenerated mock type for the HistoryProvider type
+type HistoryProvider struct {
+	mock.Mock
+}
+
+// AuditEvents provides a mock function with given fields: ctx, alias
+func (_m *HistoryProvider) AuditEvents(ctx context.Context, alias string) ([]models.AuditEvent, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuditEvents")
+	}
+
+	var r0 []models.AuditEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.AuditEvent, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.AuditEvent); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTeamMember provides a mock function with given fields: ctx, teamID, member
+func (_m *HistoryProvider) IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error) {
+	ret := _m.Called(ctx, teamID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTeamMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(ctx, teamID, member)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, teamID, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, teamID, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// URL provides a mock function with given fields: ctx, alias
+func (_m *HistoryProvider) URL(ctx context.Context, alias string) (models.URL, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for URL")
+	}
+
+	var r0 models.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.URL, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.URL); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		r0 = ret.Get(0).(models.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewHistoryProvider creates a new instance of HistoryProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewHistoryProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *HistoryProvider {
+	mock := &HistoryProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,111 @@
+package list
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+)
+
+// Значение параметра status для выборки недоступных ссылок
+const statusBroken = "broken"
+
+type Response struct {
+	resp.Response
+	URLs []models.URL `json:"urls"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=URLLister
+type URLLister interface {
+	ListURLs(ctx context.Context) ([]models.URL, error)
+	BrokenURLs(ctx context.Context) ([]models.URL, error)
+	URLsByTag(ctx context.Context, tag string) ([]models.URL, error)
+}
+
+// New возвращает список ссылок. С параметром ?status=broken -
+// только ссылки, недоступные при последней проверке, а с ?tag=... -
+// ссылки с тегом (для папки - и из вложенных папок). Фильтры можно сочетать.
+func New(log *slog.Logger, urlLister URLLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var (
+			urls []models.URL
+			err  error
+		)
+
+		status := r.URL.Query().Get("status")
+		if status != "" && status != statusBroken {
+			log.InfoContext(r.Context(), "invalid status filter", slog.String("status", status))
+
+			render.JSON(w, r, resp.Error("invalid status"))
+
+			return
+		}
+
+		tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag")))
+		if tag != "" && !tags.Valid(tag) {
+			log.InfoContext(r.Context(), "invalid tag filter", slog.String("tag", tag))
+
+			render.JSON(w, r, resp.Error("invalid tag"))
+
+			return
+		}
+
+		switch {
+		case tag != "":
+			urls, err = urlLister.URLsByTag(r.Context(), tag)
+			if status == statusBroken {
+				urls = broken(urls)
+			}
+		case status == statusBroken:
+			urls, err = urlLister.BrokenURLs(r.Context())
+		default:
+			urls, err = urlLister.ListURLs(r.Context())
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list urls", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		// Пустой список отдаем как [], а не null
+		if urls == nil {
+			urls = []models.URL{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+		})
+	}
+}
+
+// broken оставляет только недоступные ссылки
+func broken(urls []models.URL) []models.URL {
+	var res []models.URL
+
+	for _, u := range urls {
+		if u.Broken() {
+			res = append(res, u)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,98 @@
+package list_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/list/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestListHandler(t *testing.T) {
+	all := []models.URL{
+		{Alias: "ok", URL: "https://google.com", LastStatus: http.StatusOK},
+		{Alias: "gone", URL: "https://example.com/gone", LastStatus: http.StatusNotFound},
+	}
+
+	cases := []struct {
+		name      string
+		query     string
+		mockCall  string
+		tag       string // Тег, по которому ищутся ссылки
+		urls      []models.URL
+		respError string
+	}{
+		{
+			name:     "All",
+			mockCall: "ListURLs",
+			urls:     all,
+		},
+		{
+			name:     "Broken",
+			query:    "?status=broken",
+			mockCall: "BrokenURLs",
+			urls:     all[1:],
+		},
+		{
+			name:      "Invalid status",
+			query:     "?status=unknown",
+			respError: "invalid status",
+		},
+		{
+			name:  "Tag",
+			query: "?tag=Docs",
+			tag:   "docs",
+			urls:  all,
+		},
+		{
+			name:  "Broken with tag",
+			query: "?tag=docs&status=broken",
+			tag:   "docs",
+			urls:  all[1:],
+		},
+		{
+			name:      "Invalid tag",
+			query:     "?tag=a,b",
+			respError: "invalid tag",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			listerMock := mocks.NewURLLister(t)
+
+			if tc.mockCall != "" {
+				listerMock.On(tc.mockCall, mock.Anything).Return(tc.urls, nil).Once()
+			}
+
+			if tc.tag != "" {
+				// Фильтр по статусу применяется к ссылкам с тегом
+				listerMock.On("URLsByTag", mock.Anything, tc.tag).Return(all, nil).Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), listerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, len(tc.urls), len(resp.URLs))
+			if tc.urls != nil {
+				require.Equal(t, tc.urls, resp.URLs)
+			}
+		})
+	}
+}
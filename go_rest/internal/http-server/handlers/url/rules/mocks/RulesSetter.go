@@ -0,0 +1,103 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "url-shortener/internal/domain/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RulesSetter is an autogenerated mock type for the RulesSetter type
+type RulesSetter struct {
+	mock.Mock
+}
+
+// IsTeamMember provides a mock function with given fields: ctx, teamID, member
+func (_m *RulesSetter) IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error) {
+	ret := _m.Called(ctx, teamID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTeamMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(ctx, teamID, member)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, teamID, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, teamID, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRedirectRules provides a mock function with given fields: ctx, alias, _a2
+func (_m *RulesSetter) SetRedirectRules(ctx context.Context, alias string, _a2 []models.RedirectRule) error {
+	ret := _m.Called(ctx, alias, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRedirectRules")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.RedirectRule) error); ok {
+		r0 = rf(ctx, alias, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// URL provides a mock function with given fields: ctx, alias
+func (_m *RulesSetter) URL(ctx context.Context, alias string) (models.URL, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for URL")
+	}
+
+	var r0 models.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.URL, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.URL); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		r0 = ret.Get(0).(models.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRulesSetter creates a new instance of RulesSetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRulesSetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RulesSetter {
+	mock := &RulesSetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Request заменяет все правила перенаправления ссылки.
+// Пустой список удаляет правила.
+type Request struct {
+	Rules []Rule `json:"rules" validate:"max=20,dive"`
+}
+
+// Rule - правило перенаправления, см. models.RedirectRule.
+// Языки приводятся к нижнему регистру, коды стран - к верхнему.
+type Rule struct {
+	Languages []string `json:"languages,omitempty" validate:"max=20,dive,bcp47_language_tag"`
+	Devices   []string `json:"devices,omitempty" validate:"max=3,dive,oneof=ios android desktop"`
+	Countries []string `json:"countries,omitempty" validate:"max=50,dive,iso3166_1_alpha2"`
+	URL       string   `json:"url" validate:"required,url"`
+}
+
+type Response struct {
+	resp.Response
+	Rules []models.RedirectRule `json:"rules"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=RulesSetter
+type RulesSetter interface {
+	URL(ctx context.Context, alias string) (models.URL, error)
+	SetRedirectRules(ctx context.Context, alias string, rules []models.RedirectRule) error
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// Auditor записывает изменения ссылок в журнал аудита
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=Auditor
+type Auditor interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// New заменяет правила перенаправления ссылки. Правила проверяются по порядку,
+// срабатывает первое подходящее. Менять правила может тот, кто может изменять ссылку.
+func New(log *slog.Logger, setter RulesSetter, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.InfoContext(r.Context(), "alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		redirectRules := normalize(req.Rules)
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		// Правило без условий срабатывало бы всегда и скрывало бы
+		// и остальные правила, и адрес ссылки
+		for i, rule := range redirectRules {
+			if len(rule.Languages) == 0 && len(rule.Devices) == 0 && len(rule.Countries) == 0 {
+				log.InfoContext(r.Context(), "rule without conditions", slog.Int("rule", i))
+
+				render.JSON(w, r, resp.Error(fmt.Sprintf("rule %d has no conditions", i)))
+
+				return
+			}
+		}
+
+		link, err := setter.URL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		ok, err := auth.CanEdit(r.Context(), setter, link)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to check access", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+		if !ok {
+			log.WarnContext(r.Context(), "rules change denied",
+				slog.String("alias", alias), slog.String("actor", auth.Actor(r.Context())),
+			)
+
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		err = setter.SetRedirectRules(r.Context(), alias, redirectRules)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to set redirect rules", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to set rules"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "redirect rules set",
+			slog.String("alias", alias), slog.Int("rules", len(redirectRules)),
+		)
+
+		err = auditor.Record(r.Context(), models.AuditEvent{
+			Actor:     auth.Actor(r.Context()),
+			Action:    models.AuditActionRules,
+			Alias:     alias,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to record audit event", sl.Err(err))
+		}
+
+		if redirectRules == nil {
+			redirectRules = []models.RedirectRule{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Rules:    redirectRules,
+		})
+	}
+}
+
+// normalize приводит языки к нижнему регистру, а коды стран и классы
+// устройств - к виду, в котором их сравнивает обработчик редиректа.
+// Правила из запроса изменяются на месте, чтобы валидатор проверял
+// уже нормализованные значения.
+func normalize(reqRules []Rule) []models.RedirectRule {
+	var res []models.RedirectRule
+
+	for i := range reqRules {
+		rule := &reqRules[i]
+
+		for j := range rule.Languages {
+			rule.Languages[j] = strings.ToLower(strings.TrimSpace(rule.Languages[j]))
+		}
+
+		for j := range rule.Devices {
+			rule.Devices[j] = strings.ToLower(strings.TrimSpace(rule.Devices[j]))
+		}
+
+		for j := range rule.Countries {
+			rule.Countries[j] = strings.ToUpper(strings.TrimSpace(rule.Countries[j]))
+		}
+
+		res = append(res, models.RedirectRule{
+			Languages: rule.Languages,
+			Devices:   rule.Devices,
+			Countries: rule.Countries,
+			URL:       rule.URL,
+		})
+	}
+
+	return res
+}
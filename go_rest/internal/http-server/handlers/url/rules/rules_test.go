@@ -0,0 +1,117 @@
+package rules_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/url/rules"
+	"url-shortener/internal/http-server/handlers/url/rules/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/users"
+)
+
+func TestRulesHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		owner     string
+		want      []models.RedirectRule
+		respError string
+	}{
+		{
+			name: "Success",
+			body: `{"rules": [
+				{"devices": ["iOS"], "url": "https://apps.apple.com/app"},
+				{"languages": ["ru", "EN-gb"], "countries": ["ru", "by"], "url": "https://example.ru"}]}`,
+			owner: "alice",
+			want: []models.RedirectRule{
+				{Devices: []string{"ios"}, URL: "https://apps.apple.com/app"},
+				{Languages: []string{"ru", "en-gb"}, Countries: []string{"RU", "BY"}, URL: "https://example.ru"},
+			},
+		},
+		{
+			name:  "Clear rules",
+			body:  `{"rules": []}`,
+			owner: "alice",
+		},
+		{
+			name:      "Unknown device",
+			body:      `{"rules": [{"devices": ["tv"], "url": "https://example.com"}]}`,
+			respError: "field Devices[0] is not valid",
+		},
+		{
+			name:      "Invalid country",
+			body:      `{"rules": [{"countries": ["XX"], "url": "https://example.com"}]}`,
+			respError: "field Countries[0] is not valid",
+		},
+		{
+			name:      "Invalid language",
+			body:      `{"rules": [{"languages": ["not a language"], "url": "https://example.com"}]}`,
+			respError: "field Languages[0] is not valid",
+		},
+		{
+			name:      "Invalid URL",
+			body:      `{"rules": [{"devices": ["ios"], "url": "nope"}]}`,
+			respError: "field URL is not a valid URL",
+		},
+		{
+			name:      "No conditions",
+			body:      `{"rules": [{"devices": ["ios"], "url": "https://a.example.com"}, {"url": "https://example.com"}]}`,
+			respError: "rule 1 has no conditions",
+		},
+		{
+			name:      "Foreign link",
+			body:      `{"rules": [{"devices": ["ios"], "url": "https://example.com"}]}`,
+			owner:     "bob",
+			respError: "forbidden",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setterMock := mocks.NewRulesSetter(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			if tc.owner != "" {
+				setterMock.On("URL", mock.Anything, "promo").
+					Return(models.URL{Alias: "promo", Owner: models.Owner{User: tc.owner}}, nil).Once()
+			}
+
+			if tc.respError == "" {
+				setterMock.On("SetRedirectRules", mock.Anything, "promo", tc.want).Return(nil).Once()
+				auditorMock.On("Record", mock.Anything, mock.MatchedBy(func(e models.AuditEvent) bool {
+					return e.Action == models.AuditActionRules && e.Alias == "promo" && e.Actor == "alice"
+				})).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/rules", rules.New(slogdiscard.NewDiscardLogger(), setterMock, auditorMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/url/promo/rules", strings.NewReader(tc.body))
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "alice", Role: users.RoleWriter}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body rules.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Len(t, body.Rules, len(tc.want))
+			}
+		})
+	}
+}
@@ -2,16 +2,49 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+	models "url-shortener/internal/domain/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: URL, alias
-func (_m *URLSaver) SaveURL(URL string, alias string) (int64, error) {
-	ret := _m.Called(URL, alias)
+// IsTeamMember provides a mock function with given fields: ctx, teamID, member
+func (_m *URLSaver) IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error) {
+	ret := _m.Called(ctx, teamID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTeamMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(ctx, teamID, member)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, teamID, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, teamID, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURL provides a mock function with given fields: ctx, link, passHash
+func (_m *URLSaver) SaveURL(ctx context.Context, link models.URL, passHash []byte) (int64, error) {
+	ret := _m.Called(ctx, link, passHash)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveURL")
@@ -19,17 +52,17 @@ func (_m *URLSaver) SaveURL(URL string, alias string) (int64, error) {
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int64, error)); ok {
-		return rf(URL, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, models.URL, []byte) (int64, error)); ok {
+		return rf(ctx, link, passHash)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(URL, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, models.URL, []byte) int64); ok {
+		r0 = rf(ctx, link, passHash)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(URL, alias)
+	if rf, ok := ret.Get(1).(func(context.Context, models.URL, []byte) error); ok {
+		r1 = rf(ctx, link, passHash)
 	} else {
 		r1 = ret.Error(1)
 	}
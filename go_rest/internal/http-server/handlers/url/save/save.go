@@ -1,26 +1,59 @@
 package save
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	"log/slog" // для логирования
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
 
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/http-server/middleware/auth"
 	// для краткости даем короткий алиас пакету
 	resp "url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/sl"
 	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/tags"
 	"url-shortener/internal/storage"
 )
 
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
+	// Адрес ссылки. Не указывается, если задан Destinations
+	URL   string `json:"url,omitempty" validate:"required_without=Destinations,omitempty,url"`
 	Alias string `json:"alias,omitempty"`
+	// Пароль для доступа к ссылке. bcrypt не принимает пароли длиннее 72 байт
+	Password string `json:"password,omitempty" validate:"omitempty,max=72"`
+	// Команда, которой будет принадлежать ссылка. Создатель должен в ней состоять
+	TeamID *int64 `json:"team_id,omitempty" validate:"omitempty,gt=0"`
+	// Теги приводятся к нижнему регистру, "/" разделяет папки: "marketing/2026"
+	Tags []string `json:"tags,omitempty" validate:"max=20,dive,tag"`
+	// Варианты адресов с весами для разбиения трафика (A/B-тест)
+	Destinations []Destination `json:"destinations,omitempty" validate:"omitempty,min=2,max=10,dive"`
+}
+
+type Destination struct {
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"required,min=1,max=1000"`
+}
+
+// LogValue скрывает пароль, чтобы он не попал в логи
+func (r Request) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("url", r.URL),
+		slog.String("alias", r.Alias),
+		slog.Bool("protected", r.Password != ""),
+		slog.Any("team_id", r.TeamID),
+		slog.Any("tags", r.Tags),
+		slog.Int("destinations", len(r.Destinations)),
+	)
 }
 
 type Response struct {
@@ -33,16 +66,29 @@ const aliasLength = 6
 
 //go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=URLSaver
 type URLSaver interface {
-	SaveURL(URL, alias string) (int64, error)
+	SaveURL(ctx context.Context, link models.URL, passHash []byte) (int64, error)
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// Auditor записывает изменения ссылок в журнал аудита
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=Auditor
+type Auditor interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// EventPublisher публикует события о ссылках для других сервисов
+type EventPublisher interface {
+	Publish(event events.Event) error
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+func New(log *slog.Logger, urlSaver URLSaver, auditor Auditor, publisher EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
 		// Добавляем к текущму объекту логгера поля op и request_id
 		// Они могут очень упростить нам жизнь в будущем
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -54,14 +100,14 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 		if errors.Is(err, io.EOF) {
 			// Такую ошибку встретим, если получили запрос с пустым телом
 			// Обработаем её отдельно
-			log.Error("request body is empty")
+			log.ErrorContext(r.Context(), "request body is empty")
 
 			render.JSON(w, r, resp.Error("empty request"))
 
 			return
 		}
 		if err != nil {
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 
 			render.JSON(w, r, resp.Error("failed to decode request"))
 
@@ -70,15 +116,17 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 
 		// Лучше больше логов, чем меньше - лишнее мы легко сможем почистить,
 		// при необходимости. А вот недостающую информацию мы уже не получим.
-		log.Info("request body decoded", slog.Any("req", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("req", req))
 
-		// Создаем объект валидатора
+		req.Tags = tags.Normalize(req.Tags)
+
+		// Создаем объект валидатора с правилом для тегов
 		// и передаем в него структуру, которую нужно провалидировать
-		if err := validator.New().Struct(req); err != nil {
+		if err := tags.NewValidator().Struct(req); err != nil {
 			// Приводим ошибку к типу ошибки валидации
 			validateErr := err.(validator.ValidationErrors)
 
-			log.Error("invalid request", sl.Err(err))
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
 
 			// render.JSON(w, r, resp.Error(validateErr.Error()))
 			render.JSON(w, r, resp.ValidationError(validateErr))
@@ -86,30 +134,116 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
+		if req.URL != "" && len(req.Destinations) > 0 {
+			log.InfoContext(r.Context(), "both url and destinations are set")
+
+			render.JSON(w, r, resp.Error("use either url or destinations"))
+
+			return
+		}
+
+		link := models.URL{URL: req.URL, Tags: req.Tags}
+
+		// Основным адресом ссылки (для проверки доступности
+		// и старых клиентов) считается первый вариант
+		for _, d := range req.Destinations {
+			link.Destinations = append(link.Destinations, models.Destination{URL: d.URL, Weight: d.Weight})
+		}
+		if len(link.Destinations) > 0 {
+			link.URL = link.Destinations[0].URL
+		}
+
+		if req.TeamID != nil {
+			ok, err := auth.InTeam(r.Context(), urlSaver, *req.TeamID)
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to check team membership", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to add url"))
+
+				return
+			}
+			if !ok {
+				log.WarnContext(r.Context(), "not a team member", slog.Int64("team_id", *req.TeamID))
+
+				render.JSON(w, r, resp.Error("forbidden"))
+
+				return
+			}
+		}
+
+		actor := auth.Actor(r.Context())
+
 		alias := req.Alias
 		if alias == "" {
 			alias = random.NewRandomString(aliasLength)
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
+		// Пароль храним только в виде bcrypt-хэша
+		var passHash []byte
+		if req.Password != "" {
+			passHash, err = bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to hash password", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to add url"))
+
+				return
+			}
+		}
+
+		link.Alias = alias
+		link.Owner = models.Owner{User: actor, TeamID: req.TeamID}
+
+		id, err := urlSaver.SaveURL(r.Context(), link, passHash)
 		if errors.Is(err, storage.ErrURLExists) {
 			// Отдельно обрабатываем ситуацию,
 			// когда запись с таким Alias уже существует
-			log.Info("url already exists", slog.String("url", req.URL))
+			log.InfoContext(r.Context(), "url already exists", slog.String("url", link.URL))
 
 			render.JSON(w, r, resp.Error("url already exists"))
 
 			return
 		}
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			log.InfoContext(r.Context(), "team not found", slog.Int64("team_id", *req.TeamID))
+
+			render.JSON(w, r, resp.Error("team not found"))
+
+			return
+		}
 		if err != nil {
-			log.Error("failed to add url", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to add url", sl.Err(err))
 
 			render.JSON(w, r, resp.Error("failed to add url"))
 
 			return
 		}
 
-		log.Info("url added", slog.Int64("id", id))
+		log.InfoContext(r.Context(), "url added", slog.Int64("id", id))
+
+		err = auditor.Record(r.Context(), models.AuditEvent{
+			Actor:     actor,
+			Action:    models.AuditActionCreate,
+			Alias:     alias,
+			NewURL:    link.URL,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			// Ссылка уже сохранена, поэтому запрос не проваливаем
+			log.ErrorContext(r.Context(), "failed to record audit event", sl.Err(err))
+		}
+
+		err = publisher.Publish(events.Event{
+			Type:      events.TypeLinkCreated,
+			Alias:     alias,
+			URL:       link.URL,
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+			Time:      time.Now().UTC(),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to publish event", sl.Err(err))
+		}
 
 		responseOK(w, r, alias)
 	}
@@ -4,26 +4,39 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/events"
+	"url-shortener/internal/events/memory"
 	"url-shortener/internal/http-server/handlers/url/save"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
 )
 
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
-		name      string // Имя теста
-		alias     string // Отправляемый alias
-		url       string // Отправляемый URL
-		respError string // Какую ошибку мы должны получить?
-		mockError error  // Ошибку, которую вернёт мок
+		name      string   // Имя теста
+		alias     string   // Отправляемый alias
+		url       string   // Отправляемый URL
+		password  string   // Отправляемый пароль
+		respError string   // Какую ошибку мы должны получить?
+		mockError error    // Ошибку, которую вернёт мок
+		teamID    int64    // Команда, которой принадлежит ссылка
+		member    bool     // Состоит ли пользователь в команде
+		tags      []string // Отправляемые теги
+		wantTags  []string // Теги, которые уйдут в хранилище
 	}{
 		{
 			name:  "Success",
@@ -47,6 +60,19 @@ func TestSaveHandler(t *testing.T) {
 			alias:     "some_alias",
 			respError: "field URL is not a valid URL",
 		},
+		{
+			name:     "With password",
+			alias:    "protected_alias",
+			url:      "https://google.com",
+			password: "secret",
+		},
+		{
+			name:      "Too long password",
+			alias:     "protected_alias",
+			url:       "https://google.com",
+			password:  strings.Repeat("a", 73),
+			respError: "field Password is too long",
+		},
 		{
 			name:      "SaveURL Error",
 			alias:     "test_alias",
@@ -54,6 +80,50 @@ func TestSaveHandler(t *testing.T) {
 			respError: "failed to add url",
 			mockError: errors.New("unexpected error"),
 		},
+		{
+			name:   "Team link",
+			alias:  "team_alias",
+			url:    "https://google.com",
+			teamID: 7,
+			member: true,
+		},
+		{
+			name:      "Not a team member",
+			alias:     "team_alias",
+			url:       "https://google.com",
+			teamID:    7,
+			respError: "forbidden",
+		},
+		{
+			name:      "Team not found",
+			alias:     "team_alias",
+			url:       "https://google.com",
+			teamID:    7,
+			member:    true,
+			respError: "team not found",
+			mockError: storage.ErrTeamNotFound,
+		},
+		{
+			name:     "With tags",
+			alias:    "tagged",
+			url:      "https://google.com",
+			tags:     []string{" Go ", "news/IT", "go"},
+			wantTags: []string{"go", "news/it"},
+		},
+		{
+			name:      "Invalid tag",
+			alias:     "tagged",
+			url:       "https://google.com",
+			tags:      []string{"go", "with space"},
+			respError: "field Tags[1] is not a valid tag: use lowercase letters, digits, '-', '_' and '/'",
+		},
+		{
+			name:      "Too many tags",
+			alias:     "tagged",
+			url:       "https://google.com",
+			tags:      strings.Split("a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,p,q,r,s,t,u", ","),
+			respError: "field Tags has more than 20 items",
+		},
 	}
 
 	for _, tc := range cases {
@@ -61,25 +131,56 @@ func TestSaveHandler(t *testing.T) {
 			// Создаем объект мока стораджа
 			urlSaverMock := mocks.NewURLSaver(t)
 
+			owner := models.Owner{User: "user"}
+			if tc.teamID != 0 {
+				owner.TeamID = &tc.teamID
+
+				urlSaverMock.On("IsTeamMember", mock.Anything, tc.teamID, "user").Return(tc.member, nil).Once()
+			}
+
 			// Если ожидается успешный ответ, значит к моку точно будет вызов
 			// Либо даже если в ответе ожидаем ошибку,
 			// но мок должен ответить с ошибкой, к нему тоже будет запрос:
 			if tc.respError == "" || tc.mockError != nil {
+				// Владелец - пользователь, создавший ссылку
 				// Сообщаем моку, какой к нему будет запрос, и что надо вернуть
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(link models.URL) bool {
+					return link.URL == tc.url && link.Alias != "" &&
+						reflect.DeepEqual(link.Owner, owner) && reflect.DeepEqual(link.Tags, tc.wantTags)
+				}), mock.MatchedBy(passHashMatcher(tc.password))).
 					Return(int64(1), tc.mockError).
 					Once() // Запрос будет ровно один
 			}
 
+			// Успешное сохранение попадает в журнал аудита
+			auditorMock := mocks.NewAuditor(t)
+			if tc.respError == "" {
+				auditorMock.On("Record", mock.Anything, mock.MatchedBy(func(e models.AuditEvent) bool {
+					return e.Action == models.AuditActionCreate && e.NewURL == tc.url && e.Actor == "user"
+				})).Return(nil).Once()
+			}
+
 			// Создаем наш хэндлер
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+			publisher := memory.New()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, auditorMock, publisher)
 
 			// Формируем тело запроса
-			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
+			fields := map[string]any{"url": tc.url, "alias": tc.alias, "password": tc.password}
+			if tc.teamID != 0 {
+				fields["team_id"] = tc.teamID
+			}
+			if tc.tags != nil {
+				fields["tags"] = tc.tags
+			}
+
+			input, err := json.Marshal(fields)
+			require.NoError(t, err)
 
 			// Создаем объект запроса
-			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(input))
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "user", Role: users.RoleWriter}))
 
 			// Создаем ResponseRecorder для записи ответа хэндлера
 			rr := httptest.NewRecorder()
@@ -99,7 +200,108 @@ func TestSaveHandler(t *testing.T) {
 			// Проверяем наличие требуемой ошибки в ответе
 			require.Equal(t, tc.respError, resp.Error)
 
+			// Событие публикуется только для сохраненной ссылки
+			if tc.respError == "" {
+				published := publisher.Events()
+				require.Len(t, published, 1)
+				require.Equal(t, events.TypeLinkCreated, published[0].Type)
+				require.Equal(t, resp.Alias, published[0].Alias)
+			} else {
+				require.Empty(t, publisher.Events())
+			}
+
 			// TODO: add more checks
 		})
 	}
 }
+
+func TestSaveHandler_Destinations(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		respError string
+	}{
+		{
+			name: "Success",
+			body: `{"alias": "ab", "destinations": [
+				{"url": "https://a.example.com", "weight": 70},
+				{"url": "https://b.example.com", "weight": 30}]}`,
+		},
+		{
+			name: "Both url and destinations",
+			body: `{"alias": "ab", "url": "https://a.example.com", "destinations": [
+				{"url": "https://a.example.com", "weight": 70},
+				{"url": "https://b.example.com", "weight": 30}]}`,
+			respError: "use either url or destinations",
+		},
+		{
+			name:      "Single destination",
+			body:      `{"alias": "ab", "destinations": [{"url": "https://a.example.com", "weight": 1}]}`,
+			respError: "field Destinations is not valid",
+		},
+		{
+			name: "Zero weight",
+			body: `{"alias": "ab", "destinations": [
+				{"url": "https://a.example.com", "weight": 0},
+				{"url": "https://b.example.com", "weight": 30}]}`,
+			respError: "field Weight is a required field",
+		},
+		{
+			name: "Invalid destination URL",
+			body: `{"alias": "ab", "destinations": [
+				{"url": "not a url", "weight": 1},
+				{"url": "https://b.example.com", "weight": 1}]}`,
+			respError: "field URL is not a valid URL",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlSaverMock := mocks.NewURLSaver(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			if tc.respError == "" {
+				// Основной адрес ссылки - первый вариант
+				urlSaverMock.On("SaveURL", mock.Anything, models.URL{
+					Alias: "ab",
+					URL:   "https://a.example.com",
+					Owner: models.Owner{User: "user"},
+					Destinations: []models.Destination{
+						{URL: "https://a.example.com", Weight: 70},
+						{URL: "https://b.example.com", Weight: 30},
+					},
+				}, []byte(nil)).Return(int64(1), nil).Once()
+
+				auditorMock.On("Record", mock.Anything, mock.MatchedBy(func(e models.AuditEvent) bool {
+					return e.NewURL == "https://a.example.com"
+				})).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, auditorMock, memory.New())
+
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(tc.body))
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "user", Role: users.RoleWriter}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+// passHashMatcher проверяет, что в хранилище уходит хэш пароля, а не сам пароль
+func passHashMatcher(password string) func([]byte) bool {
+	return func(passHash []byte) bool {
+		if password == "" {
+			return passHash == nil
+		}
+
+		return bcrypt.CompareHashAndPassword(passHash, []byte(password)) == nil
+	}
+}
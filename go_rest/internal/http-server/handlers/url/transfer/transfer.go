@@ -0,0 +1,190 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Request задает нового владельца ссылки. Если Owner не указан,
+// владелец не меняется. Команда всегда заменяется на TeamID:
+// без него ссылка перестает принадлежать команде.
+type Request struct {
+	Owner  string `json:"owner,omitempty" validate:"omitempty,max=64"`
+	TeamID *int64 `json:"team_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+type Response struct {
+	resp.Response
+	models.Owner
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=URLTransferer
+type URLTransferer interface {
+	URL(ctx context.Context, alias string) (models.URL, error)
+	TransferURL(ctx context.Context, alias string, owner models.Owner) error
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// Auditor записывает изменения ссылок в журнал аудита
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=Auditor
+type Auditor interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// New передает ссылку другому пользователю или команде. Передать ссылку
+// может тот, кто может ее изменять, и только в команду, в которой состоит сам.
+func New(log *slog.Logger, transferer URLTransferer, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.transfer.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.InfoContext(r.Context(), "alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.ErrorContext(r.Context(), "request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		link, err := transferer.URL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		ok, err := auth.CanEdit(r.Context(), transferer, link)
+		if err == nil && ok && req.TeamID != nil {
+			ok, err = auth.InTeam(r.Context(), transferer, *req.TeamID)
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to check access", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+		if !ok {
+			log.WarnContext(r.Context(), "transfer denied",
+				slog.String("alias", alias), slog.String("actor", auth.Actor(r.Context())),
+			)
+
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		owner := models.Owner{User: req.Owner, TeamID: req.TeamID}
+		if owner.User == "" {
+			owner.User = link.Owner.User
+		}
+		if owner.User == "" {
+			// У ссылки без владельца им становится тот, кто ее передает
+			owner.User = auth.Actor(r.Context())
+		}
+
+		err = transferer.TransferURL(r.Context(), alias, owner)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.InfoContext(r.Context(), "url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.Error("not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			log.InfoContext(r.Context(), "team not found", slog.Int64("team_id", *req.TeamID))
+
+			render.JSON(w, r, resp.Error("team not found"))
+
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to transfer url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to transfer url"))
+
+			return
+		}
+
+		log.InfoContext(r.Context(), "url transferred",
+			slog.String("alias", alias),
+			slog.String("old_owner", link.Owner.String()),
+			slog.String("new_owner", owner.String()),
+		)
+
+		err = auditor.Record(r.Context(), models.AuditEvent{
+			Actor:     auth.Actor(r.Context()),
+			Action:    models.AuditActionTransfer,
+			Alias:     alias,
+			OldOwner:  link.Owner.String(),
+			NewOwner:  owner.String(),
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to record audit event", sl.Err(err))
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Owner:    owner,
+		})
+	}
+}
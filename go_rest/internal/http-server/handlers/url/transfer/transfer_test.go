@@ -0,0 +1,123 @@
+package transfer_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/handlers/url/transfer"
+	"url-shortener/internal/http-server/handlers/url/transfer/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+func TestTransferHandler(t *testing.T) {
+	teamID := int64(5)
+
+	cases := []struct {
+		name        string
+		body        string
+		owner       models.Owner // Текущий владелец
+		member      bool         // Состоит ли пользователь в целевой команде
+		newOwner    models.Owner // Ожидаемый владелец после передачи
+		transferErr error
+		respError   string
+	}{
+		{
+			name:     "To user",
+			body:     `{"owner": "bob"}`,
+			owner:    models.Owner{User: "user"},
+			newOwner: models.Owner{User: "bob"},
+		},
+		{
+			name:     "To own team",
+			body:     `{"team_id": 5}`,
+			owner:    models.Owner{User: "user"},
+			member:   true,
+			newOwner: models.Owner{User: "user", TeamID: &teamID},
+		},
+		{
+			name:      "To foreign team",
+			body:      `{"team_id": 5}`,
+			owner:     models.Owner{User: "user"},
+			respError: "forbidden",
+		},
+		{
+			name:      "Someone else's link",
+			body:      `{"owner": "user"}`,
+			owner:     models.Owner{User: "bob"},
+			respError: "forbidden",
+		},
+		{
+			name:        "Team not found",
+			body:        `{"team_id": 5}`,
+			owner:       models.Owner{User: "user"},
+			member:      true,
+			newOwner:    models.Owner{User: "user", TeamID: &teamID},
+			transferErr: storage.ErrTeamNotFound,
+			respError:   "team not found",
+		},
+		{
+			name:      "Invalid team",
+			body:      `{"team_id": -1}`,
+			respError: "field TeamID is not valid",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transfererMock := mocks.NewURLTransferer(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			if !strings.Contains(tc.respError, "field") {
+				transfererMock.On("URL", mock.Anything, "abc").
+					Return(models.URL{Alias: "abc", Owner: tc.owner}, nil).Once()
+			}
+
+			if strings.Contains(tc.body, "team_id") && tc.owner.User == "user" {
+				transfererMock.On("IsTeamMember", mock.Anything, teamID, "user").Return(tc.member, nil).Once()
+			}
+
+			if tc.respError == "" || tc.transferErr != nil {
+				transfererMock.On("TransferURL", mock.Anything, "abc", tc.newOwner).Return(tc.transferErr).Once()
+			}
+
+			if tc.respError == "" {
+				auditorMock.On("Record", mock.Anything, mock.MatchedBy(func(e models.AuditEvent) bool {
+					return e.Action == models.AuditActionTransfer &&
+						e.OldOwner == tc.owner.String() &&
+						e.NewOwner == tc.newOwner.String()
+				})).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/transfer", transfer.New(slogdiscard.NewDiscardLogger(), transfererMock, auditorMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/abc/transfer", strings.NewReader(tc.body))
+			req = req.WithContext(auth.WithUser(req.Context(), users.User{Name: "user", Role: users.RoleWriter}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body transfer.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, tc.newOwner, body.Owner)
+			}
+		})
+	}
+}
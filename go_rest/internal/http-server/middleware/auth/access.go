@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/users"
+)
+
+// TeamMembership проверяет, состоит ли участник в команде
+type TeamMembership interface {
+	IsTeamMember(ctx context.Context, teamID int64, member string) (bool, error)
+}
+
+// IsAdmin сообщает, что запрос выполняет пользователь с ролью admin.
+// API-ключи администраторами не считаются.
+func IsAdmin(ctx context.Context) bool {
+	if _, ok := APIKeyFromContext(ctx); ok {
+		return false
+	}
+
+	user, ok := UserFromContext(ctx)
+
+	return ok && user.Role.Allows(users.RoleAdmin)
+}
+
+// InTeam сообщает, что текущий пользователь (или API-ключ) может работать
+// от имени команды: состоит в ней или является администратором
+func InTeam(ctx context.Context, teams TeamMembership, teamID int64) (bool, error) {
+	if IsAdmin(ctx) {
+		return true, nil
+	}
+
+	return teams.IsTeamMember(ctx, teamID, Actor(ctx))
+}
+
+// CanEdit сообщает, что текущий пользователь может изменять ссылку:
+// администраторы - любую, остальные - свою или ссылку своей команды.
+// Ссылки без владельца, созданные до его появления, может изменять любой,
+// у кого есть право на запись.
+func CanEdit(ctx context.Context, teams TeamMembership, link models.URL) (bool, error) {
+	if IsAdmin(ctx) || link.Owner.User == "" || link.Owner.User == Actor(ctx) {
+		return true, nil
+	}
+
+	if link.TeamID == nil {
+		return false, nil
+	}
+
+	return teams.IsTeamMember(ctx, *link.TeamID, Actor(ctx))
+}
+
+// CanViewHistory сообщает, что текущий пользователь может смотреть историю
+// ссылки: ее видят те, кто может ссылку изменять. В истории есть авторы
+// изменений и прежние адреса, поэтому у ссылок без владельца ее видят
+// только пользователи и API-ключи с правом на запись, а не любой читатель.
+func CanViewHistory(ctx context.Context, teams TeamMembership, link models.URL) (bool, error) {
+	if link.Owner.User == "" {
+		return allowed(ctx, users.RoleWriter, models.ScopeCreate), nil
+	}
+
+	return CanEdit(ctx, teams, link)
+}
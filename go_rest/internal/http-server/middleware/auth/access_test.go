@@ -0,0 +1,140 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/users"
+)
+
+// teams - участники команд по ID
+type teams map[int64][]string
+
+func (t teams) IsTeamMember(_ context.Context, teamID int64, member string) (bool, error) {
+	for _, m := range t[teamID] {
+		if m == member {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestCanEdit(t *testing.T) {
+	teamID := int64(1)
+	membership := teams{teamID: {"alice", "apikey:ci"}}
+
+	asUser := func(name string, role users.Role) context.Context {
+		return auth.WithUser(context.Background(), users.User{Name: name, Role: role})
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		link models.URL
+		want bool
+	}{
+		{
+			name: "Owner",
+			ctx:  asUser("bob", users.RoleWriter),
+			link: models.URL{Owner: models.Owner{User: "bob"}},
+			want: true,
+		},
+		{
+			name: "Stranger",
+			ctx:  asUser("bob", users.RoleWriter),
+			link: models.URL{Owner: models.Owner{User: "carol"}},
+		},
+		{
+			name: "Team member",
+			ctx:  asUser("alice", users.RoleWriter),
+			link: models.URL{Owner: models.Owner{User: "carol", TeamID: &teamID}},
+			want: true,
+		},
+		{
+			name: "Not a team member",
+			ctx:  asUser("bob", users.RoleWriter),
+			link: models.URL{Owner: models.Owner{User: "carol", TeamID: &teamID}},
+		},
+		{
+			name: "Team API key",
+			ctx:  auth.WithAPIKey(context.Background(), models.APIKey{Name: "ci"}),
+			link: models.URL{Owner: models.Owner{User: "carol", TeamID: &teamID}},
+			want: true,
+		},
+		{
+			name: "Admin",
+			ctx:  asUser("root", users.RoleAdmin),
+			link: models.URL{Owner: models.Owner{User: "carol"}},
+			want: true,
+		},
+		{
+			name: "Link without owner",
+			ctx:  asUser("bob", users.RoleWriter),
+			link: models.URL{},
+			want: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := auth.CanEdit(tc.ctx, membership, tc.link)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ok)
+		})
+	}
+}
+
+func TestCanViewHistory(t *testing.T) {
+	asUser := func(name string, role users.Role) context.Context {
+		return auth.WithUser(context.Background(), users.User{Name: name, Role: role})
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		link models.URL
+		want bool
+	}{
+		{
+			name: "Owner, reader",
+			ctx:  asUser("bob", users.RoleReader),
+			link: models.URL{Owner: models.Owner{User: "bob"}},
+			want: true,
+		},
+		{
+			name: "Link without owner, reader",
+			ctx:  asUser("bob", users.RoleReader),
+			link: models.URL{},
+		},
+		{
+			name: "Link without owner, writer",
+			ctx:  asUser("bob", users.RoleWriter),
+			link: models.URL{},
+			want: true,
+		},
+		{
+			name: "Link without owner, read-only API key",
+			ctx:  auth.WithAPIKey(context.Background(), models.APIKey{Name: "stats", Scopes: []string{models.ScopeReadStats}}),
+			link: models.URL{},
+		},
+		{
+			name: "Link without owner, API key with create scope",
+			ctx:  auth.WithAPIKey(context.Background(), models.APIKey{Name: "ci", Scopes: []string{models.ScopeCreate}}),
+			link: models.URL{},
+			want: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := auth.CanViewHistory(tc.ctx, teams{}, tc.link)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ok)
+		})
+	}
+}
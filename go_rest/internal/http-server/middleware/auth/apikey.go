@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/domain/models"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type apiKeyCtxKey struct{}
+
+// Время последнего использования обновляется не чаще этого интервала,
+// чтобы не писать в БД на каждый запрос
+const touchInterval = time.Minute
+
+// KeyStore ищет API-ключи и отмечает их использование
+type KeyStore interface {
+	APIKeyByPrefix(ctx context.Context, prefix string) (models.APIKey, []byte, error)
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// APIKey проверяет ключ из заголовка "Authorization: Bearer <key>" или
+// "X-API-Key" и кладет его в контекст запроса. Запросы без ключа
+// пропускаются дальше, например в BasicAuth.
+func APIKey(log *slog.Logger, store KeyStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/apikey"))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key, ok := keyFromRequest(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log := log.With(slog.String("request_id", middleware.GetReqID(r.Context())))
+
+			apiKey, err := lookupKey(r.Context(), store, key)
+			if err != nil {
+				if errors.Is(err, errInvalidKey) {
+					log.WarnContext(r.Context(), "invalid api key", slog.String("prefix", apiKey.Prefix))
+				} else {
+					log.ErrorContext(r.Context(), "failed to check api key", sl.Err(err))
+				}
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("unauthorized"))
+
+				return
+			}
+
+			now := time.Now()
+			if apiKey.Expired(now) {
+				log.WarnContext(r.Context(), "api key expired", slog.String("prefix", apiKey.Prefix))
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("api key expired"))
+
+				return
+			}
+
+			if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= touchInterval {
+				if err := store.TouchAPIKey(r.Context(), apiKey.ID, now); err != nil {
+					// Ключ верный, поэтому запрос не проваливаем
+					log.ErrorContext(r.Context(), "failed to update api key last use", sl.Err(err))
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), apiKey)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+var errInvalidKey = errors.New("invalid api key")
+
+func lookupKey(ctx context.Context, store KeyStore, key string) (models.APIKey, error) {
+	prefix, ok := apikey.Prefix(key)
+	if !ok {
+		return models.APIKey{}, errInvalidKey
+	}
+
+	apiKey, hash, err := store.APIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return models.APIKey{Prefix: prefix}, errInvalidKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	if !apikey.Verify(key, hash) {
+		return models.APIKey{Prefix: prefix}, errInvalidKey
+	}
+
+	return apiKey, nil
+}
+
+func keyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+		return strings.TrimSpace(token), true
+	}
+
+	return "", false
+}
+
+// WithAPIKey возвращает контекст с проверенным API-ключом
+func WithAPIKey(ctx context.Context, key models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyCtxKey{}, key)
+}
+
+// APIKeyFromContext возвращает ключ, которым аутентифицирован запрос
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(models.APIKey)
+
+	return key, ok
+}
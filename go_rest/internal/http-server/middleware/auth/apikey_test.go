@@ -0,0 +1,124 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/users"
+)
+
+// keyStore - хранилище ключей в памяти
+type keyStore struct {
+	keys    map[string]models.APIKey
+	hashes  map[string][]byte
+	touched map[int64]bool
+}
+
+func (s *keyStore) add(t *testing.T, key models.APIKey) string {
+	t.Helper()
+
+	raw, prefix, err := apikey.Generate()
+	require.NoError(t, err)
+
+	key.Prefix = prefix
+	s.keys[prefix] = key
+	s.hashes[prefix] = apikey.Hash(raw)
+
+	return raw
+}
+
+func (s *keyStore) APIKeyByPrefix(_ context.Context, prefix string) (models.APIKey, []byte, error) {
+	key, ok := s.keys[prefix]
+	if !ok {
+		return models.APIKey{}, nil, storage.ErrAPIKeyNotFound
+	}
+
+	return key, s.hashes[prefix], nil
+}
+
+func (s *keyStore) TouchAPIKey(_ context.Context, id int64, _ time.Time) error {
+	s.touched[id] = true
+
+	return nil
+}
+
+func TestAPIKey(t *testing.T) {
+	store := &keyStore{
+		keys:    map[string]models.APIKey{},
+		hashes:  map[string][]byte{},
+		touched: map[int64]bool{},
+	}
+
+	past := time.Now().Add(-time.Hour)
+
+	ciKey := store.add(t, models.APIKey{ID: 1, Name: "ci", Scopes: []string{models.ScopeCreate}})
+	expiredKey := store.add(t, models.APIKey{ID: 2, Name: "old", Scopes: []string{models.ScopeCreate}, ExpiresAt: &past})
+
+	apiUsers, err := users.FromList(nil)
+	require.NoError(t, err)
+
+	var actor string
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		actor = auth.Actor(r.Context())
+	}
+
+	r := chi.NewRouter()
+	r.Use(auth.APIKey(slogdiscard.NewDiscardLogger(), store))
+	r.Use(auth.BasicAuth(slogdiscard.NewDiscardLogger(), "test", apiUsers))
+	r.With(auth.Require(users.RoleWriter, models.ScopeCreate)).Post("/url", ok)
+	r.With(auth.Require(users.RoleWriter, models.ScopeDelete)).Delete("/url", ok)
+	r.With(auth.RequireRole(users.RoleAdmin)).Get("/admin", ok)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		code   int
+	}{
+		{name: "Bearer", method: http.MethodPost, path: "/url", header: "Authorization", value: "Bearer " + ciKey, code: http.StatusOK},
+		{name: "X-API-Key", method: http.MethodPost, path: "/url", header: "X-API-Key", value: ciKey, code: http.StatusOK},
+		{name: "Missing scope", method: http.MethodDelete, path: "/url", header: "X-API-Key", value: ciKey, code: http.StatusForbidden},
+		{name: "Admin route", method: http.MethodGet, path: "/admin", header: "X-API-Key", value: ciKey, code: http.StatusForbidden},
+		{name: "Expired", method: http.MethodPost, path: "/url", header: "X-API-Key", value: expiredKey, code: http.StatusUnauthorized},
+		{name: "Wrong secret", method: http.MethodPost, path: "/url", header: "X-API-Key", value: ciKey + "x", code: http.StatusUnauthorized},
+		{name: "Malformed", method: http.MethodPost, path: "/url", header: "Authorization", value: "Bearer garbage", code: http.StatusUnauthorized},
+		{name: "No key", method: http.MethodPost, path: "/url", code: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actor = ""
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+
+			if tc.code == http.StatusOK {
+				require.Equal(t, "apikey:ci", actor)
+			}
+		})
+	}
+
+	require.True(t, store.touched[1])
+	require.False(t, store.touched[2])
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"log/slog" // для логирования
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/users"
+)
+
+type ctxKey struct{}
+
+// Authenticator проверяет логин и пароль
+type Authenticator interface {
+	Authenticate(name string, password string) (users.User, bool)
+}
+
+// BasicAuth проверяет креды из заголовка Authorization и кладет
+// пользователя в контекст запроса. Запросы, уже аутентифицированные
+// API-ключом, пропускаются без проверки.
+func BasicAuth(log *slog.Logger, realm string, authenticator Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/auth"))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := APIKeyFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			name, password, ok := r.BasicAuth()
+			if !ok {
+				unauthorized(w, r, realm)
+
+				return
+			}
+
+			user, ok := authenticator.Authenticate(name, password)
+			if !ok {
+				log.WarnContext(r.Context(), "authentication failed",
+					slog.String("user", name),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				unauthorized(w, r, realm)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireRole пропускает только пользователей с ролью не ниже required.
+// API-ключи не пропускаются.
+func RequireRole(required users.Role) func(next http.Handler) http.Handler {
+	return Require(required, "")
+}
+
+// Require пропускает пользователей с ролью не ниже role и API-ключи
+// с правом scope
+func Require(role users.Role, scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !allowed(r.Context(), role, scope) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("forbidden"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func allowed(ctx context.Context, role users.Role, scope string) bool {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return scope != "" && key.HasScope(scope)
+	}
+
+	user, ok := UserFromContext(ctx)
+
+	return ok && user.Role.Allows(role)
+}
+
+// WithUser возвращает контекст с аутентифицированным пользователем
+func WithUser(ctx context.Context, user users.User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, user)
+}
+
+// UserFromContext возвращает пользователя, которого положил BasicAuth
+func UserFromContext(ctx context.Context) (users.User, bool) {
+	user, ok := ctx.Value(ctxKey{}).(users.User)
+
+	return user, ok
+}
+
+// Actor возвращает имя пользователя (или API-ключа) для журналов и событий
+func Actor(ctx context.Context) string {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return "apikey:" + key.Name
+	}
+
+	user, _ := UserFromContext(ctx)
+
+	return user.Name
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, realm string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
+
+	render.Status(r, http.StatusUnauthorized)
+	render.JSON(w, r, resp.Error("unauthorized"))
+}
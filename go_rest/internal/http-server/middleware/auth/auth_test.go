@@ -0,0 +1,74 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/users"
+)
+
+func TestBasicAuthRoles(t *testing.T) {
+	hash := func(password string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		return string(h)
+	}
+
+	apiUsers, err := users.FromList([]users.User{
+		{Name: "reader", PasswordHash: hash("r"), Role: users.RoleReader},
+		{Name: "writer", PasswordHash: hash("w"), Role: users.RoleWriter},
+		{Name: "admin", PasswordHash: hash("a"), Role: users.RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		require.NotEmpty(t, auth.Actor(r.Context()))
+	}
+
+	r := chi.NewRouter()
+	r.Use(auth.BasicAuth(slogdiscard.NewDiscardLogger(), "test", apiUsers))
+	r.With(auth.RequireRole(users.RoleReader)).Get("/url", ok)
+	r.With(auth.RequireRole(users.RoleWriter)).Delete("/url", ok)
+
+	cases := []struct {
+		name     string
+		method   string
+		user     string
+		password string
+		code     int
+	}{
+		{name: "No credentials", method: http.MethodGet, code: http.StatusUnauthorized},
+		{name: "Wrong password", method: http.MethodGet, user: "reader", password: "x", code: http.StatusUnauthorized},
+		{name: "Unknown user", method: http.MethodGet, user: "nobody", password: "r", code: http.StatusUnauthorized},
+		{name: "Reader can list", method: http.MethodGet, user: "reader", password: "r", code: http.StatusOK},
+		{name: "Reader cannot delete", method: http.MethodDelete, user: "reader", password: "r", code: http.StatusForbidden},
+		{name: "Writer can delete", method: http.MethodDelete, user: "writer", password: "w", code: http.StatusOK},
+		{name: "Admin can delete", method: http.MethodDelete, user: "admin", password: "a", code: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/url", nil)
+			if tc.user != "" {
+				req.SetBasicAuth(tc.user, tc.password)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+
+			if tc.code == http.StatusUnauthorized {
+				require.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
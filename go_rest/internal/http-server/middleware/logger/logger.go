@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"net"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"log/slog" // для логирования
@@ -9,6 +11,9 @@ import (
 	"github.com/go-chi/chi/v5/middleware" // для обработки HTTP-запросов
 )
 
+// Длинные User-Agent обрезаются: в них бывает что угодно, вплоть до токенов
+const maxUserAgentLen = 200
+
 func New(log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		log = log.With(
@@ -23,8 +28,8 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 			entry := log.With(
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
-				slog.String("remote_addr", r.RemoteAddr),
-				slog.String("user_agent", r.UserAgent()),
+				slog.String("remote_addr", anonymizeAddr(r.RemoteAddr)),
+				slog.String("user_agent", truncate(r.UserAgent(), maxUserAgentLen)),
 				slog.String("request_id", middleware.GetReqID(r.Context())),
 			)
 
@@ -38,7 +43,7 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 			// Запись отправится в лог в defer
 			// в этот момент запрос уже будет обработан
 			defer func() {
-				entry.Info("request completed",
+				entry.InfoContext(r.Context(), "request completed",
 					slog.Int("status", ww.Status()),
 					slog.Int("bytes", ww.BytesWritten()),
 					slog.String("duration", time.Since(t1).String()),
@@ -53,3 +58,38 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// anonymizeAddr оставляет от IP-адреса только сеть (/24 для IPv4, /48 для IPv6)
+// и отбрасывает порт: для отладки этого достаточно, а адрес клиента
+// не попадает в логи целиком
+func anonymizeAddr(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+
+	bits := 48
+	if addr.Unmap().Is4() {
+		addr, bits = addr.Unmap(), 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+
+	return prefix.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n] + "..."
+}
@@ -0,0 +1,51 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"log/slog" // для логирования
+
+	"github.com/stretchr/testify/require"
+
+	mwLogger "url-shortener/internal/http-server/middleware/logger"
+)
+
+func TestLogger_AnonymizesClient(t *testing.T) {
+	cases := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{remoteAddr: "203.0.113.57:41234", want: "203.0.113.0/24"},
+		{remoteAddr: "[2001:db8:85a3:8d3:1319:8a2e:370:7348]:443", want: "2001:db8:85a3::/48"},
+		{remoteAddr: "[::ffff:203.0.113.57]:80", want: "203.0.113.0/24"},
+		{remoteAddr: "garbage", want: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.remoteAddr, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			log := slog.New(slog.NewJSONHandler(&buf, nil))
+			handler := mwLogger.New(log)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/alias?token=abc", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("User-Agent", strings.Repeat("a", 500))
+
+			buf.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+			require.Equal(t, tc.want, entry["remote_addr"])
+			require.Equal(t, "/alias", entry["path"])
+			require.Less(t, len(entry["user_agent"].(string)), 250)
+		})
+	}
+}
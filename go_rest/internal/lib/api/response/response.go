@@ -2,6 +2,7 @@ package response
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -35,10 +36,20 @@ func ValidationError(errs validator.ValidationErrors) Response {
 
 	for _, err := range errs {
 		switch err.ActualTag() {
-		case "required":
+		case "required", "required_without":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
 		case "url":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+		case "max":
+			if err.Kind() == reflect.Slice {
+				errMsgs = append(errMsgs, fmt.Sprintf("field %s has more than %s items", err.Field(), err.Param()))
+			} else {
+				errMsgs = append(errMsgs, fmt.Sprintf("field %s is too long", err.Field()))
+			}
+		case "tag":
+			errMsgs = append(errMsgs, fmt.Sprintf(
+				"field %s is not a valid tag: use lowercase letters, digits, '-', '_' and '/'", err.Field(),
+			))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
 		}
@@ -0,0 +1,61 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Ключ имеет вид usk_<prefix>_<secret>. Префикс хранится открыто и нужен,
+// чтобы найти ключ в БД и узнать его в списке, секрет - только в виде хэша.
+const (
+	scheme      = "usk"
+	prefixBytes = 4
+	secretBytes = 32
+)
+
+// Generate создает новый ключ и возвращает его вместе с префиксом
+func Generate() (key string, prefix string, err error) {
+	p := make([]byte, prefixBytes)
+	s := make([]byte, secretBytes)
+
+	if _, err := rand.Read(p); err != nil {
+		return "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	if _, err := rand.Read(s); err != nil {
+		return "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	prefix = hex.EncodeToString(p)
+	key = scheme + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(s)
+
+	return key, prefix, nil
+}
+
+// Prefix извлекает префикс из ключа. ok = false, если формат ключа неверный.
+func Prefix(key string) (prefix string, ok bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != scheme || len(parts[1]) != 2*prefixBytes || parts[2] == "" {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+// Hash возвращает хэш ключа для хранения. Ключ случайный и длинный,
+// поэтому медленный хэш (bcrypt) здесь не нужен.
+func Hash(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+
+	return sum[:]
+}
+
+// Verify сравнивает ключ с сохраненным хэшем за постоянное время
+func Verify(key string, hash []byte) bool {
+	return subtle.ConstantTimeCompare(Hash(key), hash) == 1
+}
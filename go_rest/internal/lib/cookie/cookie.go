@@ -0,0 +1,61 @@
+package cookie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidValue = errors.New("invalid cookie value")
+	ErrExpired      = errors.New("cookie expired")
+)
+
+// Sign возвращает подписанное значение cookie вида "<expires>.<signature>".
+// Подпись покрывает payload, поэтому проверить значение можно только
+// с тем же payload (например, alias ссылки и хэшем ее пароля).
+func Sign(secret []byte, payload string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	return exp + "." + signature(secret, payload, exp)
+}
+
+// Verify проверяет подпись и срок действия значения, созданного Sign.
+func Verify(secret []byte, payload string, value string) error {
+	const op = "lib.cookie.Verify"
+
+	exp, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return fmt.Errorf("%s: %w", op, ErrInvalidValue)
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, ErrInvalidValue)
+	}
+
+	// Сравнение за постоянное время, чтобы не подсказывать подпись по таймингам
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, payload, exp))) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidValue)
+	}
+
+	if time.Now().Unix() > expUnix {
+		return fmt.Errorf("%s: %w", op, ErrExpired)
+	}
+
+	return nil
+}
+
+func signature(secret []byte, payload string, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	mac.Write([]byte{0})
+	mac.Write([]byte(exp))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
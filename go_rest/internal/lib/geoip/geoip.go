@@ -0,0 +1,194 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DB определяет страну по IP-адресу по локальному файлу диапазонов.
+//
+// Файл - CSV, каждая строка задает диапазон одним из способов:
+//
+//	network,country          (1.2.3.0/24,RU)
+//	start_ip,end_ip,country  (1.2.3.0,1.2.3.255,RU - формат DB-IP Lite)
+//
+// Строки с # в начале пропускаются, первая строка может быть заголовком.
+// Диапазоны не должны пересекаться.
+type DB struct {
+	ranges []ipRange
+}
+
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// Open загружает базу из файла
+func Open(path string) (*DB, error) {
+	const op = "lib.geoip.Open"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	db, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, path, err)
+	}
+
+	return db, nil
+}
+
+// Parse читает базу в формате, описанном у DB
+func Parse(r io.Reader) (*DB, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var ranges []ipRange
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rng, err := parseRange(rec)
+		if err != nil {
+			// Заголовок CSV
+			if line == 1 {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		ranges = append(ranges, rng)
+	}
+
+	slices.SortFunc(ranges, func(a, b ipRange) int {
+		return a.start.Compare(b.start)
+	})
+
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].start.Compare(ranges[i-1].end) <= 0 {
+			return nil, fmt.Errorf("range %s-%s overlaps %s-%s",
+				ranges[i].start, ranges[i].end, ranges[i-1].start, ranges[i-1].end)
+		}
+	}
+
+	return &DB{ranges: ranges}, nil
+}
+
+func parseRange(rec []string) (ipRange, error) {
+	var (
+		rng     ipRange
+		country string
+	)
+
+	switch len(rec) {
+	case 2:
+		prefix, err := netip.ParsePrefix(rec[0])
+		if err != nil {
+			return ipRange{}, err
+		}
+
+		prefix = prefix.Masked()
+		rng.start = prefix.Addr()
+		rng.end = lastAddr(prefix)
+		country = rec[1]
+	case 3:
+		start, err := netip.ParseAddr(rec[0])
+		if err != nil {
+			return ipRange{}, err
+		}
+
+		end, err := netip.ParseAddr(rec[1])
+		if err != nil {
+			return ipRange{}, err
+		}
+
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return ipRange{}, fmt.Errorf("invalid range %s-%s", start, end)
+		}
+
+		rng.start, rng.end = start, end
+		country = rec[2]
+	default:
+		return ipRange{}, fmt.Errorf("expected 2 or 3 fields, got %d", len(rec))
+	}
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) != 2 {
+		return ipRange{}, fmt.Errorf("invalid country code %q", country)
+	}
+
+	rng.country = country
+
+	return rng, nil
+}
+
+// lastAddr возвращает последний адрес сети
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
+
+// Country возвращает двухбуквенный код страны (ISO 3166-1) в верхнем
+// регистре или пустую строку, если адреса нет в базе. Для nil базы
+// всегда возвращает пустую строку.
+func (db *DB) Country(addr netip.Addr) string {
+	if db == nil || !addr.IsValid() {
+		return ""
+	}
+
+	// IPv4-адреса, пришедшие как ::ffff:a.b.c.d
+	addr = addr.Unmap()
+
+	// Последний диапазон, начинающийся не позже addr
+	i, found := slices.BinarySearchFunc(db.ranges, addr, func(r ipRange, a netip.Addr) int {
+		return r.start.Compare(a)
+	})
+	if !found {
+		i--
+	}
+
+	if i < 0 {
+		return ""
+	}
+
+	rng := db.ranges[i]
+	if rng.start.Is4() != addr.Is4() || rng.end.Less(addr) {
+		return ""
+	}
+
+	return rng.country
+}
+
+// Len возвращает число диапазонов в базе
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+
+	return len(db.ranges)
+}
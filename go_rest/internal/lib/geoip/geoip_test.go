@@ -0,0 +1,76 @@
+package geoip_test
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/geoip"
+)
+
+const testDB = `network_or_start,end,country
+# CIDR
+10.0.0.0/8,ru
+192.168.1.0/24,DE
+2001:db8::/32,FR
+# Диапазон
+172.16.0.0,172.16.0.255,US
+`
+
+func TestCountry(t *testing.T) {
+	db, err := geoip.Parse(strings.NewReader(testDB))
+	require.NoError(t, err)
+	require.Equal(t, 4, db.Len())
+
+	cases := []struct {
+		ip      string
+		country string
+	}{
+		{"10.0.0.0", "RU"},
+		{"10.255.255.255", "RU"},
+		{"11.0.0.0", ""},
+		{"9.255.255.255", ""},
+		{"192.168.1.77", "DE"},
+		{"192.168.2.1", ""},
+		{"172.16.0.255", "US"},
+		{"172.16.1.0", ""},
+		{"::ffff:10.1.2.3", "RU"},
+		{"2001:db8::1", "FR"},
+		{"2001:db9::1", ""},
+		{"1.1.1.1", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ip, func(t *testing.T) {
+			assert.Equal(t, tc.country, db.Country(netip.MustParseAddr(tc.ip)))
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{"Invalid network", "10.0.0.0/8,RU\nnot-a-network,DE\n"},
+		{"Invalid country", "10.0.0.0/8,RU\n11.0.0.0/8,RUS\n"},
+		{"Reversed range", "10.0.0.0/8,RU\n2.0.0.0,1.0.0.0,DE\n"},
+		{"Overlap", "10.0.0.0/8,RU\n10.1.0.0/16,DE\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := geoip.Parse(strings.NewReader(tc.data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCountry_NilDB(t *testing.T) {
+	var db *geoip.DB
+
+	assert.Equal(t, "", db.Country(netip.MustParseAddr("10.0.0.1")))
+}
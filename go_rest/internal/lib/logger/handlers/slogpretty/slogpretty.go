@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	stdLog "log"
+	"slices"
 
 	"log/slog" // для логирования
 
@@ -69,7 +70,7 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		}
 	}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
+	timeStr := r.Time.Format("[15:04:05.000]")
 	msg := color.CyanString(r.Message)
 
 	h.l.Println(
@@ -83,10 +84,11 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 }
 
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// Атрибуты накапливаются: log.With(...).With(...) сохраняет оба набора
 	return &PrettyHandler{
 		Handler: h.Handler,
 		l:       h.l,
-		attrs:   attrs,
+		attrs:   append(slices.Clip(h.attrs), attrs...),
 	}
 }
 
@@ -0,0 +1,220 @@
+package slogredact
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"log/slog" // для логирования
+)
+
+// Значение, которым заменяются скрытые данные
+const Redacted = "[REDACTED]"
+
+// Ключи атрибутов, значения которых скрываются по умолчанию
+var DefaultKeys = []string{
+	"password", "pass", "secret", "token", "access_token", "refresh_token",
+	"api_key", "authorization", "cookie", "cookie_secret",
+}
+
+// Параметры URL, значения которых скрываются по умолчанию
+var DefaultQueryParams = []string{"token", "password", "key"}
+
+type Options struct {
+	// Ключи атрибутов (без учета регистра), значения которых скрываются целиком.
+	// Проверяются и вложенные группы, и поля структур, переданных через slog.Any.
+	Keys []string
+	// Параметры запроса, значения которых скрываются в строках с URL
+	QueryParams []string
+}
+
+// Handler скрывает чувствительные данные в атрибутах записи
+// и передает ее следующему обработчику
+type Handler struct {
+	next        slog.Handler
+	keys        map[string]struct{}
+	queryParams map[string]struct{}
+}
+
+func NewHandler(next slog.Handler, opts Options) *Handler {
+	return &Handler{
+		next:        next,
+		keys:        toSet(opts.Keys),
+		queryParams: toSet(opts.QueryParams),
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, h.redactAttr(a))
+	}
+
+	return &Handler{next: h.next.WithAttrs(redacted), keys: h.keys, queryParams: h.queryParams}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), keys: h.keys, queryParams: h.queryParams}
+}
+
+func (h *Handler) redactAttr(a slog.Attr) slog.Attr {
+	// Разворачиваем LogValuer, чтобы проверить итоговое значение
+	a.Value = a.Value.Resolve()
+
+	if h.sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+
+		attrs := make([]slog.Attr, 0, len(group))
+		for _, ga := range group {
+			attrs = append(attrs, h.redactAttr(ga))
+		}
+
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindString:
+		return slog.String(a.Key, h.redactURL(a.Value.String()))
+	case slog.KindAny:
+		if v, ok := h.redactAny(a.Value.Any()); ok {
+			return slog.Any(a.Key, v)
+		}
+	}
+
+	return a
+}
+
+// redactAny скрывает поля структур и map, переданных через slog.Any
+// (например, gRPC-сообщений). Значение приводится к JSON-представлению,
+// так же, как его вывел бы JSON-обработчик.
+func (h *Handler) redactAny(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+
+	if _, ok := v.(error); ok {
+		return nil, false
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+		return nil, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, false
+	}
+
+	return h.redactJSON(decoded), true
+}
+
+func (h *Handler) redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if h.sensitive(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = h.redactJSON(val)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = h.redactJSON(val)
+		}
+	case string:
+		return h.redactURL(v)
+	}
+
+	return v
+}
+
+// redactURL скрывает значения параметров запроса, если строка - URL.
+// Порядок параметров и остальная часть строки не меняются.
+func (h *Handler) redactURL(s string) string {
+	if len(h.queryParams) == 0 {
+		return s
+	}
+
+	base, rawQuery, ok := strings.Cut(s, "?")
+	if !ok || rawQuery == "" {
+		return s
+	}
+
+	rawQuery, fragment, hasFragment := strings.Cut(rawQuery, "#")
+
+	pairs := strings.Split(rawQuery, "&")
+	changed := false
+
+	for i, pair := range pairs {
+		rawName, _, _ := strings.Cut(pair, "=")
+
+		name := rawName
+		if unescaped, err := url.QueryUnescape(rawName); err == nil {
+			name = unescaped
+		}
+
+		if _, ok := h.queryParams[strings.ToLower(name)]; !ok {
+			continue
+		}
+
+		pairs[i] = rawName + "=" + Redacted
+		changed = true
+	}
+
+	if !changed {
+		return s
+	}
+
+	res := base + "?" + strings.Join(pairs, "&")
+	if hasFragment {
+		res += "#" + fragment
+	}
+
+	return res
+}
+
+func (h *Handler) sensitive(key string) bool {
+	_, ok := h.keys[strings.ToLower(key)]
+
+	return ok
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = struct{}{}
+	}
+
+	return set
+}
@@ -0,0 +1,93 @@
+package slogsampling
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"log/slog" // для логирования
+)
+
+// Options задает сэмплирование: в каждом интервале Tick из записей
+// с одинаковыми уровнем и сообщением пропускаются первые First,
+// а затем каждая Thereafter-я. Thereafter = 0 отбрасывает все остальные.
+type Options struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+}
+
+// Handler ограничивает число одинаковых записей, чтобы частые сообщения
+// (например, о каждом запросе) не забивали лог под нагрузкой.
+// Записи уровня Error и выше не сэмплируются.
+type Handler struct {
+	next     slog.Handler
+	opts     Options
+	counters *counters
+}
+
+func NewHandler(next slog.Handler, opts Options) *Handler {
+	return &Handler{
+		next:     next,
+		opts:     opts,
+		counters: &counters{counts: make(map[key]int)},
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelError && !h.sample(r) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs и WithGroup разделяют счетчики с исходным обработчиком,
+// иначе log.With(...) в каждом запросе обнулял бы сэмплирование
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), opts: h.opts, counters: h.counters}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), opts: h.opts, counters: h.counters}
+}
+
+func (h *Handler) sample(r slog.Record) bool {
+	n := h.counters.inc(key{level: r.Level, msg: r.Message}, r.Time, h.opts.Tick)
+
+	if n <= h.opts.First {
+		return true
+	}
+
+	return h.opts.Thereafter > 0 && (n-h.opts.First)%h.opts.Thereafter == 0
+}
+
+type key struct {
+	level slog.Level
+	msg   string
+}
+
+type counters struct {
+	mu     sync.Mutex
+	start  time.Time
+	counts map[key]int
+}
+
+// inc увеличивает счетчик записи и возвращает ее номер в текущем интервале
+func (c *counters) inc(k key, now time.Time, tick time.Duration) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.start) >= tick {
+		c.start = now
+		clear(c.counts)
+	}
+
+	c.counts[k]++
+
+	return c.counts[k]
+}
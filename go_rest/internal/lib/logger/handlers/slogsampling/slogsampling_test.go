@@ -0,0 +1,40 @@
+package slogsampling_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"log/slog" // для логирования
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogsampling"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := slog.New(slogsampling.NewHandler(
+		slog.NewTextHandler(&buf, nil),
+		slogsampling.Options{Tick: time.Hour, First: 2, Thereafter: 3},
+	))
+
+	for i := 0; i < 10; i++ {
+		// Счетчики общие для логгеров, созданных через With
+		log.With(slog.Int("i", i)).Info("request")
+		log.Error("failure")
+	}
+
+	out := buf.String()
+
+	// Первые 2, затем каждая 3-я: записи 1, 2, 5, 8
+	require.Equal(t, 4, strings.Count(out, "msg=request"))
+	for _, i := range []string{"i=0", "i=1", "i=4", "i=7"} {
+		require.Contains(t, out, i)
+	}
+
+	// Ошибки не сэмплируются
+	require.Equal(t, 10, strings.Count(out, "msg=failure"))
+}
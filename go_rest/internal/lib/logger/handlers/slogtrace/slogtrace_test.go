@@ -0,0 +1,35 @@
+package slogtrace_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"log/slog" // для логирования
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"url-shortener/internal/lib/logger/handlers/slogtrace"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := slog.New(slogtrace.NewHandler(slog.NewTextHandler(&buf, nil))).With(slog.String("op", "test"))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	log.InfoContext(ctx, "traced")
+	require.Contains(t, buf.String(), "op=test trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7")
+
+	// Без спана в контексте запись не меняется
+	buf.Reset()
+	log.Info("untraced")
+	require.NotContains(t, buf.String(), "trace_id")
+}
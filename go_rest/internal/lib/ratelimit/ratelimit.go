@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter считает попытки по ключу в пределах окна времени.
+// После limit попыток без Reset ключ блокируется до конца окна.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	attempts map[string]*attempt
+}
+
+type attempt struct {
+	count int
+	start time.Time
+}
+
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		window:   window,
+		attempts: make(map[string]*attempt),
+	}
+}
+
+// Reserve засчитывает попытку для ключа, если лимит еще не исчерпан.
+// Проверка и учет выполняются атомарно, поэтому параллельные запросы
+// не проходят лимит до того, как засчитана хотя бы одна неудача
+func (l *Limiter) Reserve(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	a, ok := l.attempts[key]
+	if !ok || now.Sub(a.start) > l.window {
+		l.attempts[key] = &attempt{count: 1, start: now}
+
+		l.cleanup(now)
+
+		return true
+	}
+
+	if a.count >= l.limit {
+		return false
+	}
+
+	a.count++
+
+	return true
+}
+
+// Reset сбрасывает счетчик ключа, например после успешной попытки
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+}
+
+// cleanup удаляет устаревшие записи, чтобы map не росла бесконечно
+func (l *Limiter) cleanup(now time.Time) {
+	for key, a := range l.attempts {
+		if now.Sub(a.start) > l.window {
+			delete(l.attempts, key)
+		}
+	}
+}
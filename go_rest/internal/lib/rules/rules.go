@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"url-shortener/internal/domain/models"
+)
+
+// Client - свойства посетителя, по которым проверяются правила
+type Client struct {
+	Language string // Предпочитаемый язык в нижнем регистре ("en-us"), пусто - неизвестен
+	Device   string // Класс устройства (models.Device*)
+	Country  string // Код страны в верхнем регистре, пусто - неизвестна
+}
+
+// Match возвращает индекс первого подходящего правила или -1
+func Match(rules []models.RedirectRule, c Client) int {
+	for i, rule := range rules {
+		if matches(rule, c) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func matches(rule models.RedirectRule, c Client) bool {
+	if len(rule.Languages) > 0 && !slices.ContainsFunc(rule.Languages, func(lang string) bool {
+		return languageMatches(lang, c.Language)
+	}) {
+		return false
+	}
+
+	if len(rule.Devices) > 0 && !slices.Contains(rule.Devices, c.Device) {
+		return false
+	}
+
+	if len(rule.Countries) > 0 && (c.Country == "" || !slices.Contains(rule.Countries, c.Country)) {
+		return false
+	}
+
+	return true
+}
+
+// languageMatches сравнивает язык правила с языком посетителя:
+// правило "en" подходит для "en" и "en-us", правило "en-us" - только для "en-us"
+func languageMatches(rule, client string) bool {
+	if client == "" {
+		return false
+	}
+
+	return client == rule || strings.HasPrefix(client, rule+"-")
+}
+
+// PreferredLanguage возвращает язык с наибольшим весом из заголовка
+// Accept-Language в нижнем регистре. При равных весах выигрывает
+// указанный раньше. "*" и языки с q=0 не учитываются.
+func PreferredLanguage(header string) string {
+	var (
+		best  string
+		bestQ float64
+	)
+
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+
+		for param := range strings.SplitSeq(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+
+			q = parsed
+		}
+
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+
+	return best
+}
+
+// DeviceClass определяет класс устройства по User-Agent.
+// Все, что не iOS и не Android, считается desktop.
+func DeviceClass(userAgent string) string {
+	switch {
+	// iPadOS 13+ представляется как Macintosh, такие iPad считаются desktop
+	case strings.Contains(userAgent, "iPhone"),
+		strings.Contains(userAgent, "iPad"),
+		strings.Contains(userAgent, "iPod"):
+		return models.DeviceIOS
+	case strings.Contains(userAgent, "Android"):
+		return models.DeviceAndroid
+	default:
+		return models.DeviceDesktop
+	}
+}
@@ -0,0 +1,82 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/lib/rules"
+)
+
+func TestMatch(t *testing.T) {
+	set := []models.RedirectRule{
+		{Devices: []string{models.DeviceIOS}, URL: "https://apps.apple.com/app"},
+		{Devices: []string{models.DeviceAndroid}, URL: "https://play.google.com/app"},
+		{Languages: []string{"ru"}, Countries: []string{"RU", "BY"}, URL: "https://example.ru"},
+		{Languages: []string{"de", "en-gb"}, URL: "https://example.eu"},
+	}
+
+	cases := []struct {
+		name   string
+		client rules.Client
+		want   int
+	}{
+		{"iOS", rules.Client{Device: models.DeviceIOS, Language: "ru", Country: "RU"}, 0},
+		{"Android", rules.Client{Device: models.DeviceAndroid}, 1},
+		{"Russian in Russia", rules.Client{Device: models.DeviceDesktop, Language: "ru-ru", Country: "RU"}, 2},
+		{"Russian abroad", rules.Client{Device: models.DeviceDesktop, Language: "ru", Country: "US"}, -1},
+		{"Russian, unknown country", rules.Client{Device: models.DeviceDesktop, Language: "ru"}, -1},
+		{"German", rules.Client{Device: models.DeviceDesktop, Language: "de-at"}, 3},
+		{"British English", rules.Client{Device: models.DeviceDesktop, Language: "en-gb"}, 3},
+		{"American English", rules.Client{Device: models.DeviceDesktop, Language: "en-us"}, -1},
+		{"No language", rules.Client{Device: models.DeviceDesktop}, -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rules.Match(set, tc.client))
+		})
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"ru-RU", "ru-ru"},
+		{"en-US,en;q=0.9,ru;q=0.8", "en-us"},
+		{"de;q=0.5, fr;q=0.7", "fr"},
+		{"*, es;q=0.4", "es"},
+		{"ru;q=0, en;q=0.1", "en"},
+		{"en;q=abc", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.want, rules.PreferredLanguage(tc.header))
+		})
+	}
+}
+
+func TestDeviceClass(t *testing.T) {
+	cases := []struct {
+		ua   string
+		want string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15", models.DeviceIOS},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X)", models.DeviceIOS},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Mobile", models.DeviceAndroid},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36", models.DeviceDesktop},
+		{"curl/8.5.0", models.DeviceDesktop},
+		{"", models.DeviceDesktop},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.want+" "+tc.ua, func(t *testing.T) {
+			assert.Equal(t, tc.want, rules.DeviceClass(tc.ua))
+		})
+	}
+}
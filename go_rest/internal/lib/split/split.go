@@ -0,0 +1,42 @@
+package split
+
+import (
+	"hash/fnv"
+)
+
+// Pick выбирает индекс варианта по весам. Выбор детерминирован: один и тот же
+// key (посетитель + ссылка) всегда получает один и тот же вариант, пока не
+// изменились веса. Варианты с весом <= 0 не выбираются. Если положительных
+// весов нет, возвращает -1.
+func Pick(weights []int, key string) int {
+	total := 0
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+
+	if total == 0 {
+		return -1
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	point := int(h.Sum64() % uint64(total))
+
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+
+		if point < w {
+			return i
+		}
+
+		point -= w
+	}
+
+	// Недостижимо: point всегда меньше суммы весов
+	return -1
+}
@@ -0,0 +1,46 @@
+package split_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/split"
+)
+
+func TestPick_Deterministic(t *testing.T) {
+	weights := []int{50, 50}
+
+	first := split.Pick(weights, "visitor-1|promo")
+	for range 10 {
+		assert.Equal(t, first, split.Pick(weights, "visitor-1|promo"))
+	}
+}
+
+func TestPick_Weights(t *testing.T) {
+	weights := []int{70, 20, 10, 0}
+
+	const visitors = 20000
+
+	counts := make([]int, len(weights))
+	for i := range visitors {
+		idx := split.Pick(weights, strconv.Itoa(i)+"|promo")
+		require.GreaterOrEqual(t, idx, 0)
+
+		counts[idx]++
+	}
+
+	// Доли с допуском в 2 процентных пункта
+	assert.InDelta(t, 0.70, float64(counts[0])/visitors, 0.02)
+	assert.InDelta(t, 0.20, float64(counts[1])/visitors, 0.02)
+	assert.InDelta(t, 0.10, float64(counts[2])/visitors, 0.02)
+	assert.Zero(t, counts[3], "zero weight must never be picked")
+}
+
+func TestPick_NoWeights(t *testing.T) {
+	assert.Equal(t, -1, split.Pick(nil, "visitor"))
+	assert.Equal(t, -1, split.Pick([]int{0, 0}, "visitor"))
+	assert.Equal(t, 0, split.Pick([]int{5}, "visitor"))
+}
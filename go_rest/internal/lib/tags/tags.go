@@ -0,0 +1,64 @@
+package tags
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationTag - имя правила валидатора для тегов: `validate:"dive,tag"`
+const ValidationTag = "tag"
+
+// MaxLen - максимальная длина тега в символах
+const MaxLen = 32
+
+// Тег состоит из строчных букв, цифр, "-" и "_". Через "/" теги
+// складываются в папки: "marketing/2026".
+var tagRe = regexp.MustCompile(`^[\p{Ll}\p{N}_-]+(/[\p{Ll}\p{N}_-]+)*$`)
+
+// Valid сообщает, что тег допустим. Тег должен быть уже нормализован.
+func Valid(tag string) bool {
+	return utf8.RuneCountInString(tag) <= MaxLen && tagRe.MatchString(tag)
+}
+
+// Normalize приводит теги к нижнему регистру, убирает пробелы по краям
+// и повторы. Порядок тегов сохраняется.
+func Normalize(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	res := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+
+	return res
+}
+
+// RegisterValidation добавляет в валидатор правило "tag"
+func RegisterValidation(v *validator.Validate) error {
+	return v.RegisterValidation(ValidationTag, func(fl validator.FieldLevel) bool {
+		return Valid(fl.Field().String())
+	})
+}
+
+// NewValidator возвращает валидатор с правилом "tag"
+func NewValidator() *validator.Validate {
+	v := validator.New()
+
+	// Ошибка возможна только при пустом имени правила или функции
+	if err := RegisterValidation(v); err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -0,0 +1,36 @@
+package tags_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/tags"
+)
+
+func TestValid(t *testing.T) {
+	for _, tag := range []string{"go", "marketing/2026", "q1_report", "a-b/c-d", "новости"} {
+		require.True(t, tags.Valid(tag), tag)
+	}
+
+	for _, tag := range []string{"", "Go", "with space", "a,b", "/lead", "trail/", "a//b", "very-long-tag-name-that-exceeds-limit"} {
+		require.False(t, tags.Valid(tag), tag)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, []string{"go", "news/it"}, tags.Normalize([]string{" Go ", "news/IT", "go"}))
+	require.Nil(t, tags.Normalize(nil))
+}
+
+func TestValidator(t *testing.T) {
+	type request struct {
+		Tags []string `validate:"max=2,dive,tag"`
+	}
+
+	v := tags.NewValidator()
+
+	require.NoError(t, v.Struct(request{Tags: []string{"go", "news/it"}}))
+	require.Error(t, v.Struct(request{Tags: []string{"Not Valid"}}))
+	require.Error(t, v.Struct(request{Tags: []string{"a", "b", "c"}}))
+}
@@ -0,0 +1,175 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+const userAgent = "url-shortener-linkcheck/1.0"
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.5 --name=LinkStore
+type LinkStore interface {
+	ListURLs(ctx context.Context) ([]models.URL, error)
+	SaveURLStatus(ctx context.Context, alias string, status int, checkErr string, checkedAt time.Time) error
+}
+
+// Checker периодически проверяет доступность всех сохраненных URL
+type Checker struct {
+	log         *slog.Logger
+	store       LinkStore
+	client      *http.Client
+	interval    time.Duration
+	concurrency int
+}
+
+// New создает Checker. timeout ограничивает время проверки одного URL,
+// concurrency - число одновременных запросов.
+func New(
+	log *slog.Logger,
+	store LinkStore,
+	interval time.Duration,
+	timeout time.Duration,
+	concurrency int,
+) *Checker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Checker{
+		log:         log.With(slog.String("component", "linkcheck")),
+		store:       store,
+		client:      &http.Client{Timeout: timeout},
+		interval:    interval,
+		concurrency: concurrency,
+	}
+}
+
+// Run проверяет ссылки сразу и затем каждые interval, пока не отменен ctx
+func (c *Checker) Run(ctx context.Context) {
+	c.log.Info("link checker started", slog.Duration("interval", c.interval))
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.CheckAll(ctx); err != nil {
+			c.log.Error("failed to check links", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			c.log.Info("link checker stopped")
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll выполняет один проход проверки по всем ссылкам
+func (c *Checker) CheckAll(ctx context.Context) error {
+	const op = "linkcheck.CheckAll"
+
+	urls, err := c.store.ListURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs := make(chan models.URL)
+
+	var (
+		wg     sync.WaitGroup
+		broken atomic.Int64
+	)
+
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for u := range jobs {
+				if c.checkOne(ctx, u) {
+					broken.Add(1)
+				}
+			}
+		}()
+	}
+
+loop:
+	for _, u := range urls {
+		select {
+		case <-ctx.Done():
+			break loop
+		case jobs <- u:
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	c.log.InfoContext(ctx, "links checked", slog.Int("total", len(urls)), slog.Int64("broken", broken.Load()))
+
+	return nil
+}
+
+// checkOne проверяет ссылку, сохраняет результат и сообщает, битая ли она
+func (c *Checker) checkOne(ctx context.Context, u models.URL) bool {
+	u.LastStatus, u.CheckError = c.check(ctx, u.URL)
+
+	// Проверку прервали при остановке сервиса - результат не сохраняем
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err := c.store.SaveURLStatus(ctx, u.Alias, u.LastStatus, u.CheckError, time.Now()); err != nil {
+		c.log.ErrorContext(ctx, "failed to save url status", slog.String("alias", u.Alias), sl.Err(err))
+	}
+
+	return u.Broken()
+}
+
+// check делает HEAD-запрос, а если сервер его не поддерживает - GET.
+// Возвращает HTTP-статус или текст ошибки, если ответа нет.
+func (c *Checker) check(ctx context.Context, url string) (int, string) {
+	status, err := c.do(ctx, http.MethodHead, url)
+	if err == nil && status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+		return status, ""
+	}
+
+	status, err = c.do(ctx, http.MethodGet, url)
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	return status, ""
+}
+
+func (c *Checker) do(ctx context.Context, method string, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Тело не нужно, но дочитываем немного, чтобы соединение переиспользовалось
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,64 @@
+package linkcheck_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/domain/models"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/linkcheck"
+	"url-shortener/internal/linkcheck/mocks"
+)
+
+func TestChecker_CheckAll(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// Сервер не поддерживает HEAD - должен сработать fallback на GET
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Адрес закрытого сервера - запрос завершится ошибкой
+	closed := httptest.NewServer(mux)
+	closedURL := closed.URL
+	closed.Close()
+
+	storeMock := mocks.NewLinkStore(t)
+	storeMock.On("ListURLs", mock.Anything).Return([]models.URL{
+		{Alias: "ok", URL: ts.URL + "/ok"},
+		{Alias: "gone", URL: ts.URL + "/gone"},
+		{Alias: "no-head", URL: ts.URL + "/no-head"},
+		{Alias: "down", URL: closedURL},
+	}, nil).Once()
+
+	storeMock.On("SaveURLStatus", mock.Anything, "ok", http.StatusOK, "", mock.AnythingOfType("time.Time")).Return(nil).Once()
+	storeMock.On("SaveURLStatus", mock.Anything, "gone", http.StatusNotFound, "", mock.AnythingOfType("time.Time")).Return(nil).Once()
+	storeMock.On("SaveURLStatus", mock.Anything, "no-head", http.StatusOK, "", mock.AnythingOfType("time.Time")).Return(nil).Once()
+	storeMock.On("SaveURLStatus", mock.Anything, "down", 0, mock.MatchedBy(func(checkErr string) bool {
+		return checkErr != ""
+	}), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	checker := linkcheck.New(slogdiscard.NewDiscardLogger(), storeMock, time.Hour, time.Second, 2)
+
+	require.NoError(t, checker.CheckAll(context.Background()))
+}
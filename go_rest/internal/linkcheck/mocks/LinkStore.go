@@ -0,0 +1,80 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/internal/domain/models"
+
+	time "time"
+)
+
+// LinkStore is an autogenerated mock type for the LinkStore type
+type LinkStore struct {
+	mock.Mock
+}
+
+// ListURLs provides a mock function with given fields: ctx
+func (_m *LinkStore) ListURLs(ctx context.Context) ([]models.URL, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListURLs")
+	}
+
+	var r0 []models.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.URL, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.URL); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURLStatus provides a mock function with given fields: ctx, alias, status, checkErr, checkedAt
+func (_m *LinkStore) SaveURLStatus(ctx context.Context, alias string, status int, checkErr string, checkedAt time.Time) error {
+	ret := _m.Called(ctx, alias, status, checkErr, checkedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURLStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string, time.Time) error); ok {
+		r0 = rf(ctx, alias, status, checkErr, checkedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLinkStore creates a new instance of LinkStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLinkStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LinkStore {
+	mock := &LinkStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
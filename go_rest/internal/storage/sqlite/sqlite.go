@@ -1,12 +1,19 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mattn/go-sqlite3"
 
+	"url-shortener/internal/domain/models"
 	"url-shortener/internal/storage"
 )
 
@@ -22,19 +29,81 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Создаем таблицу, если ее еще нет
-	stmt, err := db.Prepare(`
+	// Создаем таблицу, если ее еще нет.
+	// Exec, в отличие от Prepare, выполняет все выражения, а не только первое
+	_, err = db.Exec(`
     CREATE TABLE IF NOT EXISTS url(
         id INTEGER PRIMARY KEY,
         alias TEXT NOT NULL UNIQUE,
-        url TEXT NOT NULL);
+        url TEXT NOT NULL,
+        pass_hash BLOB,
+        last_status INTEGER NOT NULL DEFAULT 0,
+        check_error TEXT NOT NULL DEFAULT '',
+        checked_at DATETIME);
     CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+    CREATE TABLE IF NOT EXISTS audit(
+        id INTEGER PRIMARY KEY,
+        time DATETIME NOT NULL,
+        actor TEXT NOT NULL,
+        action TEXT NOT NULL,
+        alias TEXT NOT NULL,
+        old_url TEXT NOT NULL DEFAULT '',
+        new_url TEXT NOT NULL DEFAULT '',
+        request_id TEXT NOT NULL DEFAULT '');
+    CREATE INDEX IF NOT EXISTS idx_audit_alias ON audit(alias);
+    CREATE TABLE IF NOT EXISTS api_key(
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL,
+        prefix TEXT NOT NULL UNIQUE,
+        key_hash BLOB NOT NULL,
+        scopes TEXT NOT NULL,
+        created_by TEXT NOT NULL,
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME,
+        last_used_at DATETIME);
+    CREATE TABLE IF NOT EXISTS team(
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE,
+        created_by TEXT NOT NULL,
+        created_at DATETIME NOT NULL);
+    CREATE TABLE IF NOT EXISTS tag(
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE);
+    CREATE TABLE IF NOT EXISTS url_tag(
+        url_id INTEGER NOT NULL,
+        tag_id INTEGER NOT NULL,
+        PRIMARY KEY(url_id, tag_id));
+    CREATE INDEX IF NOT EXISTS idx_url_tag_tag ON url_tag(tag_id);
+    CREATE TABLE IF NOT EXISTS team_member(
+        team_id INTEGER NOT NULL,
+        member TEXT NOT NULL,
+        PRIMARY KEY(team_id, member));
     `)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec()
+	// Колонки, появившиеся после первой версии схемы
+	columns := []struct{ table, name, definition string }{
+		{"url", "pass_hash", "BLOB"},
+		{"url", "last_status", "INTEGER NOT NULL DEFAULT 0"},
+		{"url", "check_error", "TEXT NOT NULL DEFAULT ''"},
+		{"url", "checked_at", "DATETIME"},
+		{"url", "owner", "TEXT NOT NULL DEFAULT ''"},
+		{"url", "team_id", "INTEGER"},
+		{"url", "destinations", "TEXT NOT NULL DEFAULT ''"},
+		{"url", "rules", "TEXT NOT NULL DEFAULT ''"},
+		{"audit", "old_owner", "TEXT NOT NULL DEFAULT ''"},
+		{"audit", "new_owner", "TEXT NOT NULL DEFAULT ''"},
+	}
+
+	for _, c := range columns {
+		if err := addColumn(db, c.table, c.name, c.definition); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_url_team ON url(team_id)")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -42,17 +111,66 @@ func New(storagePath string) (*Storage, error) {
 	return &Storage{db: db}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
+// addColumn добавляет колонку в таблицу, если ее там еще нет
+func addColumn(db *sql.DB, table, column, definition string) error {
+	var count int
+
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check column %s.%s: %w", table, column, err)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
+
+// SaveURL сохраняет ссылку вместе с владельцем, тегами, вариантами
+// адресов и правилами перенаправления. passHash - bcrypt-хэш пароля, nil для ссылок без пароля.
+// Если у владельца указана команда, она должна существовать.
+func (s *Storage) SaveURL(ctx context.Context, link models.URL, passHash []byte) (_ int64, err error) {
 	const op = "storage.sqlite.SaveURL"
 
-	// Подготавливаем запрос
-	stmt, err := s.db.Prepare("INSERT INTO url(url,alias) values(?,?)")
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	if link.TeamID != nil {
+		if err := s.checkTeam(ctx, *link.TeamID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	destinations, err := marshalList(link.Destinations)
 	if err != nil {
-		return 0, fmt.Errorf("%s: prepare statement: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	rules, err := marshalList(link.Rules)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Ссылка и ее теги сохраняются вместе
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
 	// Выполняем запрос
-	res, err := stmt.Exec(urlToSave, alias)
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO url(url,alias,pass_hash,owner,team_id,destinations,rules) values(?,?,?,?,?,?,?)",
+		link.URL, link.Alias, passHash, link.Owner.User, link.TeamID, destinations, rules,
+	)
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
 			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
@@ -67,21 +185,52 @@ func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
 		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
 	}
 
+	if err := addTags(ctx, tx, id, link.Tags); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
 	// Возвращаем ID
 	return id, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+// addTags привязывает теги к ссылке, создавая недостающие
+func addTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO tag(name) VALUES(?)", tag)
+		if err != nil {
+			return fmt.Errorf("add tag %q: %w", tag, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO url_tag(url_id, tag_id)
+		SELECT ?, id FROM tag WHERE name = ?`, urlID, tag)
+		if err != nil {
+			return fmt.Errorf("tag url with %q: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) GetURL(ctx context.Context, alias string) (_ string, err error) {
 	const op = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = ?")
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT url FROM url WHERE alias = ?")
 	if err != nil {
 		return "", fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
+	defer stmt.Close()
 
 	var resURL string
 
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	err = stmt.QueryRowContext(ctx, alias).Scan(&resURL)
 	if errors.Is(err, sql.ErrNoRows) {
 		return "", storage.ErrURLNotFound
 	}
@@ -92,18 +241,149 @@ func (s *Storage) GetURL(alias string) (string, error) {
 	return resURL, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
-	const op = "storage.sqlite.DeleteURL"
+// GetRedirect возвращает адрес, хэш пароля, варианты и правила ссылки
+// одним запросом: он выполняется при каждом переходе
+func (s *Storage) GetRedirect(ctx context.Context, alias string) (_ models.Redirect, err error) {
+	const op = "storage.sqlite.GetRedirect"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	var (
+		r     models.Redirect
+		dests string
+		rules string
+	)
+
+	err = s.db.QueryRowContext(ctx,
+		"SELECT url, pass_hash, destinations, rules FROM url WHERE alias = ?", alias,
+	).Scan(&r.URL, &r.PassHash, &dests, &rules)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Redirect{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return models.Redirect{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	r.Destinations, err = unmarshalList[models.Destination](dests)
+	if err != nil {
+		return models.Redirect{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.Rules, err = unmarshalList[models.RedirectRule](rules)
+	if err != nil {
+		return models.Redirect{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// SetRedirectRules заменяет правила перенаправления ссылки,
+// пустой список удаляет все правила
+func (s *Storage) SetRedirectRules(ctx context.Context, alias string, rules []models.RedirectRule) (err error) {
+	const op = "storage.sqlite.SetRedirectRules"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	raw, err := marshalList(rules)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "UPDATE url SET rules = ? WHERE alias = ?", raw, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	// Подготавливаем SQL запрос
-	stmt, err := s.db.Prepare("DELETE FROM url WHERE alias = ?")
+	n, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// Варианты адресов и правила хранятся в колонках url.destinations
+// и url.rules в виде JSON, пустая строка - пустой список
+func marshalList[T any](list []T) (string, error) {
+	if len(list) == 0 {
+		return "", nil
+	}
+
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("marshal %T: %w", list, err)
+	}
+
+	return string(raw), nil
+}
+
+func unmarshalList[T any](raw string) ([]T, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var list []T
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("unmarshal %T: %w", list, err)
+	}
+
+	return list, nil
+}
+
+// GetURLPassHash возвращает хэш пароля ссылки, nil - если пароля нет
+func (s *Storage) GetURLPassHash(ctx context.Context, alias string) (_ []byte, err error) {
+	const op = "storage.sqlite.GetURLPassHash"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT pass_hash FROM url WHERE alias = ?")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
 	defer stmt.Close()
 
+	var passHash []byte
+
+	err = stmt.QueryRowContext(ctx, alias).Scan(&passHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return passHash, nil
+}
+
+func (s *Storage) DeleteURL(ctx context.Context, alias string) (err error) {
+	const op = "storage.sqlite.DeleteURL"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	// Теги удаляются вместе со ссылкой: SQLite может выдать ее id новой ссылке
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM url_tag WHERE url_id IN (SELECT id FROM url WHERE alias = ?)", alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	// Выполняем удаление
-	result, err := stmt.Exec(alias)
+	result, err := tx.ExecContext(ctx, "DELETE FROM url WHERE alias = ?", alias)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -118,5 +398,759 @@ func (s *Storage) DeleteURL(alias string) error {
 		return storage.ErrURLNotFound
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
 	return nil
 }
+
+// SaveAuditEvent добавляет запись в журнал аудита.
+// Записи только добавляются и никогда не изменяются.
+func (s *Storage) SaveAuditEvent(ctx context.Context, event models.AuditEvent) (err error) {
+	const op = "storage.sqlite.SaveAuditEvent"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+	INSERT INTO audit(time, actor, action, alias, old_url, new_url, old_owner, new_owner, request_id)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx,
+		event.Time.UTC(), event.Actor, event.Action, event.Alias,
+		event.OldURL, event.NewURL, event.OldOwner, event.NewOwner, event.RequestID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// AuditEvents возвращает историю изменений ссылки в хронологическом порядке
+func (s *Storage) AuditEvents(ctx context.Context, alias string) (_ []models.AuditEvent, err error) {
+	const op = "storage.sqlite.AuditEvents"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+	SELECT time, actor, action, alias, old_url, new_url, old_owner, new_owner, request_id
+	FROM audit WHERE alias = ? ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+
+	for rows.Next() {
+		var e models.AuditEvent
+
+		err := rows.Scan(&e.Time, &e.Actor, &e.Action, &e.Alias, &e.OldURL, &e.NewURL, &e.OldOwner, &e.NewOwner, &e.RequestID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// ListURLs возвращает все ссылки вместе с результатом последней проверки
+func (s *Storage) ListURLs(ctx context.Context) (_ []models.URL, err error) {
+	const op = "storage.sqlite.ListURLs"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	return s.queryURLs(ctx, op, `
+	SELECT `+urlColumns+`
+	FROM url ORDER BY id`)
+}
+
+// BrokenURLs возвращает ссылки, которые при последней проверке были недоступны
+func (s *Storage) BrokenURLs(ctx context.Context) (_ []models.URL, err error) {
+	const op = "storage.sqlite.BrokenURLs"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	return s.queryURLs(ctx, op, `
+	SELECT `+urlColumns+`
+	FROM url WHERE check_error != '' OR last_status >= 400 ORDER BY id`)
+}
+
+func (s *Storage) queryURLs(ctx context.Context, op string, query string, args ...any) ([]models.URL, error) {
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var urls []models.URL
+
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		urls = append(urls, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// urlColumns - колонки, которые читает scanURL. Теги собираются
+// через запятую: в самих тегах запятых не бывает.
+const urlColumns = `alias, url, owner, team_id, last_status, check_error, checked_at, destinations, rules,
+	(SELECT GROUP_CONCAT(tag.name, ',') FROM url_tag JOIN tag ON tag.id = url_tag.tag_id
+	WHERE url_tag.url_id = url.id)`
+
+func scanURL(row scanner) (models.URL, error) {
+	var (
+		u         models.URL
+		teamID    sql.NullInt64
+		checkedAt sql.NullTime
+		tags      sql.NullString
+		dests     string
+		rules     string
+	)
+
+	err := row.Scan(&u.Alias, &u.URL, &u.Owner.User, &teamID, &u.LastStatus, &u.CheckError, &checkedAt,
+		&dests, &rules, &tags)
+	if err != nil {
+		return models.URL{}, err
+	}
+
+	u.Destinations, err = unmarshalList[models.Destination](dests)
+	if err != nil {
+		return models.URL{}, err
+	}
+
+	u.Rules, err = unmarshalList[models.RedirectRule](rules)
+	if err != nil {
+		return models.URL{}, err
+	}
+
+	if teamID.Valid {
+		u.TeamID = &teamID.Int64
+	}
+
+	if checkedAt.Valid {
+		u.CheckedAt = &checkedAt.Time
+	}
+
+	if tags.String != "" {
+		u.Tags = strings.Split(tags.String, ",")
+		slices.Sort(u.Tags)
+	}
+
+	return u, nil
+}
+
+// URL возвращает ссылку вместе с владельцем
+func (s *Storage) URL(ctx context.Context, alias string) (_ models.URL, err error) {
+	const op = "storage.sqlite.URL"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	u, err := scanURL(s.db.QueryRowContext(ctx, "SELECT "+urlColumns+" FROM url WHERE alias = ?", alias))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.URL{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return models.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// TeamURLs возвращает ссылки команды
+func (s *Storage) TeamURLs(ctx context.Context, teamID int64) (_ []models.URL, err error) {
+	const op = "storage.sqlite.TeamURLs"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	if err := s.checkTeam(ctx, teamID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.queryURLs(ctx, op, `
+	SELECT `+urlColumns+`
+	FROM url WHERE team_id = ? ORDER BY id`, teamID)
+}
+
+// TransferURL меняет владельца ссылки. Если у нового владельца
+// указана команда, она должна существовать.
+func (s *Storage) TransferURL(ctx context.Context, alias string, owner models.Owner) (err error) {
+	const op = "storage.sqlite.TransferURL"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	if owner.TeamID != nil {
+		if err := s.checkTeam(ctx, *owner.TeamID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE url SET owner = ?, team_id = ? WHERE alias = ?", owner.User, owner.TeamID, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// URLsByTag возвращает ссылки с тегом tag. Тег-папка ("marketing")
+// включает и ссылки из вложенных папок ("marketing/2026").
+func (s *Storage) URLsByTag(ctx context.Context, tag string) (_ []models.URL, err error) {
+	const op = "storage.sqlite.URLsByTag"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	return s.queryURLs(ctx, op, `
+	SELECT `+urlColumns+`
+	FROM url WHERE id IN (
+		SELECT url_tag.url_id FROM url_tag JOIN tag ON tag.id = url_tag.tag_id
+		WHERE tag.name = ? OR substr(tag.name, 1, ?) = ?)
+	ORDER BY id`, tag, utf8.RuneCountInString(tag)+1, tag+"/")
+}
+
+// ListTags возвращает теги, которые есть хотя бы у одной ссылки
+func (s *Storage) ListTags(ctx context.Context) (_ []models.Tag, err error) {
+	const op = "storage.sqlite.ListTags"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT tag.name, COUNT(*) FROM tag JOIN url_tag ON url_tag.tag_id = tag.id
+	GROUP BY tag.id ORDER BY tag.name`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+
+	for rows.Next() {
+		var t models.Tag
+
+		if err := rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		tags = append(tags, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// RenameTag переименовывает тег у всех ссылок. Если тег newName уже есть,
+// возвращается ErrTagExists - такие теги нужно объединять через MergeTags.
+func (s *Storage) RenameTag(ctx context.Context, oldName string, newName string) (err error) {
+	const op = "storage.sqlite.RenameTag"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE tag SET name = ? WHERE name = ?", newName, oldName)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, storage.ErrTagExists)
+		}
+
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// MergeTags заменяет теги from на тег to у всех ссылок и удаляет теги from.
+// Тег to создается, если его еще нет. Возвращает число ссылок,
+// получивших тег to.
+func (s *Storage) MergeTags(ctx context.Context, from []string, to string) (_ int64, err error) {
+	const op = "storage.sqlite.MergeTags"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO tag(name) VALUES(?)", to)
+	if err != nil {
+		return 0, fmt.Errorf("%s: create tag: %w", op, err)
+	}
+
+	var merged int64
+
+	for _, name := range from {
+		if name == to {
+			continue
+		}
+
+		var tagID int64
+
+		err := tx.QueryRowContext(ctx, "SELECT id FROM tag WHERE name = ?", name).Scan(&tagID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %q: %w", op, name, storage.ErrTagNotFound)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: find tag: %w", op, err)
+		}
+
+		res, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO url_tag(url_id, tag_id)
+		SELECT url_id, (SELECT id FROM tag WHERE name = ?) FROM url_tag WHERE tag_id = ?`, to, tagID)
+		if err != nil {
+			return 0, fmt.Errorf("%s: move urls: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		merged += n
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM url_tag WHERE tag_id = ?", tagID); err != nil {
+			return 0, fmt.Errorf("%s: untag urls: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM tag WHERE id = ?", tagID); err != nil {
+			return 0, fmt.Errorf("%s: delete tag: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return merged, nil
+}
+
+// SaveURLStatus сохраняет результат проверки доступности ссылки
+func (s *Storage) SaveURLStatus(ctx context.Context, alias string, status int, checkErr string, checkedAt time.Time) (err error) {
+	const op = "storage.sqlite.SaveURLStatus"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx,
+		"UPDATE url SET last_status = ?, check_error = ?, checked_at = ? WHERE alias = ?",
+	)
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	// Ссылку могли удалить во время проверки - это не ошибка
+	if _, err := stmt.ExecContext(ctx, status, checkErr, checkedAt.UTC(), alias); err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveAPIKey сохраняет API-ключ. keyHash - хэш самого ключа.
+func (s *Storage) SaveAPIKey(ctx context.Context, key models.APIKey, keyHash []byte) (_ int64, err error) {
+	const op = "storage.sqlite.SaveAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+	INSERT INTO api_key(name, prefix, key_hash, scopes, created_by, created_at, expires_at)
+	VALUES(?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var expiresAt sql.NullTime
+	if key.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt.UTC(), Valid: true}
+	}
+
+	res, err := stmt.ExecContext(ctx,
+		key.Name, key.Prefix, keyHash, strings.Join(key.Scopes, ","),
+		key.CreatedBy, key.CreatedAt.UTC(), expiresAt,
+	)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAPIKeyExists)
+		}
+
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// APIKeyByPrefix возвращает API-ключ и его хэш по префиксу
+func (s *Storage) APIKeyByPrefix(ctx context.Context, prefix string) (_ models.APIKey, _ []byte, err error) {
+	const op = "storage.sqlite.APIKeyByPrefix"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+	SELECT id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at, key_hash
+	FROM api_key WHERE prefix = ?`)
+	if err != nil {
+		return models.APIKey{}, nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var keyHash []byte
+
+	key, err := scanAPIKey(stmt.QueryRowContext(ctx, prefix), &keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, nil, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return key, keyHash, nil
+}
+
+// ListAPIKeys возвращает все API-ключи без хэшей
+func (s *Storage) ListAPIKeys(ctx context.Context) (_ []models.APIKey, err error) {
+	const op = "storage.sqlite.ListAPIKeys"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+	SELECT id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at
+	FROM api_key ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey отзывает API-ключ
+func (s *Storage) DeleteAPIKey(ctx context.Context, id int64) (err error) {
+	const op = "storage.sqlite.DeleteAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, "DELETE FROM api_key WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey запоминает время последнего использования ключа
+func (s *Storage) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) (err error) {
+	const op = "storage.sqlite.TouchAPIKey"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	stmt, err := s.db.PrepareContext(ctx, "UPDATE api_key SET last_used_at = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, usedAt.UTC(), id); err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAPIKey читает ключ из строки результата. В extra передаются
+// дополнительные колонки, выбранные после основных.
+func scanAPIKey(row scanner, extra ...any) (models.APIKey, error) {
+	var (
+		key                   models.APIKey
+		scopes                string
+		expiresAt, lastUsedAt sql.NullTime
+	)
+
+	dest := append([]any{
+		&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedBy,
+		&key.CreatedAt, &expiresAt, &lastUsedAt,
+	}, extra...)
+
+	if err := row.Scan(dest...); err != nil {
+		return models.APIKey{}, err
+	}
+
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return key, nil
+}
+
+// CreateTeam создает команду вместе с начальным списком участников
+func (s *Storage) CreateTeam(ctx context.Context, team models.Team) (_ int64, err error) {
+	const op = "storage.sqlite.CreateTeam"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO team(name, created_by, created_at) VALUES(?, ?, ?)",
+		team.Name, team.CreatedBy, team.CreatedAt.UTC(),
+	)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrTeamExists)
+		}
+
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	for _, member := range team.Members {
+		_, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO team_member(team_id, member) VALUES(?, ?)", id, member,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: add member: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// AddTeamMember добавляет участника в команду. Повторное добавление
+// не считается ошибкой.
+func (s *Storage) AddTeamMember(ctx context.Context, teamID int64, member string) (err error) {
+	const op = "storage.sqlite.AddTeamMember"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	if err := s.checkTeam(ctx, teamID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO team_member(team_id, member) VALUES(?, ?)", teamID, member,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RemoveTeamMember исключает участника из команды. Ссылки, которые он
+// создал для команды, остаются за командой.
+func (s *Storage) RemoveTeamMember(ctx context.Context, teamID int64, member string) (err error) {
+	const op = "storage.sqlite.RemoveTeamMember"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM team_member WHERE team_id = ? AND member = ?", teamID, member,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// IsTeamMember сообщает, что member состоит в команде
+func (s *Storage) IsTeamMember(ctx context.Context, teamID int64, member string) (_ bool, err error) {
+	const op = "storage.sqlite.IsTeamMember"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	var count int
+
+	err = s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM team_member WHERE team_id = ? AND member = ?", teamID, member,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// checkTeam возвращает ErrTeamNotFound, если команды нет
+func (s *Storage) checkTeam(ctx context.Context, teamID int64) error {
+	var count int
+
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM team WHERE id = ?", teamID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check team: %w", err)
+	}
+
+	if count == 0 {
+		return storage.ErrTeamNotFound
+	}
+
+	return nil
+}
+
+// Vacuum перестраивает файл БД, освобождая место после удалений
+func (s *Storage) Vacuum(ctx context.Context) (err error) {
+	const op = "storage.sqlite.Vacuum"
+
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Close закрывает соединение с БД
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"url-shortener/internal/storage"
+)
+
+var tracer = otel.Tracer("url-shortener/internal/storage/sqlite")
+
+// startSpan начинает дочерний span для вызова хранилища.
+// Пока трейсинг не настроен, глобальный провайдер ничего не делает.
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "sqlite")),
+	)
+}
+
+// endSpan завершает span и отмечает в нем ошибку. Ожидаемые ошибки
+// (ссылка не найдена, алиас занят) ошибкой хранилища не считаются.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+
+		if !expected(err) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+
+	span.End()
+}
+
+func expected(err error) bool {
+	return errors.Is(err, storage.ErrURLNotFound) ||
+		errors.Is(err, storage.ErrURLExists) ||
+		errors.Is(err, storage.ErrAPIKeyNotFound) ||
+		errors.Is(err, storage.ErrAPIKeyExists) ||
+		errors.Is(err, storage.ErrTeamNotFound) ||
+		errors.Is(err, storage.ErrTeamExists) ||
+		errors.Is(err, storage.ErrMemberNotFound) ||
+		errors.Is(err, storage.ErrTagNotFound) ||
+		errors.Is(err, storage.ErrTagExists)
+}
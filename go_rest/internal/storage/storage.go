@@ -5,4 +5,14 @@ import "errors"
 var (
 	ErrURLNotFound = errors.New("url not found")
 	ErrURLExists   = errors.New("url exists")
+
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExists   = errors.New("api key exists")
+
+	ErrTeamNotFound   = errors.New("team not found")
+	ErrTeamExists     = errors.New("team exists")
+	ErrMemberNotFound = errors.New("team member not found")
+
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag exists")
 )
@@ -0,0 +1,216 @@
+package users
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Role - роль пользователя. Роли упорядочены: admin может все,
+// что может writer, а writer - все, что может reader.
+type Role string
+
+const (
+	RoleReader Role = "reader" // Просмотр ссылок
+	RoleWriter Role = "writer" // Создание и удаление ссылок
+	RoleAdmin  Role = "admin"  // Полный доступ
+)
+
+var roleLevels = map[Role]int{
+	RoleReader: 1,
+	RoleWriter: 2,
+	RoleAdmin:  3,
+}
+
+// Valid сообщает, что роль известна
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+
+	return ok
+}
+
+// Allows сообщает, что роль дает права не меньше, чем required
+func (r Role) Allows(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+var ErrInvalidUser = errors.New("invalid user")
+
+// User - учетная запись для доступа к API
+type User struct {
+	Name         string `yaml:"name"`
+	PasswordHash string `yaml:"password_hash"` // bcrypt-хэш пароля
+	Role         Role   `yaml:"role"`
+}
+
+// file - формат файла пользователей
+type file struct {
+	Users []User `yaml:"users"`
+}
+
+// dummyHash используется для неизвестных пользователей, чтобы время
+// ответа не выдавало, существует ли пользователь
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// Users - потокобезопасный набор пользователей, который можно
+// перечитать из файла без перезапуска сервера
+type Users struct {
+	path string
+
+	mu    sync.RWMutex
+	users map[string]User
+	// Кэш успешных проверок: bcrypt медленный, а BasicAuth
+	// присылает пароль в каждом запросе
+	verified map[string][sha256.Size]byte
+}
+
+// Load читает пользователей из YAML-файла
+func Load(path string) (*Users, error) {
+	u := &Users{path: path}
+
+	if err := u.Reload(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// FromList создает набор пользователей без файла
+func FromList(list []User) (*Users, error) {
+	const op = "users.FromList"
+
+	users, err := index(list)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Users{users: users, verified: make(map[string][sha256.Size]byte)}, nil
+}
+
+// Reload перечитывает файл. При ошибке остаются прежние пользователи.
+func (u *Users) Reload() error {
+	const op = "users.Reload"
+
+	if u.path == "" {
+		return nil
+	}
+
+	list, err := ReadFile(u.path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, err := index(list)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.users = users
+	u.verified = make(map[string][sha256.Size]byte)
+
+	return nil
+}
+
+// Len возвращает число пользователей
+func (u *Users) Len() int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return len(u.users)
+}
+
+// Authenticate проверяет имя и пароль и возвращает пользователя
+func (u *Users) Authenticate(name string, password string) (User, bool) {
+	sum := sha256.Sum256([]byte(name + "\x00" + password))
+
+	u.mu.RLock()
+	user, ok := u.users[name]
+	cached, isCached := u.verified[name]
+	u.mu.RUnlock()
+
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+
+		return User{}, false
+	}
+
+	if isCached && cached == sum {
+		return user, true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return User{}, false
+	}
+
+	u.mu.Lock()
+	// Пользователей могли перечитать, пока шла проверка пароля
+	if current, ok := u.users[name]; ok && current.PasswordHash == user.PasswordHash {
+		u.verified[name] = sum
+	}
+	u.mu.Unlock()
+
+	return user, true
+}
+
+// ReadFile читает список пользователей из YAML-файла
+func ReadFile(path string) ([]User, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	return f.Users, nil
+}
+
+// WriteFile сохраняет список пользователей в YAML-файл
+func WriteFile(path string, list []User) error {
+	if _, err := index(list); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(file{Users: list})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+func index(list []User) (map[string]User, error) {
+	users := make(map[string]User, len(list))
+
+	for _, user := range list {
+		if user.Name == "" {
+			return nil, fmt.Errorf("%w: empty name", ErrInvalidUser)
+		}
+
+		if !user.Role.Valid() {
+			return nil, fmt.Errorf("%w: %s: unknown role %q", ErrInvalidUser, user.Name, user.Role)
+		}
+
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("%w: %s: password_hash is not a bcrypt hash", ErrInvalidUser, user.Name)
+		}
+
+		if _, ok := users[user.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate user %s", ErrInvalidUser, user.Name)
+		}
+
+		users[user.Name] = user
+	}
+
+	return users, nil
+}
@@ -0,0 +1,59 @@
+package users_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"url-shortener/internal/users"
+)
+
+func TestUsers_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+
+	write := func(password string, role users.Role) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		require.NoError(t, users.WriteFile(path, []users.User{
+			{Name: "alice", PasswordHash: string(hash), Role: role},
+		}))
+	}
+
+	write("old", users.RoleReader)
+
+	u, err := users.Load(path)
+	require.NoError(t, err)
+
+	user, ok := u.Authenticate("alice", "old")
+	require.True(t, ok)
+	require.Equal(t, users.RoleReader, user.Role)
+
+	// Новый пароль и роль применяются после Reload
+	write("new", users.RoleAdmin)
+	require.NoError(t, u.Reload())
+
+	_, ok = u.Authenticate("alice", "old")
+	require.False(t, ok)
+
+	user, ok = u.Authenticate("alice", "new")
+	require.True(t, ok)
+	require.Equal(t, users.RoleAdmin, user.Role)
+
+	// Битый файл не затирает загруженных пользователей
+	require.NoError(t, os.WriteFile(path, []byte("users:\n  - name: bob\n    role: root\n"), 0o600))
+	require.Error(t, u.Reload())
+
+	_, ok = u.Authenticate("alice", "new")
+	require.True(t, ok)
+}
+
+func TestRole_Allows(t *testing.T) {
+	require.True(t, users.RoleAdmin.Allows(users.RoleWriter))
+	require.True(t, users.RoleWriter.Allows(users.RoleReader))
+	require.False(t, users.RoleReader.Allows(users.RoleWriter))
+	require.False(t, users.Role("root").Allows(users.RoleReader))
+}